@@ -21,6 +21,7 @@ import (
 	"github.com/artie-labs/transfer/lib/destination/ddl"
 	"github.com/artie-labs/transfer/lib/logger"
 	"github.com/artie-labs/transfer/lib/optimization"
+	"github.com/artie-labs/transfer/lib/telemetry/tracing"
 )
 
 type Row struct {
@@ -106,15 +107,23 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		return nil
 	}
 
+	fqName := tableData.ToFqName(ctx, s.Label(), true)
+	_, span := tracing.StartSpan(ctx, "bigquery.get_table_config", tracing.Table(fqName))
 	tableConfig, err := s.getTableConfig(ctx, tableData)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		return err
 	}
 
+	if err = tableData.ValidateColumnTransforms(ctx, tableConfig.Columns().GetColumns()); err != nil {
+		return err
+	}
+
 	log := logger.FromContext(ctx)
 	// Check if all the columns exist in BigQuery
 	srcKeysMissing, targetKeysMissing := columns.Diff(ctx, tableData.ReadOnlyInMemoryCols(),
 		tableConfig.Columns(), tableData.TopicConfig.SoftDelete, tableData.TopicConfig.IncludeArtieUpdatedAt)
+	srcKeysMissing = tableData.WithoutExcludedColumns(ctx, srcKeysMissing)
 	createAlterTableArgs := ddl.AlterTableArgs{
 		Dwh:         s,
 		Tc:          tableConfig,
@@ -125,7 +134,9 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 	}
 
 	// Keys that exist in CDC stream, but not in BigQuery
+	_, span = tracing.StartSpan(ctx, "bigquery.add_columns", tracing.Table(fqName))
 	err = ddl.AlterTable(ctx, createAlterTableArgs, targetKeysMissing...)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		log.WithError(err).Warn("failed to apply alter table")
 		return err
@@ -144,7 +155,9 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		CdcTime:                tableData.LatestCDCTs,
 	}
 
+	_, span = tracing.StartSpan(ctx, "bigquery.drop_columns", tracing.Table(fqName))
 	err = ddl.AlterTable(ctx, deleteAlterTableArgs, srcKeysMissing...)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		log.WithError(err).Warn("failed to apply alter table")
 		return err
@@ -180,12 +193,16 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		ColumnOp:       constants.Add,
 	}
 
-	if err = ddl.AlterTable(ctx, tempAlterTableArgs, tableData.ReadOnlyInMemoryCols().GetColumns()...); err != nil {
+	_, span = tracing.StartSpan(ctx, "bigquery.create_temp_table", tracing.Table(tempAlterTableArgs.FqTableName))
+	err = ddl.AlterTable(ctx, tempAlterTableArgs, tableData.ReadOnlyInMemoryCols().GetColumns()...)
+	tracing.EndSpan(ctx, span, err)
+	if err != nil {
 		return fmt.Errorf("failed to create temp table, error: %v", err)
 	}
 	// End temporary table creation
 
 	// Backfill columns if necessary
+	_, span = tracing.StartSpan(ctx, "bigquery.backfill_columns", tracing.Table(fqName))
 	for _, col := range tableData.ReadOnlyInMemoryCols().GetColumns() {
 		if col.ShouldSkip() {
 			continue
@@ -207,12 +224,15 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 				time.Sleep(time.Duration(jitter.JitterMs(1500, attempts)) * time.Millisecond)
 			} else {
 				defaultVal, _ := col.DefaultValue(ctx, nil)
-				return fmt.Errorf("failed to backfill col: %v, default value: %v, err: %v",
-					col.Name(ctx, nil), defaultVal, err)
+				err = fmt.Errorf("failed to backfill col: %v, default value: %v, err: %v",
+					col.Name(ctx, nil), logger.RedactValue(ctx, defaultVal), err)
+				tracing.EndSpan(ctx, span, err)
+				return err
 			}
 		}
 
 	}
+	tracing.EndSpan(ctx, span, nil)
 
 	// Perform actual merge now
 	rows, err := merge(ctx, tableData)
@@ -222,7 +242,9 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 	}
 
 	tableName := fmt.Sprintf("%s_%s", tableData.Name(ctx, nil), tableData.TempTableSuffix())
+	_, span = tracing.StartSpan(ctx, "bigquery.load_temp_table", tracing.Table(tableName), tracing.Rows(tableData.Rows()))
 	err = s.PutTable(ctx, tableData.TopicConfig.Database, tableName, rows)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		return fmt.Errorf("failed to insert into temp table: %s, error: %v", tableName, err)
 	}
@@ -261,10 +283,13 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		return err
 	}
 
+	_, span = tracing.StartSpan(ctx, "bigquery.merge", tracing.Table(fqName), tracing.Rows(tableData.Rows()), tracing.Query(ctx, mergeQuery))
 	_, err = s.Exec(mergeQuery)
+	tracing.EndSpan(ctx, span, err)
 	// This is above, in the case we have a head of line blocking because of an error
 	// We will not create infinite temporary tables.
-	_ = ddl.DropTemporaryTable(ctx, s, tempAlterTableArgs.FqTableName, false)
+	_, span = tracing.StartSpan(ctx, "bigquery.drop_temp_table", tracing.Table(tempAlterTableArgs.FqTableName))
+	tracing.EndSpan(ctx, span, ddl.DropTemporaryTable(ctx, s, tempAlterTableArgs.FqTableName, false))
 	if err != nil {
 		return err
 	}
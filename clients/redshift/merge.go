@@ -3,6 +3,7 @@ package redshift
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/artie-labs/transfer/lib/sql"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/artie-labs/transfer/lib/logger"
 	"github.com/artie-labs/transfer/lib/optimization"
 	"github.com/artie-labs/transfer/lib/ptr"
+	"github.com/artie-labs/transfer/lib/telemetry/tracing"
 	"github.com/artie-labs/transfer/lib/typing/columns"
 )
 
@@ -22,16 +24,23 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		return nil
 	}
 
+	fqName := tableData.ToFqName(ctx, s.Label(), true)
+	_, span := tracing.StartSpan(ctx, "redshift.get_table_config", tracing.Table(fqName))
 	tableConfig, err := s.getTableConfig(ctx, tableData)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		return err
 	}
 
+	if err = tableData.ValidateColumnTransforms(ctx, tableConfig.Columns().GetColumns()); err != nil {
+		return err
+	}
+
 	log := logger.FromContext(ctx)
-	fqName := tableData.ToFqName(ctx, s.Label(), true)
 	// Check if all the columns exist in Redshift
 	srcKeysMissing, targetKeysMissing := columns.Diff(ctx, tableData.ReadOnlyInMemoryCols(), tableConfig.Columns(),
 		tableData.TopicConfig.SoftDelete, tableData.TopicConfig.IncludeArtieUpdatedAt)
+	srcKeysMissing = tableData.WithoutExcludedColumns(ctx, srcKeysMissing)
 	createAlterTableArgs := ddl.AlterTableArgs{
 		Dwh:         s,
 		Tc:          tableConfig,
@@ -42,7 +51,9 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 	}
 
 	// Keys that exist in CDC stream, but not in Redshift
+	_, span = tracing.StartSpan(ctx, "redshift.add_columns", tracing.Table(fqName))
 	err = ddl.AlterTable(ctx, createAlterTableArgs, targetKeysMissing...)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		log.WithError(err).Warn("failed to apply alter table")
 		return err
@@ -61,7 +72,9 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		CdcTime:                tableData.LatestCDCTs,
 	}
 
+	_, span = tracing.StartSpan(ctx, "redshift.drop_columns", tracing.Table(fqName))
 	err = ddl.AlterTable(ctx, deleteAlterTableArgs, srcKeysMissing...)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		log.WithError(err).Warn("failed to apply alter table")
 		return err
@@ -88,11 +101,15 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 
 	// Temporary tables cannot specify schemas, so we just prefix it instead.
 	temporaryTableName := fmt.Sprintf("%s_%s", tableData.ToFqName(ctx, s.Label(), false), tableData.TempTableSuffix())
-	if err = s.prepareTempTable(ctx, tableData, tableConfig, temporaryTableName); err != nil {
+	_, span = tracing.StartSpan(ctx, "redshift.load_temp_table", tracing.Table(temporaryTableName), tracing.Rows(tableData.Rows()))
+	err = s.prepareTempTable(ctx, tableData, tableConfig, temporaryTableName)
+	tracing.EndSpan(ctx, span, err)
+	if err != nil {
 		return err
 	}
 
 	// Now iterate over all the in-memory cols and see which one requires backfill.
+	_, span = tracing.StartSpan(ctx, "redshift.backfill_columns", tracing.Table(fqName))
 	for _, col := range tableData.ReadOnlyInMemoryCols().GetColumns() {
 		if col.ShouldSkip() {
 			continue
@@ -101,8 +118,10 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		err = utils.BackfillColumn(ctx, s, col, tableData.ToFqName(ctx, s.Label(), true))
 		if err != nil {
 			defaultVal, _ := col.DefaultValue(ctx, nil)
-			return fmt.Errorf("failed to backfill col: %v, default value: %v, error: %v",
-				col.Name(ctx, nil), defaultVal, err)
+			err = fmt.Errorf("failed to backfill col: %v, default value: %v, error: %v",
+				col.Name(ctx, nil), logger.RedactValue(ctx, defaultVal), err)
+			tracing.EndSpan(ctx, span, err)
+			return err
 		}
 
 		tableConfig.Columns().UpsertColumn(col.Name(ctx, nil), columns.UpsertColumnArg{
@@ -110,6 +129,8 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		})
 	}
 
+	tracing.EndSpan(ctx, span, nil)
+
 	// Prepare merge statement
 	mergeParts, err := dml.MergeStatementParts(ctx, &dml.MergeArgument{
 		FqTableName: fqName,
@@ -131,6 +152,21 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		return fmt.Errorf("failed to generate merge statement, err: %v", err)
 	}
 
+	_, span = tracing.StartSpan(ctx, "redshift.merge", tracing.Table(fqName), tracing.Rows(tableData.Rows()),
+		tracing.Query(ctx, strings.Join(mergeParts, ";\n")))
+	err = s.executeMergeParts(mergeParts)
+	tracing.EndSpan(ctx, span, err)
+	if err != nil {
+		return err
+	}
+
+	_, span = tracing.StartSpan(ctx, "redshift.drop_temp_table", tracing.Table(temporaryTableName))
+	tracing.EndSpan(ctx, span, ddl.DropTemporaryTable(ctx, s, temporaryTableName, false))
+	return err
+}
+
+// executeMergeParts runs all the merge parts within a single transaction.
+func (s *Store) executeMergeParts(mergeParts []string) error {
 	tx, err := s.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to start tx, err: %v", err)
@@ -147,6 +183,5 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		return fmt.Errorf("failed to merge, parts: %v, err: %v", mergeParts, err)
 	}
 
-	_ = ddl.DropTemporaryTable(ctx, s, temporaryTableName, false)
-	return err
+	return nil
 }
@@ -26,29 +26,35 @@ func (s *Store) Sweep(ctx context.Context) error {
 
 	dbAndSchemaPairs := kafkalib.GetUniqueDatabaseAndSchema(tcs)
 	for _, dbAndSchemaPair := range dbAndSchemaPairs {
+		// Templated schemas are matched with ILIKE.
+		schemaClause := fmt.Sprintf("n.nspname = '%s'", dbAndSchemaPair.Schema)
+		if dbAndSchemaPair.SchemaIsPattern() {
+			schemaClause = fmt.Sprintf("n.nspname ILIKE '%s' ESCAPE '%s'", dbAndSchemaPair.Schema, kafkalib.PatternEscape)
+		}
+
 		// ILIKE is used to be case-insensitive since Snowflake stores all the tables in UPPER.
 		var rows *sql.Rows
 		rows, err = s.Store.Query(fmt.Sprintf(
-			`select c.relname, d.description from pg_catalog.pg_description d
+			`select n.nspname, c.relname, d.description from pg_catalog.pg_description d
 JOIN pg_class c on d.objoid = c.oid
 JOIN pg_catalog.pg_namespace n on n.oid = c.relnamespace
-WHERE n.nspname = '%s' and c.relname ILIKE '%s';`,
-			dbAndSchemaPair.Schema,
+WHERE %s and c.relname ILIKE '%s';`,
+			schemaClause,
 			"%"+constants.ArtiePrefix+"%"))
 		if err != nil {
 			return err
 		}
 
 		for rows != nil && rows.Next() {
-			var tableName, comment string
-			err = rows.Scan(&tableName, &comment)
+			var schemaName, tableName, comment string
+			err = rows.Scan(&schemaName, &tableName, &comment)
 			if err != nil {
 				return err
 			}
 
 			if ddl.ShouldDelete(comment) {
-				err = ddl.DropTemporaryTable(ctx, s,
-					fmt.Sprintf("%s.%s.%s", dbAndSchemaPair.Database, dbAndSchemaPair.Schema, tableName), true)
+				// The catalog only has the tables within the database we're connected to, same as ToFqName.
+				err = ddl.DropTemporaryTable(ctx, s, fmt.Sprintf("%s.%s", schemaName, tableName), true)
 				if err != nil {
 					return err
 				}
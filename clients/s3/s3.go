@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/artie-labs/transfer/lib/ptr"
 
 	"github.com/artie-labs/transfer/lib/stringutil"
@@ -21,6 +23,7 @@ import (
 	"github.com/artie-labs/transfer/lib/config/constants"
 	"github.com/artie-labs/transfer/lib/optimization"
 	"github.com/artie-labs/transfer/lib/parquetutil"
+	"github.com/artie-labs/transfer/lib/telemetry/tracing"
 	"github.com/artie-labs/transfer/lib/typing"
 	"github.com/artie-labs/transfer/lib/typing/columns"
 	"github.com/xitongsys/parquet-go-source/local"
@@ -72,6 +75,32 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		return nil
 	}
 
+	fqName := tableData.ToFqName(ctx, s.Label(), false)
+	_, span := tracing.StartSpan(ctx, "s3.write_parquet", tracing.Table(fqName), tracing.Rows(tableData.Rows()))
+	fp, err := writeParquetFile(ctx, tableData)
+	tracing.EndSpan(ctx, span, err)
+	if err != nil {
+		return err
+	}
+
+	_, span = tracing.StartSpan(ctx, "s3.upload", tracing.Table(fqName), attribute.String("bucket", s.Settings.Bucket))
+	_, err = s3lib.UploadLocalFileToS3(ctx, s3lib.UploadArgs{
+		Bucket:                     s.Settings.Bucket,
+		OptionalS3Prefix:           s.ObjectPrefix(ctx, tableData),
+		FilePath:                   fp,
+		OverrideAWSAccessKeyID:     ptr.ToString(s.Settings.AwsAccessKeyID),
+		OverrideAWSAccessKeySecret: ptr.ToString(s.Settings.AwsSecretAccessKey),
+	})
+	tracing.EndSpan(ctx, span, err)
+	if err != nil {
+		return fmt.Errorf("failed to upload file to s3, err: %v", err)
+	}
+
+	return os.RemoveAll(fp)
+}
+
+// writeParquetFile will write the rows into a local parquet file and return the file path.
+func writeParquetFile(ctx context.Context, tableData *optimization.TableData) (string, error) {
 	var cols []columns.Column
 	for _, col := range tableData.ReadOnlyInMemoryCols().GetColumns() {
 		if col.KindDetails == typing.Invalid {
@@ -83,18 +112,18 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 
 	schema, err := parquetutil.GenerateJSONSchema(ctx, cols)
 	if err != nil {
-		return fmt.Errorf("failed to generate parquet schema, err: %v", err)
+		return "", fmt.Errorf("failed to generate parquet schema, err: %v", err)
 	}
 
 	fp := fmt.Sprintf("/tmp/%v_%s.parquet.gz", tableData.LatestCDCTs.UnixMilli(), stringutil.Random(4))
 	fw, err := local.NewLocalFileWriter(fp)
 	if err != nil {
-		return fmt.Errorf("failed to create a local parquet file, err: %v", err)
+		return "", fmt.Errorf("failed to create a local parquet file, err: %v", err)
 	}
 
 	pw, err := writer.NewJSONWriter(schema, fw, 4)
 	if err != nil {
-		return fmt.Errorf("failed to instantiate parquet writer, err: %v", err)
+		return "", fmt.Errorf("failed to instantiate parquet writer, err: %v", err)
 	}
 
 	pw.CompressionType = parquet.CompressionCodec_GZIP
@@ -103,12 +132,12 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 		for _, col := range tableData.ReadOnlyInMemoryCols().GetColumnsToUpdate(ctx, nil) {
 			colKind, isOk := tableData.ReadOnlyInMemoryCols().GetColumn(col)
 			if !isOk {
-				return fmt.Errorf("expected column: %v to exist in readOnlyInMemoryCols(...) but it does not", col)
+				return "", fmt.Errorf("expected column: %v to exist in readOnlyInMemoryCols(...) but it does not", col)
 			}
 
 			value, err := parquetutil.ParseValue(ctx, val[col], colKind)
 			if err != nil {
-				return fmt.Errorf("failed to parse value, err: %v, value: %v, column: %v", err, val[col], col)
+				return "", fmt.Errorf("failed to parse value, err: %v, value: %v, column: %v", err, val[col], col)
 			}
 
 			row[col] = value
@@ -116,33 +145,23 @@ func (s *Store) Merge(ctx context.Context, tableData *optimization.TableData) er
 
 		rowBytes, err := json.Marshal(row)
 		if err != nil {
-			return fmt.Errorf("failed to marshal row, err: %v", err)
+			return "", fmt.Errorf("failed to marshal row, err: %v", err)
 		}
 
 		if err = pw.Write(string(rowBytes)); err != nil {
-			return fmt.Errorf("failed to write row, err: %v", err)
+			return "", fmt.Errorf("failed to write row, err: %v", err)
 		}
 	}
 
 	if err = pw.WriteStop(); err != nil {
-		return fmt.Errorf("failed to write stop, err: %v", err)
+		return "", fmt.Errorf("failed to write stop, err: %v", err)
 	}
 
 	if err = fw.Close(); err != nil {
-		return fmt.Errorf("failed to close filewriter, err: %v", err)
-	}
-
-	if _, err = s3lib.UploadLocalFileToS3(ctx, s3lib.UploadArgs{
-		Bucket:                     s.Settings.Bucket,
-		OptionalS3Prefix:           s.ObjectPrefix(ctx, tableData),
-		FilePath:                   fp,
-		OverrideAWSAccessKeyID:     ptr.ToString(s.Settings.AwsAccessKeyID),
-		OverrideAWSAccessKeySecret: ptr.ToString(s.Settings.AwsSecretAccessKey),
-	}); err != nil {
-		return fmt.Errorf("failed to upload file to s3, err: %v", err)
+		return "", fmt.Errorf("failed to close filewriter, err: %v", err)
 	}
 
-	return os.RemoveAll(fp)
+	return fp, nil
 }
 
 func LoadStore(ctx context.Context, settings *config.S3Settings) (*Store, error) {
@@ -21,6 +21,7 @@ import (
 	"github.com/artie-labs/transfer/lib/destination/types"
 	"github.com/artie-labs/transfer/lib/logger"
 	"github.com/artie-labs/transfer/lib/optimization"
+	"github.com/artie-labs/transfer/lib/telemetry/tracing"
 )
 
 // prepareTempTable does the following:
@@ -116,15 +117,23 @@ func (s *Store) mergeWithStages(ctx context.Context, tableData *optimization.Tab
 	}
 
 	fqName := tableData.ToFqName(ctx, constants.Snowflake, true)
+	_, span := tracing.StartSpan(ctx, "snowflake.get_table_config", tracing.Table(fqName))
 	tableConfig, err := s.getTableConfig(ctx, fqName, tableData.TopicConfig.DropDeletedColumns)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		return err
 	}
 
+	if err = tableData.ValidateColumnTransforms(ctx, tableConfig.Columns().GetColumns()); err != nil {
+		return err
+	}
+
 	log := logger.FromContext(ctx)
 	// Check if all the columns exist in Snowflake
 	srcKeysMissing, targetKeysMissing := columns.Diff(ctx, tableData.ReadOnlyInMemoryCols(), tableConfig.Columns(),
 		tableData.TopicConfig.SoftDelete, tableData.TopicConfig.IncludeArtieUpdatedAt)
+	// Excluded columns are never dropped, even if DropDeletedColumns is enabled.
+	srcKeysMissing = tableData.WithoutExcludedColumns(ctx, srcKeysMissing)
 	createAlterTableArgs := ddl.AlterTableArgs{
 		Dwh:         s,
 		Tc:          tableConfig,
@@ -135,7 +144,9 @@ func (s *Store) mergeWithStages(ctx context.Context, tableData *optimization.Tab
 	}
 
 	// Keys that exist in CDC stream, but not in Snowflake
+	_, span = tracing.StartSpan(ctx, "snowflake.add_columns", tracing.Table(fqName))
 	err = ddl.AlterTable(ctx, createAlterTableArgs, targetKeysMissing...)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		log.WithError(err).Warn("failed to apply alter table")
 		return err
@@ -154,7 +165,9 @@ func (s *Store) mergeWithStages(ctx context.Context, tableData *optimization.Tab
 		CdcTime:                tableData.LatestCDCTs,
 	}
 
+	_, span = tracing.StartSpan(ctx, "snowflake.drop_columns", tracing.Table(fqName))
 	err = ddl.AlterTable(ctx, deleteAlterTableArgs, srcKeysMissing...)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		log.WithError(err).Warn("failed to apply alter table")
 		return err
@@ -179,11 +192,15 @@ func (s *Store) mergeWithStages(ctx context.Context, tableData *optimization.Tab
 
 	tableData.UpdateInMemoryColumnsFromDestination(ctx, tableConfig.Columns().GetColumns()...)
 	temporaryTableName := fmt.Sprintf("%s_%s", tableData.ToFqName(ctx, s.Label(), false), tableData.TempTableSuffix())
-	if err = s.prepareTempTable(ctx, tableData, tableConfig, temporaryTableName); err != nil {
+	_, span = tracing.StartSpan(ctx, "snowflake.load_temp_table", tracing.Table(temporaryTableName), tracing.Rows(tableData.Rows()))
+	err = s.prepareTempTable(ctx, tableData, tableConfig, temporaryTableName)
+	tracing.EndSpan(ctx, span, err)
+	if err != nil {
 		return err
 	}
 
 	// Now iterate over all the in-memory cols and see which one requires backfill.
+	_, span = tracing.StartSpan(ctx, "snowflake.backfill_columns", tracing.Table(fqName))
 	for _, col := range tableData.ReadOnlyInMemoryCols().GetColumns() {
 		if col.ShouldSkip() {
 			continue
@@ -192,8 +209,10 @@ func (s *Store) mergeWithStages(ctx context.Context, tableData *optimization.Tab
 		err = utils.BackfillColumn(ctx, s, col, tableData.ToFqName(ctx, s.Label(), true))
 		if err != nil {
 			defaultVal, _ := col.DefaultValue(ctx, nil)
-			return fmt.Errorf("failed to backfill col: %v, default value: %v, error: %v",
-				col.Name(ctx, nil), defaultVal, err)
+			err = fmt.Errorf("failed to backfill col: %v, default value: %v, error: %v",
+				col.Name(ctx, nil), logger.RedactValue(ctx, defaultVal), err)
+			tracing.EndSpan(ctx, span, err)
+			return err
 		}
 
 		tableConfig.Columns().UpsertColumn(col.Name(ctx, nil), columns.UpsertColumnArg{
@@ -201,6 +220,8 @@ func (s *Store) mergeWithStages(ctx context.Context, tableData *optimization.Tab
 		})
 	}
 
+	tracing.EndSpan(ctx, span, nil)
+
 	// Prepare merge statement
 	mergeQuery, err := dml.MergeStatement(ctx, &dml.MergeArgument{
 		FqTableName:   tableData.ToFqName(ctx, constants.Snowflake, true),
@@ -219,11 +240,14 @@ func (s *Store) mergeWithStages(ctx context.Context, tableData *optimization.Tab
 	}
 
 	log.WithField("query", mergeQuery).Debug("executing...")
+	_, span = tracing.StartSpan(ctx, "snowflake.merge", tracing.Table(fqName), tracing.Rows(tableData.Rows()), tracing.Query(ctx, mergeQuery))
 	_, err = s.Exec(mergeQuery)
+	tracing.EndSpan(ctx, span, err)
 	if err != nil {
 		return err
 	}
 
-	_ = ddl.DropTemporaryTable(ctx, s, temporaryTableName, false)
+	_, span = tracing.StartSpan(ctx, "snowflake.drop_temp_table", tracing.Table(temporaryTableName))
+	tracing.EndSpan(ctx, span, ddl.DropTemporaryTable(ctx, s, temporaryTableName, false))
 	return err
 }
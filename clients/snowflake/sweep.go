@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/artie-labs/transfer/lib/destination/ddl"
 
@@ -13,6 +14,88 @@ import (
 	"github.com/artie-labs/transfer/lib/logger"
 )
 
+// databasesMatching lists the databases that match the pair's templated database, since the information schema is per database.
+func (s *Store) databasesMatching(dbAndSchemaPair kafkalib.DatabaseSchemaPair) ([]string, error) {
+	rows, err := s.Store.Query("SHOW TERSE DATABASES")
+	if err != nil {
+		return nil, err
+	}
+
+	if rows == nil {
+		return nil, nil
+	}
+
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	nameIdx := -1
+	for idx, col := range cols {
+		if strings.EqualFold(col, "name") {
+			nameIdx = idx
+		}
+	}
+
+	if nameIdx == -1 {
+		return nil, fmt.Errorf("failed to find the name column, columns: %v", cols)
+	}
+
+	var databases []string
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		for idx := range values {
+			values[idx] = new(sql.NullString)
+		}
+
+		if err = rows.Scan(values...); err != nil {
+			return nil, err
+		}
+
+		if name := values[nameIdx].(*sql.NullString).String; dbAndSchemaPair.MatchesDatabase(name) {
+			databases = append(databases, name)
+		}
+	}
+
+	return databases, rows.Err()
+}
+
+func (s *Store) sweepDatabase(ctx context.Context, database string, dbAndSchemaPair kafkalib.DatabaseSchemaPair) error {
+	// Templated schemas are matched with ILIKE.
+	schemaClause := fmt.Sprintf("table_schema = UPPER('%s')", dbAndSchemaPair.Schema)
+	if dbAndSchemaPair.SchemaIsPattern() {
+		schemaClause = fmt.Sprintf("table_schema ILIKE '%s' ESCAPE '%s'", dbAndSchemaPair.Schema, kafkalib.PatternEscape)
+	}
+
+	// ILIKE is used to be case-insensitive since Snowflake stores all the tables in UPPER.
+	rows, err := s.Store.Query(fmt.Sprintf(
+		`SELECT table_schema, table_name, comment FROM %s.information_schema.tables where table_name ILIKE '%s' AND %s`,
+		database,
+		"%"+constants.ArtiePrefix+"%", schemaClause))
+	if err != nil {
+		return err
+	}
+
+	for rows != nil && rows.Next() {
+		var schemaName, tableName, comment string
+		err = rows.Scan(&schemaName, &tableName, &comment)
+		if err != nil {
+			return err
+		}
+
+		if ddl.ShouldDelete(comment) {
+			err = ddl.DropTemporaryTable(ctx, s,
+				fmt.Sprintf("%s.%s.%s", database, schemaName, tableName), true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (s *Store) Sweep(ctx context.Context) error {
 	logger.FromContext(ctx).Info("looking to see if there are any dangling artie temporary tables to delete...")
 	// Find all the database and schema pairings
@@ -27,29 +110,17 @@ func (s *Store) Sweep(ctx context.Context) error {
 
 	dbAndSchemaPairs := kafkalib.GetUniqueDatabaseAndSchema(tcs)
 	for _, dbAndSchemaPair := range dbAndSchemaPairs {
-		// ILIKE is used to be case-insensitive since Snowflake stores all the tables in UPPER.
-		var rows *sql.Rows
-		rows, err = s.Store.Query(fmt.Sprintf(
-			`SELECT table_name, comment FROM %s.information_schema.tables where table_name ILIKE '%s' AND table_schema = UPPER('%s')`,
-			dbAndSchemaPair.Database,
-			"%"+constants.ArtiePrefix+"%", dbAndSchemaPair.Schema))
-		if err != nil {
-			return err
-		}
-
-		for rows != nil && rows.Next() {
-			var tableName, comment string
-			err = rows.Scan(&tableName, &comment)
+		databases := []string{dbAndSchemaPair.Database}
+		if dbAndSchemaPair.DatabaseIsPattern() {
+			databases, err = s.databasesMatching(dbAndSchemaPair)
 			if err != nil {
-				return err
+				return fmt.Errorf("failed to list databases, pattern: %s, err: %v", dbAndSchemaPair.Database, err)
 			}
+		}
 
-			if ddl.ShouldDelete(comment) {
-				err = ddl.DropTemporaryTable(ctx, s,
-					fmt.Sprintf("%s.%s.%s", dbAndSchemaPair.Database, dbAndSchemaPair.Schema, tableName), true)
-				if err != nil {
-					return err
-				}
+		for _, database := range databases {
+			if err = s.sweepDatabase(ctx, database, dbAndSchemaPair); err != nil {
+				return err
 			}
 		}
 	}
@@ -42,5 +42,57 @@ func (s *SnowflakeTestSuite) TestSweep() {
 
 	assert.NoError(s.T(), s.stageStore.Sweep(s.ctx))
 	query, _ := s.fakeStageStore.QueryArgsForCall(0)
-	assert.Equal(s.T(), `SELECT table_name, comment FROM db.information_schema.tables where table_name ILIKE '%__artie%' AND table_schema = UPPER('schema')`, query)
+	assert.Equal(s.T(), `SELECT table_schema, table_name, comment FROM db.information_schema.tables where table_name ILIKE '%__artie%' AND table_schema = UPPER('schema')`, query)
+}
+
+func (s *SnowflakeTestSuite) TestSweep_NameTemplates() {
+	s.ctx = config.InjectSettingsIntoContext(s.ctx, &config.Settings{
+		Config: &config.Config{
+			Queue:                constants.Kafka,
+			Output:               constants.Snowflake,
+			BufferRows:           5,
+			FlushSizeKb:          50,
+			FlushIntervalSeconds: 50,
+			Kafka: &config.Kafka{
+				GroupID:         "artie",
+				BootstrapServer: "localhost:9092",
+				TopicConfigs: []*kafkalib.TopicConfig{
+					{
+						Database:  "db",
+						Schema:    "schema",
+						Topic:     "topic1",
+						CDCFormat: constants.DBZPostgresFormat,
+						NameTemplates: &kafkalib.NameTemplates{
+							Database: "raw",
+							Schema:   "{source_db}__{source_schema}",
+							Case:     kafkalib.NameCaseUpper,
+						},
+					},
+					{
+						Database:  "db",
+						Schema:    "schema",
+						Topic:     "topic2",
+						CDCFormat: constants.DBZPostgresFormat,
+						// The database depends on the source, so the databases will be listed.
+						NameTemplates: &kafkalib.NameTemplates{
+							Database: "{source_db}",
+						},
+					},
+				},
+			},
+		},
+	})
+
+	assert.NoError(s.T(), s.stageStore.Sweep(s.ctx))
+	assert.Equal(s.T(), 2, s.fakeStageStore.QueryCallCount())
+	var queries []string
+	for idx := 0; idx < s.fakeStageStore.QueryCallCount(); idx++ {
+		query, _ := s.fakeStageStore.QueryArgsForCall(idx)
+		queries = append(queries, query)
+	}
+
+	assert.ElementsMatch(s.T(), []string{
+		`SELECT table_schema, table_name, comment FROM RAW.information_schema.tables where table_name ILIKE '%__artie%' AND table_schema ILIKE '%!_!_%' ESCAPE '!'`,
+		"SHOW TERSE DATABASES",
+	}, queries)
 }
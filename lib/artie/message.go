@@ -7,6 +7,8 @@ import (
 
 	"cloud.google.com/go/pubsub"
 	"github.com/artie-labs/transfer/lib/telemetry/metrics"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
 	"github.com/segmentio/kafka-go"
 )
 
@@ -16,6 +18,8 @@ const (
 	Invalid Kind = iota
 	Kafka
 	PubSub
+	Kinesis
+	File
 )
 
 type pubsubWrapper struct {
@@ -23,9 +27,31 @@ type pubsubWrapper struct {
 	*pubsub.Message
 }
 
+// KinesisRecord is a Kinesis record along with the stream and shard that it was read from.
+type KinesisRecord struct {
+	Stream  string
+	ShardID string
+	types.Record
+}
+
+// FileRecord is a record that has been replayed from a local file, Path and Line are where it was read from.
+type FileRecord struct {
+	Path      string
+	Line      int
+	Topic     string
+	Partition string
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+	Timestamp time.Time
+}
+
 type Message struct {
 	KafkaMsg *kafka.Message
 	PubSub   *pubsubWrapper
+	Kinesis  *KinesisRecord
+	File     *FileRecord
 }
 
 func NewMessage(kafkaMsg *kafka.Message, pubsubMsg *pubsub.Message, topic string) Message {
@@ -44,6 +70,41 @@ func NewMessage(kafkaMsg *kafka.Message, pubsubMsg *pubsub.Message, topic string
 	return msg
 }
 
+func NewKinesisMessage(record types.Record, stream, shardID string) Message {
+	return Message{
+		Kinesis: &KinesisRecord{
+			Stream:  stream,
+			ShardID: shardID,
+			Record:  record,
+		},
+	}
+}
+
+func NewFileMessage(record FileRecord) Message {
+	return Message{File: &record}
+}
+
+// AckHandle returns a copy of the message that only retains what we need to acknowledge it once the rows have been flushed.
+// Pub/Sub requires every message to be acked, so we drop the payload rather than holding onto it until the next flush.
+// Other kinds are returned as-is since we only keep the latest message per partition.
+func (m *Message) AckHandle() Message {
+	if m.PubSub == nil {
+		return *m
+	}
+
+	// Copying the message will keep its ack handler.
+	pubsubMsg := *m.PubSub.Message
+	pubsubMsg.Data = nil
+	pubsubMsg.Attributes = nil
+	pubsubMsg.OrderingKey = ""
+	return Message{
+		PubSub: &pubsubWrapper{
+			topic:   m.PubSub.topic,
+			Message: &pubsubMsg,
+		},
+	}
+}
+
 func (m *Message) Kind() Kind {
 	if m.KafkaMsg != nil {
 		return Kafka
@@ -53,6 +114,14 @@ func (m *Message) Kind() Kind {
 		return PubSub
 	}
 
+	if m.Kinesis != nil {
+		return Kinesis
+	}
+
+	if m.File != nil {
+		return File
+	}
+
 	return Invalid
 }
 
@@ -74,6 +143,14 @@ func (m *Message) PublishTime() time.Time {
 		return m.PubSub.PublishTime
 	}
 
+	if m.Kinesis != nil {
+		return aws.ToTime(m.Kinesis.ApproximateArrivalTimestamp)
+	}
+
+	if m.File != nil {
+		return m.File.Timestamp
+	}
+
 	return time.Time{}
 }
 
@@ -86,9 +163,27 @@ func (m *Message) Topic() string {
 		return m.PubSub.topic
 	}
 
+	if m.Kinesis != nil {
+		return m.Kinesis.Stream
+	}
+
+	if m.File != nil {
+		return m.File.Topic
+	}
+
 	return ""
 }
 
+// ToPartitionKey returns the key that the partition's latest message(s) are kept under.
+// The topic is included since a table may be buffering messages from more than one topic.
+func ToPartitionKey(topic, partition string) string {
+	return fmt.Sprintf("%s#%s", topic, partition)
+}
+
+func (m *Message) PartitionKey() string {
+	return ToPartitionKey(m.Topic(), m.Partition())
+}
+
 func (m *Message) Partition() string {
 	if m.KafkaMsg != nil {
 		return fmt.Sprint(m.KafkaMsg.Partition)
@@ -99,9 +194,55 @@ func (m *Message) Partition() string {
 		return "no_partition"
 	}
 
+	if m.Kinesis != nil {
+		return m.Kinesis.ShardID
+	}
+
+	if m.File != nil {
+		return m.File.Partition
+	}
+
 	return ""
 }
 
+// Offset returns the Kafka offset of the message, or the original offset of a replayed record.
+// Pub/Sub does not have offsets and Kinesis sequence numbers do not fit into an int64, so it will return 0 for both.
+func (m *Message) Offset() int64 {
+	if m.KafkaMsg != nil {
+		return m.KafkaMsg.Offset
+	}
+
+	if m.File != nil {
+		return m.File.Offset
+	}
+
+	return 0
+}
+
+// Headers returns the Kafka headers, the Pub/Sub attributes or the replayed record's headers.
+func (m *Message) Headers() map[string]string {
+	headers := make(map[string]string)
+	if m.KafkaMsg != nil {
+		for _, header := range m.KafkaMsg.Headers {
+			headers[header.Key] = string(header.Value)
+		}
+	}
+
+	if m.PubSub != nil {
+		for key, value := range m.PubSub.Attributes {
+			headers[key] = value
+		}
+	}
+
+	if m.File != nil {
+		for key, value := range m.File.Headers {
+			headers[key] = value
+		}
+	}
+
+	return headers
+}
+
 func (m *Message) Key() []byte {
 	if m.KafkaMsg != nil {
 		return m.KafkaMsg.Key
@@ -111,6 +252,14 @@ func (m *Message) Key() []byte {
 		return []byte(m.PubSub.OrderingKey)
 	}
 
+	if m.Kinesis != nil {
+		return []byte(aws.ToString(m.Kinesis.PartitionKey))
+	}
+
+	if m.File != nil {
+		return m.File.Key
+	}
+
 	return nil
 }
 
@@ -123,5 +272,13 @@ func (m *Message) Value() []byte {
 		return m.PubSub.Data
 	}
 
+	if m.Kinesis != nil {
+		return m.Kinesis.Data
+	}
+
+	if m.File != nil {
+		return m.File.Value
+	}
+
 	return nil
 }
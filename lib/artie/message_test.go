@@ -1,10 +1,14 @@
 package artie
 
 import (
+	"testing"
+	"time"
+
 	"cloud.google.com/go/pubsub"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 const keyString = "Struct{id=12}"
@@ -27,6 +31,33 @@ func TestNewMessage_Pubsub(t *testing.T) {
 	assert.Equal(t, "database.schema.table", msg.Topic())
 }
 
+func TestMessage_AckHandle(t *testing.T) {
+	pubsubMsg := &pubsub.Message{
+		ID:          "msg-id",
+		Data:        []byte("hello_world"),
+		Attributes:  map[string]string{"foo": "bar"},
+		OrderingKey: keyString,
+	}
+
+	msg := NewMessage(nil, pubsubMsg, "topic")
+	handle := msg.AckHandle()
+	assert.Equal(t, PubSub, handle.Kind())
+	assert.Equal(t, "topic", handle.Topic())
+	assert.Equal(t, "no_partition", handle.Partition())
+	assert.Equal(t, "msg-id", handle.PubSub.ID)
+	assert.Nil(t, handle.Value())
+	assert.Empty(t, handle.Key())
+	assert.Empty(t, handle.Headers())
+
+	// The original message is left untouched.
+	assert.Equal(t, "hello_world", string(msg.Value()))
+	assert.Equal(t, keyString, string(msg.Key()))
+
+	kafkaMsg := &kafka.Message{Topic: "test_topic", Value: []byte("kafka_value")}
+	msg = NewMessage(kafkaMsg, nil, "")
+	assert.Equal(t, msg, msg.AckHandle())
+}
+
 func TestNewMessage(t *testing.T) {
 	kafkaMsg := &kafka.Message{
 		Topic:     "test_topic",
@@ -38,6 +69,70 @@ func TestNewMessage(t *testing.T) {
 	msg := NewMessage(kafkaMsg, nil, "")
 	assert.Equal(t, "test_topic", msg.Topic())
 	assert.Equal(t, "5", msg.Partition())
+	assert.Equal(t, "test_topic#5", msg.PartitionKey())
 	assert.Equal(t, keyString, string(msg.Key()))
 	assert.Equal(t, "kafka_value", string(msg.Value()))
 }
+
+func TestMessage_OffsetAndHeaders(t *testing.T) {
+	kafkaMsg := &kafka.Message{
+		Topic:   "test_topic",
+		Offset:  123,
+		Headers: []kafka.Header{{Key: "foo", Value: []byte("bar")}},
+	}
+
+	msg := NewMessage(kafkaMsg, nil, "")
+	assert.Equal(t, int64(123), msg.Offset())
+	assert.Equal(t, map[string]string{"foo": "bar"}, msg.Headers())
+
+	pubsubMsg := &pubsub.Message{
+		Attributes: map[string]string{"abc": "def"},
+	}
+
+	msg = NewMessage(nil, pubsubMsg, "topic")
+	assert.Equal(t, int64(0), msg.Offset())
+	assert.Equal(t, map[string]string{"abc": "def"}, msg.Headers())
+}
+
+func TestNewKinesisMessage(t *testing.T) {
+	arrival := time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC)
+	msg := NewKinesisMessage(types.Record{
+		Data:                        []byte("kinesis_value"),
+		PartitionKey:                aws.String(keyString),
+		SequenceNumber:              aws.String("49590338271490256608559692538361571095921575989136588898"),
+		ApproximateArrivalTimestamp: &arrival,
+	}, "stream", "shardId-000000000001")
+
+	assert.Equal(t, Kinesis, msg.Kind())
+	assert.Equal(t, "stream", msg.Topic())
+	assert.Equal(t, "shardId-000000000001", msg.Partition())
+	assert.Equal(t, keyString, string(msg.Key()))
+	assert.Equal(t, "kinesis_value", string(msg.Value()))
+	assert.Equal(t, arrival, msg.PublishTime())
+	assert.Equal(t, int64(0), msg.Offset())
+	assert.Empty(t, msg.Headers())
+}
+
+func TestNewFileMessage(t *testing.T) {
+	timestamp := time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC)
+	msg := NewFileMessage(FileRecord{
+		Path:      "/tmp/replay.jsonl",
+		Line:      5,
+		Topic:     "topic",
+		Partition: "3",
+		Offset:    99,
+		Key:       []byte(keyString),
+		Value:     []byte("file_value"),
+		Headers:   map[string]string{"foo": "bar"},
+		Timestamp: timestamp,
+	})
+
+	assert.Equal(t, File, msg.Kind())
+	assert.Equal(t, "topic", msg.Topic())
+	assert.Equal(t, "3", msg.Partition())
+	assert.Equal(t, int64(99), msg.Offset())
+	assert.Equal(t, keyString, string(msg.Key()))
+	assert.Equal(t, "file_value", string(msg.Value()))
+	assert.Equal(t, map[string]string{"foo": "bar"}, msg.Headers())
+	assert.Equal(t, timestamp, msg.PublishTime())
+}
@@ -0,0 +1,157 @@
+package avro
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// Codec decodes Avro binary data into the same shape as Kafka Connect's JsonConverter would've emitted it.
+// Logical types are not converted, so timestamps stay as numbers and decimals stay as (base64 encoded) bytes.
+// This allows the rest of Transfer to parse them the same way it does for JSON.
+type Codec struct {
+	schema *Type
+	codec  *goavro.Codec
+}
+
+var (
+	codecs   = make(map[string]*Codec)
+	codecsMu sync.RWMutex
+)
+
+// GetCodec returns a cached codec for the schema, schemas that have been fetched from the schema registry are immutable.
+func GetCodec(schema string) (*Codec, error) {
+	codecsMu.RLock()
+	codec, isOk := codecs[schema]
+	codecsMu.RUnlock()
+	if isOk {
+		return codec, nil
+	}
+
+	codec, err := NewCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	codecsMu.Lock()
+	codecs[schema] = codec
+	codecsMu.Unlock()
+	return codec, nil
+}
+
+func NewCodec(schema string) (*Codec, error) {
+	parsedSchema, err := ParseSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err = json.Unmarshal([]byte(schema), &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal avro schema, err: %v", err)
+	}
+
+	rawSchema, err := json.Marshal(stripLogicalTypes(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal avro schema, err: %v", err)
+	}
+
+	codec, err := goavro.NewCodec(string(rawSchema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create avro codec, err: %v", err)
+	}
+
+	return &Codec{
+		schema: parsedSchema,
+		codec:  codec,
+	}, nil
+}
+
+func (c *Codec) Schema() *Type {
+	return c.schema
+}
+
+func (c *Codec) Decode(bytes []byte) (interface{}, error) {
+	native, _, err := c.codec.NativeFromBinary(bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode avro, err: %v", err)
+	}
+
+	return normalize(c.schema, native), nil
+}
+
+// stripLogicalTypes removes `logicalType` so that goavro will decode the underlying primitive.
+func stripLogicalTypes(raw interface{}) interface{} {
+	switch castedRaw := raw.(type) {
+	case map[string]interface{}:
+		delete(castedRaw, "logicalType")
+		for key, value := range castedRaw {
+			castedRaw[key] = stripLogicalTypes(value)
+		}
+	case []interface{}:
+		for i, value := range castedRaw {
+			castedRaw[i] = stripLogicalTypes(value)
+		}
+	}
+
+	return raw
+}
+
+// normalize unwraps unions and base64 encodes bytes, using the schema rather than guessing from the decoded value.
+func normalize(t *Type, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch t.Type {
+	case Union:
+		// goavro decodes a non-null union value as map[string]interface{}{"branch name": value}
+		wrapped, isOk := value.(map[string]interface{})
+		if !isOk {
+			return value
+		}
+
+		for _, branch := range t.Branches {
+			if branchValue, isOk := wrapped[branch.unionName()]; isOk {
+				return normalize(branch, branchValue)
+			}
+		}
+	case Record:
+		record, isOk := value.(map[string]interface{})
+		if !isOk {
+			return value
+		}
+
+		for _, field := range t.Fields {
+			if fieldValue, isOk := record[field.Name]; isOk {
+				record[field.Name] = normalize(field.Type, fieldValue)
+			}
+		}
+	case Array:
+		items, isOk := value.([]interface{})
+		if !isOk {
+			return value
+		}
+
+		for i, item := range items {
+			items[i] = normalize(t.Items, item)
+		}
+	case Map:
+		values, isOk := value.(map[string]interface{})
+		if !isOk {
+			return value
+		}
+
+		for key, mapValue := range values {
+			values[key] = normalize(t.Values, mapValue)
+		}
+	case Bytes, Fixed:
+		if bytes, isOk := value.([]byte); isOk {
+			return base64.StdEncoding.EncodeToString(bytes)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,81 @@
+package avro
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchema = `{
+	"type": "record",
+	"name": "Value",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "name", "type": ["null", "string"], "default": null},
+		{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 5, "scale": 2}},
+		{"name": "created_at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "attributes", "type": {"type": "map", "values": ["null", "int"]}},
+		{"name": "nested", "type": ["null", {"type": "record", "name": "Nested", "fields": [{"name": "flag", "type": "boolean"}]}]}
+	]
+}`
+
+func TestCodec_Decode(t *testing.T) {
+	codec, err := NewCodec(testSchema)
+	assert.NoError(t, err)
+
+	// Encode without logical types, since that is how we will be decoding it.
+	rawCodec, err := goavro.NewCodec(`{
+	"type": "record",
+	"name": "Value",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "name", "type": ["null", "string"], "default": null},
+		{"name": "price", "type": "bytes"},
+		{"name": "created_at", "type": "long"},
+		{"name": "attributes", "type": {"type": "map", "values": ["null", "int"]}},
+		{"name": "nested", "type": ["null", {"type": "record", "name": "Nested", "fields": [{"name": "flag", "type": "boolean"}]}]}
+	]
+}`)
+	assert.NoError(t, err)
+
+	bytes, err := rawCodec.BinaryFromNative(nil, map[string]interface{}{
+		"id":         int64(1),
+		"name":       goavro.Union("string", "robin"),
+		"price":      []byte{0x30, 0x39},
+		"created_at": int64(1687894424000),
+		"attributes": map[string]interface{}{"a": goavro.Union("int", int32(1)), "b": nil},
+		"nested":     goavro.Union("Nested", map[string]interface{}{"flag": true}),
+	})
+	assert.NoError(t, err)
+
+	decoded, err := codec.Decode(bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":   int64(1),
+		"name": "robin",
+		// Bytes are base64 encoded, just like the JsonConverter.
+		"price": base64.StdEncoding.EncodeToString([]byte{0x30, 0x39}),
+		// Logical types are not converted.
+		"created_at": int64(1687894424000),
+		"attributes": map[string]interface{}{"a": int32(1), "b": nil},
+		"nested":     map[string]interface{}{"flag": true},
+	}, decoded)
+
+	_, err = codec.Decode([]byte{0xff})
+	assert.Error(t, err)
+}
+
+func TestGetCodec(t *testing.T) {
+	codec, err := GetCodec(testSchema)
+	assert.NoError(t, err)
+	assert.Equal(t, Record, codec.Schema().Type)
+
+	cachedCodec, err := GetCodec(testSchema)
+	assert.NoError(t, err)
+	assert.Same(t, codec, cachedCodec)
+
+	_, err = GetCodec(`{"type": "record"}`)
+	assert.Error(t, err)
+}
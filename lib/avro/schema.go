@@ -0,0 +1,257 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	Null    = "null"
+	Boolean = "boolean"
+	Int     = "int"
+	Long    = "long"
+	Float   = "float"
+	Double  = "double"
+	Bytes   = "bytes"
+	String  = "string"
+	Record  = "record"
+	Enum    = "enum"
+	Array   = "array"
+	Map     = "map"
+	Fixed   = "fixed"
+	// Union is not an Avro type name, we use it to represent a schema that is a JSON array.
+	Union = "union"
+)
+
+// Type is a parsed Avro schema.
+// Kafka Connect's AvroConverter will annotate the schema with the original Connect schema (connect.name, connect.type and connect.parameters),
+// which is how Debezium's semantic types (io.debezium.time.MicroTimestamp, etc.) are carried over.
+type Type struct {
+	Type string
+	// Name is the full name of a named type (record, enum and fixed).
+	Name     string
+	Fields   []Field
+	Items    *Type
+	Values   *Type
+	Branches []*Type
+
+	LogicalType string
+	Scale       int
+	Precision   int
+
+	ConnectName       string
+	ConnectType       string
+	ConnectParameters map[string]interface{}
+}
+
+type Field struct {
+	Name    string
+	Type    *Type
+	Default interface{}
+}
+
+// ParseSchema parses an Avro schema, named types can be referenced after they have been defined.
+func ParseSchema(schema string) (*Type, error) {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(schema), &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal avro schema, err: %v", err)
+	}
+
+	p := parser{namedTypes: make(map[string]*Type)}
+	return p.parse(raw, "")
+}
+
+type parser struct {
+	namedTypes map[string]*Type
+}
+
+func (p parser) parse(raw interface{}, namespace string) (*Type, error) {
+	switch castedRaw := raw.(type) {
+	case string:
+		switch castedRaw {
+		case Null, Boolean, Int, Long, Float, Double, Bytes, String:
+			return &Type{Type: castedRaw}, nil
+		}
+
+		if namedType, isOk := p.namedTypes[fullName(castedRaw, namespace)]; isOk {
+			return namedType, nil
+		}
+
+		if namedType, isOk := p.namedTypes[castedRaw]; isOk {
+			return namedType, nil
+		}
+
+		return nil, fmt.Errorf("unknown avro type: %s", castedRaw)
+	case []interface{}:
+		union := &Type{Type: Union}
+		for _, rawBranch := range castedRaw {
+			branch, err := p.parse(rawBranch, namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			union.Branches = append(union.Branches, branch)
+		}
+
+		return union, nil
+	case map[string]interface{}:
+		return p.parseObject(castedRaw, namespace)
+	}
+
+	return nil, fmt.Errorf("unexpected avro schema: %v", raw)
+}
+
+func (p parser) parseObject(raw map[string]interface{}, namespace string) (*Type, error) {
+	typeName, isOk := raw["type"].(string)
+	if !isOk {
+		// {"type": {"type": "record", ...}} or {"type": ["null", "string"]}
+		return p.parse(raw["type"], namespace)
+	}
+
+	t := &Type{
+		Type:        typeName,
+		LogicalType: stringValue(raw, "logicalType"),
+		Scale:       intValue(raw, "scale"),
+		Precision:   intValue(raw, "precision"),
+		ConnectName: stringValue(raw, "connect.name"),
+		ConnectType: stringValue(raw, "connect.type"),
+	}
+
+	if parameters, isOk := raw["connect.parameters"].(map[string]interface{}); isOk {
+		t.ConnectParameters = parameters
+	}
+
+	switch typeName {
+	case Record, Enum, Fixed:
+		if ns := stringValue(raw, "namespace"); ns != "" {
+			namespace = ns
+		}
+
+		t.Name = fullName(stringValue(raw, "name"), namespace)
+		// Register the type before parsing the fields, so that recursive types can reference themselves.
+		p.namedTypes[t.Name] = t
+		if typeName != Record {
+			return t, nil
+		}
+
+		// Types defined within a record will inherit the record's namespace.
+		namespace = t.Name[:max(strings.LastIndex(t.Name, "."), 0)]
+		rawFields, _ := raw["fields"].([]interface{})
+		for _, rawField := range rawFields {
+			fieldObject, isOk := rawField.(map[string]interface{})
+			if !isOk {
+				return nil, fmt.Errorf("unexpected avro field: %v", rawField)
+			}
+
+			fieldType, err := p.parse(fieldObject["type"], namespace)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse field: %v, err: %v", fieldObject["name"], err)
+			}
+
+			t.Fields = append(t.Fields, Field{
+				Name:    stringValue(fieldObject, "name"),
+				Type:    fieldType,
+				Default: fieldObject["default"],
+			})
+		}
+	case Array:
+		items, err := p.parse(raw["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		t.Items = items
+	case Map:
+		values, err := p.parse(raw["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		t.Values = values
+	case Null, Boolean, Int, Long, Float, Double, Bytes, String:
+	default:
+		// A named type that is being referenced as {"type": "name"}.
+		return p.parse(typeName, namespace)
+	}
+
+	return t, nil
+}
+
+// Optional returns true if the type is a union containing null.
+func (t *Type) Optional() bool {
+	for _, branch := range t.Branches {
+		if branch.Type == Null {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NonNull will unwrap optional unions (["null", T]) into T, otherwise it'll return itself.
+func (t *Type) NonNull() *Type {
+	if t.Type != Union {
+		return t
+	}
+
+	var nonNull []*Type
+	for _, branch := range t.Branches {
+		if branch.Type != Null {
+			nonNull = append(nonNull, branch)
+		}
+	}
+
+	if len(nonNull) == 1 {
+		return nonNull[0]
+	}
+
+	return t
+}
+
+// FieldByName returns nil if the field does not exist.
+func (t *Type) FieldByName(name string) *Field {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+
+	return nil
+}
+
+// unionName is the name that is used to identify the branch of a decoded union value.
+func (t *Type) unionName() string {
+	if t.Name != "" {
+		return t.Name
+	}
+
+	return t.Type
+}
+
+func fullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+
+	return namespace + "." + name
+}
+
+func stringValue(raw map[string]interface{}, key string) string {
+	val, _ := raw[key].(string)
+	return val
+}
+
+func intValue(raw map[string]interface{}, key string) int {
+	// Numbers are decoded as float64 by encoding/json.
+	val, _ := raw[key].(float64)
+	return int(val)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,67 @@
+package avro
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema(`{
+	"type": "record",
+	"name": "Envelope",
+	"namespace": "dbserver1.public.orders",
+	"fields": [
+		{"name": "before", "type": ["null", {
+			"type": "record",
+			"name": "Value",
+			"fields": [
+				{"name": "id", "type": {"type": "int", "connect.type": "int16"}},
+				{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+				{"name": "created_at", "type": ["null", {"type": "long", "connect.name": "io.debezium.time.MicroTimestamp"}], "default": null},
+				{"name": "tags", "type": {"type": "array", "items": "string"}}
+			]
+		}], "default": null},
+		{"name": "after", "type": ["null", "Value"], "default": null},
+		{"name": "op", "type": "string"}
+	]
+}`)
+	assert.NoError(t, err)
+	assert.Equal(t, Record, schema.Type)
+	assert.Equal(t, "dbserver1.public.orders.Envelope", schema.Name)
+
+	after := schema.FieldByName("after")
+	assert.NotNil(t, after)
+	assert.True(t, after.Type.Optional())
+
+	// `after` references the record that was defined within `before`.
+	value := after.Type.NonNull()
+	assert.Equal(t, "dbserver1.public.orders.Value", value.Name)
+	assert.Equal(t, schema.FieldByName("before").Type.NonNull(), value)
+	assert.Len(t, value.Fields, 4)
+
+	assert.Equal(t, Int, value.Fields[0].Type.Type)
+	assert.Equal(t, "int16", value.Fields[0].Type.ConnectType)
+
+	assert.Equal(t, "decimal", value.Fields[1].Type.LogicalType)
+	assert.Equal(t, 2, value.Fields[1].Type.Scale)
+	assert.Equal(t, 10, value.Fields[1].Type.Precision)
+
+	assert.True(t, value.Fields[2].Type.Optional())
+	assert.Equal(t, "io.debezium.time.MicroTimestamp", value.Fields[2].Type.NonNull().ConnectName)
+	assert.Nil(t, value.Fields[2].Default)
+
+	assert.Equal(t, Array, value.Fields[3].Type.Type)
+	assert.Equal(t, String, value.Fields[3].Type.Items.Type)
+
+	assert.Nil(t, schema.FieldByName("transaction"))
+	assert.False(t, schema.FieldByName("op").Type.Optional())
+}
+
+func TestParseSchema_Invalid(t *testing.T) {
+	_, err := ParseSchema(`not json`)
+	assert.Error(t, err)
+
+	_, err = ParseSchema(`{"type": "record", "name": "foo", "fields": [{"name": "bar", "type": "Unknown"}]}`)
+	assert.ErrorContains(t, err, "unknown avro type: Unknown")
+}
@@ -22,12 +22,21 @@ type Event interface {
 	Operation() string
 	DeletePayload() bool
 	GetTableName() string
+	// GetSourceMetadata returns where the event originated from, this is used to look up per-table overrides.
+	GetSourceMetadata() SourceMetadata
 	GetData(ctx context.Context, pkMap map[string]interface{}, config *kafkalib.TopicConfig) map[string]interface{}
 	GetOptionalSchema(ctx context.Context) map[string]typing.KindDetails
 	// GetColumns will inspect the envelope's payload right now and return.
 	GetColumns(ctx context.Context) *columns.Columns
 }
 
+// SourceMetadata is the database, schema and table from the event's source metadata.
+type SourceMetadata struct {
+	Database string
+	Schema   string
+	Table    string
+}
+
 // FieldLabelKind is used when the schema is turned on. Each schema object will be labelled.
 type FieldLabelKind string
 
@@ -3,7 +3,11 @@ package format
 import (
 	"context"
 
+	"google.golang.org/protobuf/reflect/protoreflect"
+
 	"github.com/artie-labs/transfer/lib/cdc/mysql"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/protobuf"
 
 	"github.com/artie-labs/transfer/lib/cdc"
 	"github.com/artie-labs/transfer/lib/cdc/mongo"
@@ -12,29 +16,42 @@ import (
 )
 
 var (
-	d     postgres.Debezium
-	m     mongo.Debezium
-	mySQL mysql.Debezium
+	d         postgres.Debezium
+	dAvro     postgres.DebeziumAvro
+	m         mongo.Debezium
+	mySQL     mysql.Debezium
+	mySQLAvro mysql.DebeziumAvro
 )
 
-func GetFormatParser(ctx context.Context, label, topic string) cdc.Format {
+func GetFormatParser(ctx context.Context, tc *kafkalib.TopicConfig) cdc.Format {
+	// Protobuf parsers are created for each topic, since the message descriptor can be specified within the topic config.
+	var messageDesc protoreflect.MessageDescriptor
+	if tc.ProtobufDescriptor != nil {
+		var err error
+		messageDesc, err = protobuf.MessageFromDescriptorSet(tc.ProtobufDescriptor.Path, tc.ProtobufDescriptor.Message)
+		if err != nil {
+			logger.FromContext(ctx).WithError(err).WithField("topic", tc.Topic).Fatal("Failed to load protobuf descriptor")
+		}
+	}
+
 	validFormats := []cdc.Format{
-		&d, &m, &mySQL,
+		&d, &dAvro, &postgres.DebeziumProtobuf{MessageDesc: messageDesc},
+		&m, &mySQL, &mySQLAvro, &mysql.DebeziumProtobuf{MessageDesc: messageDesc},
 	}
 
 	for _, validFormat := range validFormats {
 		for _, fmtLabel := range validFormat.Labels() {
-			if fmtLabel == label {
+			if fmtLabel == tc.CDCFormat {
 				logger.FromContext(ctx).WithFields(map[string]interface{}{
-					"label": label,
-					"topic": topic,
+					"label": tc.CDCFormat,
+					"topic": tc.Topic,
 				}).Info("Loaded CDC Format parser...")
 				return validFormat
 			}
 		}
 	}
 
-	logger.FromContext(ctx).WithField("label", label).
+	logger.FromContext(ctx).WithField("label", tc.CDCFormat).
 		Fatalf("Failed to fetch CDC format parser")
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/kafkalib"
 
 	"github.com/stretchr/testify/assert"
 
@@ -19,9 +20,13 @@ func TestGetFormatParser(t *testing.T) {
 		VerboseLogging: true,
 	})
 
-	validFormats := []string{constants.DBZPostgresAltFormat, constants.DBZPostgresFormat, constants.DBZMongoFormat}
+	validFormats := []string{constants.DBZPostgresAltFormat, constants.DBZPostgresFormat, constants.DBZMongoFormat,
+		constants.DBZMySQLFormat, constants.DBZPostgresAvroFormat, constants.DBZMySQLAvroFormat,
+		constants.DBZPostgresProtobufFormat, constants.DBZMySQLProtobufFormat}
 	for _, validFormat := range validFormats {
-		assert.NotNil(t, GetFormatParser(ctx, validFormat, "topicA"))
+		parser := GetFormatParser(ctx, &kafkalib.TopicConfig{CDCFormat: validFormat, Topic: "topicA"})
+		assert.NotNil(t, parser)
+		assert.Contains(t, parser.Labels(), validFormat)
 	}
 }
 
@@ -44,6 +49,16 @@ func testOsExit(t *testing.T, testFunc func(*testing.T)) {
 func TestGetFormatParserFatal(t *testing.T) {
 	// This test cannot be iterated because it forks a separate process to do `go test -test.run=...`
 	testOsExit(t, func(t *testing.T) {
-		GetFormatParser(context.Background(), "foo", "topicB")
+		GetFormatParser(context.Background(), &kafkalib.TopicConfig{CDCFormat: "foo", Topic: "topicB"})
+	})
+}
+
+func TestGetFormatParserProtobufDescriptorFatal(t *testing.T) {
+	testOsExit(t, func(t *testing.T) {
+		GetFormatParser(context.Background(), &kafkalib.TopicConfig{
+			CDCFormat:          constants.DBZPostgresProtobufFormat,
+			Topic:              "topicC",
+			ProtobufDescriptor: &kafkalib.ProtobufDescriptor{Path: "/does/not/exist.pb", Message: "Envelope"},
+		})
 	})
 }
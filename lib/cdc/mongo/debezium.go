@@ -75,7 +75,7 @@ func (d *Debezium) Labels() []string {
 }
 
 func (d *Debezium) GetPrimaryKey(ctx context.Context, key []byte, tc *kafkalib.TopicConfig) (map[string]interface{}, error) {
-	kvMap, err := debezium.ParsePartitionKey(key, tc.CDCKeyFormat)
+	kvMap, err := debezium.ParsePartitionKey(ctx, key, tc.CDCKeyFormat)
 	if err != nil {
 		return nil, err
 	}
@@ -129,6 +129,14 @@ func (s *SchemaEventPayload) GetTableName() string {
 	return s.Payload.Source.Collection
 }
 
+// GetSourceMetadata - MongoDB does not have schemas, so we'll only return the database and collection.
+func (s *SchemaEventPayload) GetSourceMetadata() cdc.SourceMetadata {
+	return cdc.SourceMetadata{
+		Database: s.Payload.Source.Database,
+		Table:    s.Payload.Source.Collection,
+	}
+}
+
 func (s *SchemaEventPayload) GetOptionalSchema(ctx context.Context) map[string]typing.KindDetails {
 	// MongoDB does not have a schema at the database level.
 	return nil
@@ -190,6 +190,7 @@ func (p *MongoTestSuite) TestMongoDBEventCustomer() {
 	assert.Equal(p.T(), evt.GetExecutionTime(),
 		time.Date(2022, time.November, 18, 6, 35, 21, 0, time.UTC))
 	assert.Equal(p.T(), "customers", evt.GetTableName())
+	assert.Equal(p.T(), cdc.SourceMetadata{Database: "inventory", Table: "customers"}, evt.GetSourceMetadata())
 	assert.False(p.T(), evt.DeletePayload())
 }
 
@@ -0,0 +1,37 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/artie-labs/transfer/lib/cdc"
+	"github.com/artie-labs/transfer/lib/cdc/util"
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/debezium"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+)
+
+// DebeziumAvro is used when Debezium is running with the AvroConverter, schemas are fetched from the schema registry.
+type DebeziumAvro string
+
+func (d *DebeziumAvro) GetEventFromBytes(ctx context.Context, bytes []byte) (cdc.Event, error) {
+	if len(bytes) == 0 {
+		var event util.SchemaEventPayload
+		event.Tombstone()
+		return &event, nil
+	}
+
+	event, err := util.FromAvro(ctx, bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (d *DebeziumAvro) Labels() []string {
+	return []string{constants.DBZMySQLAvroFormat}
+}
+
+func (d *DebeziumAvro) GetPrimaryKey(ctx context.Context, key []byte, tc *kafkalib.TopicConfig) (kvMap map[string]interface{}, err error) {
+	return debezium.ParsePartitionKey(ctx, key, tc.CDCKeyFormat)
+}
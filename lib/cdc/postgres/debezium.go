@@ -33,5 +33,5 @@ func (d *Debezium) Labels() []string {
 }
 
 func (d *Debezium) GetPrimaryKey(ctx context.Context, key []byte, tc *kafkalib.TopicConfig) (kvMap map[string]interface{}, err error) {
-	return debezium.ParsePartitionKey(key, tc.CDCKeyFormat)
+	return debezium.ParsePartitionKey(ctx, key, tc.CDCKeyFormat)
 }
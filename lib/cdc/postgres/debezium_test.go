@@ -3,6 +3,7 @@ package postgres
 import (
 	"time"
 
+	"github.com/artie-labs/transfer/lib/cdc"
 	"github.com/artie-labs/transfer/lib/kafkalib"
 	"github.com/artie-labs/transfer/lib/typing"
 	"github.com/artie-labs/transfer/lib/typing/ext"
@@ -93,6 +94,7 @@ func (p *PostgresTestSuite) TestPostgresEvent() {
 	assert.Equal(p.T(), time.Date(2022, time.November, 16,
 		4, 1, 53, 308000000, time.UTC), evt.GetExecutionTime())
 	assert.Equal(p.T(), "orders", evt.GetTableName())
+	assert.Equal(p.T(), cdc.SourceMetadata{Database: "demo", Schema: "public", Table: "orders"}, evt.GetSourceMetadata())
 	assert.False(p.T(), evt.DeletePayload())
 }
 
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/artie-labs/transfer/lib/cdc"
+	"github.com/artie-labs/transfer/lib/cdc/util"
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/debezium"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+)
+
+// DebeziumProtobuf is used when Debezium is running with the ProtobufConverter.
+type DebeziumProtobuf struct {
+	// MessageDesc is loaded from a local descriptor set, if it's nil we will resolve it from the schema registry.
+	MessageDesc protoreflect.MessageDescriptor
+}
+
+func (d *DebeziumProtobuf) GetEventFromBytes(ctx context.Context, bytes []byte) (cdc.Event, error) {
+	if len(bytes) == 0 {
+		var event util.SchemaEventPayload
+		event.Tombstone()
+		return &event, nil
+	}
+
+	event, err := util.FromProtobuf(ctx, bytes, d.MessageDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (d *DebeziumProtobuf) Labels() []string {
+	return []string{constants.DBZPostgresProtobufFormat}
+}
+
+func (d *DebeziumProtobuf) GetPrimaryKey(ctx context.Context, key []byte, tc *kafkalib.TopicConfig) (kvMap map[string]interface{}, err error) {
+	return debezium.ParsePartitionKey(ctx, key, tc.CDCKeyFormat)
+}
@@ -0,0 +1,165 @@
+package util
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/artie-labs/transfer/lib/avro"
+	"github.com/artie-labs/transfer/lib/cdc"
+	"github.com/artie-labs/transfer/lib/debezium"
+	"github.com/artie-labs/transfer/lib/schemaregistry"
+)
+
+// FromAvro will decode a Debezium envelope that was serialized with the AvroConverter.
+// The Avro schema is translated into the schema the JsonConverter would have emitted, so the event is parsed just like a JSON one.
+func FromAvro(ctx context.Context, bytes []byte) (*SchemaEventPayload, error) {
+	client := schemaregistry.FromContext(ctx)
+	if client == nil {
+		return nil, fmt.Errorf("schema registry is not configured")
+	}
+
+	schemaID, payload, err := schemaregistry.ParseWireFormat(bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := client.GetSchema(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := avro.GetCodec(schema.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := codec.Decode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, isOk := decoded.(map[string]interface{})
+	if !isOk {
+		return nil, fmt.Errorf("envelope is not a record, schema id: %d", schemaID)
+	}
+
+	var afterFields []debezium.Field
+	afterField := codec.Schema().FieldByName(string(cdc.After))
+	if afterField != nil {
+		for _, field := range afterField.Type.NonNull().Fields {
+			afterFields = append(afterFields, toDebeziumField(field))
+		}
+	}
+
+	return newSchemaEventPayload(envelope, afterFields), nil
+}
+
+// newSchemaEventPayload is used by the formats that are not JSON, once they have decoded the envelope and translated the schema for `after`.
+func newSchemaEventPayload(envelope map[string]interface{}, afterFields []debezium.Field) *SchemaEventPayload {
+	var event SchemaEventPayload
+	if afterFields != nil {
+		event.Schema = debezium.Schema{
+			SchemaType: "struct",
+			FieldsObject: []debezium.FieldsObject{{
+				FieldObjectType: "struct",
+				Fields:          afterFields,
+				Optional:        true,
+				FieldLabel:      cdc.After,
+			}},
+		}
+	}
+
+	event.Payload.Before, _ = envelope[string(cdc.Before)].(map[string]interface{})
+	event.Payload.After, _ = envelope[string(cdc.After)].(map[string]interface{})
+	event.Payload.Operation, _ = envelope[string(cdc.Op)].(string)
+	if source, isOk := envelope[string(cdc.Source)].(map[string]interface{}); isOk {
+		event.Payload.Source.Connector, _ = source["connector"].(string)
+		event.Payload.Source.TsMs, _ = source["ts_ms"].(int64)
+		event.Payload.Source.Database, _ = source["db"].(string)
+		event.Payload.Source.Schema, _ = source["schema"].(string)
+		event.Payload.Source.Table, _ = source["table"].(string)
+	}
+
+	return &event
+}
+
+func toDebeziumField(field avro.Field) debezium.Field {
+	fieldType := field.Type.NonNull()
+	debeziumField := debezium.Field{
+		Type:         connectType(fieldType),
+		Optional:     field.Type.Optional(),
+		Default:      field.Default,
+		FieldName:    field.Name,
+		DebeziumType: connectName(fieldType),
+		Parameters:   fieldType.ConnectParameters,
+	}
+
+	if debeziumField.Parameters == nil && fieldType.LogicalType == "decimal" {
+		debeziumField.Parameters = map[string]interface{}{
+			"scale":                           fmt.Sprint(fieldType.Scale),
+			debezium.KafkaDecimalPrecisionKey: fmt.Sprint(fieldType.Precision),
+		}
+	}
+
+	// Avro encodes a bytes default as a string of code points (0-255), whereas the JsonConverter emits base64.
+	if defaultString, isOk := field.Default.(string); isOk && debeziumField.Type == "bytes" {
+		defaultBytes := make([]byte, 0, len(defaultString))
+		for _, r := range defaultString {
+			defaultBytes = append(defaultBytes, byte(r))
+		}
+
+		debeziumField.Default = base64.StdEncoding.EncodeToString(defaultBytes)
+	}
+
+	return debeziumField
+}
+
+// connectType returns the Kafka Connect type, the AvroConverter only annotates the types that Avro does not have (int8 and int16).
+func connectType(t *avro.Type) string {
+	if t.ConnectType != "" {
+		return t.ConnectType
+	}
+
+	switch t.Type {
+	case avro.Int:
+		return "int32"
+	case avro.Long:
+		return "int64"
+	case avro.Float, avro.Double, avro.Boolean, avro.String, avro.Bytes, avro.Array, avro.Map:
+		return t.Type
+	case avro.Enum:
+		return "string"
+	case avro.Fixed:
+		return "bytes"
+	case avro.Record:
+		return "struct"
+	}
+
+	return ""
+}
+
+// connectName returns the Kafka Connect (or Debezium) semantic type.
+// If the schema was not produced by Kafka Connect, we will fall back to the equivalent of the Avro logical type.
+func connectName(t *avro.Type) string {
+	if t.ConnectName != "" {
+		return t.ConnectName
+	}
+
+	switch t.LogicalType {
+	case "decimal":
+		return string(debezium.KafkaDecimalType)
+	case "date":
+		return string(debezium.DateKafkaConnect)
+	case "time-millis":
+		return string(debezium.TimeKafkaConnect)
+	case "time-micros":
+		return string(debezium.TimeMicro)
+	case "timestamp-millis", "local-timestamp-millis":
+		return string(debezium.DateTimeKafkaConnect)
+	case "timestamp-micros", "local-timestamp-micros":
+		return string(debezium.MicroTimestamp)
+	}
+
+	return ""
+}
@@ -0,0 +1,189 @@
+package util
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/schemaregistry"
+	"github.com/artie-labs/transfer/lib/typing"
+	"github.com/artie-labs/transfer/lib/typing/decimal"
+	"github.com/artie-labs/transfer/lib/typing/ext"
+)
+
+const avroEnvelopeSchema = `{
+	"type": "record",
+	"name": "Envelope",
+	"namespace": "dbserver1.public.orders",
+	"fields": [
+		{"name": "before", "type": ["null", {
+			"type": "record",
+			"name": "Value",
+			"fields": [
+				{"name": "id", "type": "int"},
+				{"name": "name", "type": ["null", "string"], "default": null},
+				{"name": "price", "type": {
+					"type": "bytes",
+					"scale": 2,
+					"precision": 10,
+					"connect.version": 1,
+					"connect.parameters": {"scale": "2", "connect.decimal.precision": "10"},
+					"connect.name": "org.apache.kafka.connect.data.Decimal",
+					"logicalType": "decimal"
+				}},
+				{"name": "created_at", "type": {"type": "long", "connect.version": 1, "connect.name": "io.debezium.time.MicroTimestamp"}},
+				{"name": "birthday", "type": ["null", {"type": "int", "logicalType": "date"}], "default": null}
+			],
+			"connect.name": "dbserver1.public.orders.Value"
+		}], "default": null},
+		{"name": "after", "type": ["null", "Value"], "default": null},
+		{"name": "source", "type": {
+			"type": "record",
+			"name": "Source",
+			"namespace": "io.debezium.connector.postgresql",
+			"fields": [
+				{"name": "connector", "type": "string"},
+				{"name": "ts_ms", "type": "long"},
+				{"name": "db", "type": "string"},
+				{"name": "schema", "type": "string"},
+				{"name": "table", "type": "string"}
+			]
+		}},
+		{"name": "op", "type": "string"},
+		{"name": "ts_ms", "type": ["null", "long"], "default": null}
+	]
+}`
+
+func avroRegistry(schemas map[int]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for id, schema := range schemas {
+			if r.URL.Path == fmt.Sprintf("/schemas/ids/%d", id) {
+				fmt.Fprintf(w, `{"schema": %s}`, strconv.Quote(schema))
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+}
+
+func encodeAvro(schemaID int, schema string, native map[string]interface{}) ([]byte, error) {
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header[1:], uint32(schemaID))
+	return codec.BinaryFromNative(header, native)
+}
+
+func (u *UtilTestSuite) TestFromAvro() {
+	server := avroRegistry(map[int]string{1: avroEnvelopeSchema})
+	defer server.Close()
+
+	ctx := schemaregistry.InjectClientIntoCtx(u.ctx, schemaregistry.NewClient(server.URL, "", ""))
+	bytes, err := encodeAvro(1, avroEnvelopeSchema, map[string]interface{}{
+		"before": nil,
+		"after": goavro.Union("dbserver1.public.orders.Value", map[string]interface{}{
+			"id":         int32(1),
+			"name":       goavro.Union("string", "robin"),
+			"price":      big.NewRat(12345, 100),
+			"created_at": int64(1687894424123456),
+			"birthday":   goavro.Union("int.date", time.Date(2023, 6, 27, 0, 0, 0, 0, time.UTC)),
+		}),
+		"source": map[string]interface{}{
+			"connector": "postgresql",
+			"ts_ms":     int64(1687894424000),
+			"db":        "postgres",
+			"schema":    "public",
+			"table":     "orders",
+		},
+		"op":    "c",
+		"ts_ms": goavro.Union("long", int64(1687894424500)),
+	})
+	assert.NoError(u.T(), err)
+
+	event, err := FromAvro(ctx, bytes)
+	assert.NoError(u.T(), err)
+	assert.Equal(u.T(), "orders", event.GetTableName())
+	assert.Equal(u.T(), "c", event.Operation())
+	assert.False(u.T(), event.DeletePayload())
+	assert.Equal(u.T(), time.UnixMilli(1687894424000).UTC(), event.GetExecutionTime())
+
+	optionalSchema := event.GetOptionalSchema(ctx)
+	assert.Equal(u.T(), typing.Integer, optionalSchema["id"])
+	assert.Equal(u.T(), typing.String, optionalSchema["name"])
+	assert.Equal(u.T(), typing.EDecimal.Kind, optionalSchema["price"].Kind)
+	assert.Equal(u.T(), 2, optionalSchema["price"].ExtendedDecimalDetails.Scale())
+	assert.Equal(u.T(), typing.NewKindDetailsFromTemplate(typing.ETime, ext.DateTimeKindType), optionalSchema["created_at"])
+	assert.Equal(u.T(), typing.NewKindDetailsFromTemplate(typing.ETime, ext.DateKindType), optionalSchema["birthday"])
+
+	data := event.GetData(ctx, map[string]interface{}{"id": 1}, &kafkalib.TopicConfig{})
+	assert.Equal(u.T(), int32(1), data["id"])
+	assert.Equal(u.T(), "robin", data["name"])
+	assert.Equal(u.T(), false, data[constants.DeleteColumnMarker])
+	assert.Equal(u.T(), "123.45", data["price"].(*decimal.Decimal).String())
+	assert.Equal(u.T(), time.Date(2023, 6, 27, 19, 33, 44, 123456000, time.UTC), data["created_at"].(*ext.ExtendedTime).Time)
+	assert.Equal(u.T(), "2023-06-27", data["birthday"].(*ext.ExtendedTime).String(""))
+}
+
+func (u *UtilTestSuite) TestFromAvro_Delete() {
+	server := avroRegistry(map[int]string{1: avroEnvelopeSchema})
+	defer server.Close()
+
+	ctx := schemaregistry.InjectClientIntoCtx(u.ctx, schemaregistry.NewClient(server.URL, "", ""))
+	bytes, err := encodeAvro(1, avroEnvelopeSchema, map[string]interface{}{
+		"before": goavro.Union("dbserver1.public.orders.Value", map[string]interface{}{
+			"id":         int32(1),
+			"name":       nil,
+			"price":      big.NewRat(0, 1),
+			"created_at": int64(0),
+			"birthday":   nil,
+		}),
+		"after": nil,
+		"source": map[string]interface{}{
+			"connector": "postgresql",
+			"ts_ms":     int64(1687894424000),
+			"db":        "postgres",
+			"schema":    "public",
+			"table":     "orders",
+		},
+		"op":    "d",
+		"ts_ms": nil,
+	})
+	assert.NoError(u.T(), err)
+
+	event, err := FromAvro(ctx, bytes)
+	assert.NoError(u.T(), err)
+	assert.True(u.T(), event.DeletePayload())
+
+	data := event.GetData(ctx, map[string]interface{}{"id": 1}, &kafkalib.TopicConfig{})
+	assert.Equal(u.T(), true, data[constants.DeleteColumnMarker])
+	assert.Equal(u.T(), 1, data["id"])
+}
+
+func (u *UtilTestSuite) TestFromAvro_Errors() {
+	// The schema registry has not been configured.
+	_, err := FromAvro(u.ctx, []byte{0, 0, 0, 0, 1})
+	assert.ErrorContains(u.T(), err, "schema registry is not configured")
+
+	server := avroRegistry(map[int]string{1: avroEnvelopeSchema})
+	defer server.Close()
+
+	ctx := schemaregistry.InjectClientIntoCtx(u.ctx, schemaregistry.NewClient(server.URL, "", ""))
+	_, err = FromAvro(ctx, []byte(`{"payload": {}}`))
+	assert.ErrorContains(u.T(), err, "unknown magic byte")
+
+	_, err = FromAvro(ctx, []byte{0, 0, 0, 0, 2})
+	assert.ErrorContains(u.T(), err, "status: 404")
+}
@@ -0,0 +1,132 @@
+package util
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/artie-labs/transfer/lib/cdc"
+	"github.com/artie-labs/transfer/lib/debezium"
+	"github.com/artie-labs/transfer/lib/protobuf"
+	"github.com/artie-labs/transfer/lib/schemaregistry"
+)
+
+// FromProtobuf will decode a Debezium envelope that was serialized with the ProtobufConverter.
+// If messageDesc is nil, the message descriptor will be resolved from the schema registry.
+// Otherwise, messageDesc is used (it was loaded from a local descriptor set) and the message may or may not be in the wire format.
+func FromProtobuf(ctx context.Context, bytes []byte, messageDesc protoreflect.MessageDescriptor) (*SchemaEventPayload, error) {
+	messageDesc, payload, err := resolveMessage(ctx, bytes, messageDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, err := protobuf.Decode(messageDesc, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var afterFields []debezium.Field
+	if afterField := messageDesc.Fields().ByName(protoreflect.Name(cdc.After)); afterField != nil && afterField.Message() != nil {
+		fields := afterField.Message().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			afterFields = append(afterFields, protobufToDebeziumField(fields.Get(i)))
+		}
+	}
+
+	return newSchemaEventPayload(envelope, afterFields), nil
+}
+
+func resolveMessage(ctx context.Context, bytes []byte, messageDesc protoreflect.MessageDescriptor) (protoreflect.MessageDescriptor, []byte, error) {
+	// A protobuf message can never start with 0 (field number 0 is invalid), so this has to be the wire format.
+	if messageDesc != nil && (len(bytes) == 0 || bytes[0] != 0) {
+		return messageDesc, bytes, nil
+	}
+
+	schemaID, payload, err := schemaregistry.ParseWireFormat(bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	indexes, payload, err := protobuf.ParseMessageIndexes(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if messageDesc != nil {
+		return messageDesc, payload, nil
+	}
+
+	client := schemaregistry.FromContext(ctx)
+	if client == nil {
+		return nil, nil, fmt.Errorf("schema registry is not configured")
+	}
+
+	file, err := protobuf.FileFromRegistry(ctx, client, schemaID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	messageDesc, err = protobuf.MessageByIndexes(file, indexes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return messageDesc, payload, nil
+}
+
+func protobufToDebeziumField(field protoreflect.FieldDescriptor) debezium.Field {
+	debeziumField := debezium.Field{
+		FieldName: string(field.Name()),
+		Optional:  field.HasPresence(),
+	}
+
+	switch {
+	case field.IsList():
+		debeziumField.Type = "array"
+	case field.IsMap():
+		debeziumField.Type = "map"
+	default:
+		debeziumField.Type, debeziumField.DebeziumType = protobufConnectType(protobuf.Unwrap(field))
+	}
+
+	return debeziumField
+}
+
+// protobufConnectType returns the Kafka Connect type and semantic type, this has to match how protobuf.Decode converts the value.
+func protobufConnectType(field protoreflect.FieldDescriptor) (string, string) {
+	switch field.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "int32", ""
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "int64", ""
+	case protoreflect.FloatKind:
+		return "float", ""
+	case protoreflect.DoubleKind:
+		return "double", ""
+	case protoreflect.BoolKind:
+		return "boolean", ""
+	case protoreflect.StringKind, protoreflect.EnumKind:
+		return "string", ""
+	case protoreflect.BytesKind:
+		return "bytes", ""
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		switch field.Message().FullName() {
+		case protobuf.Timestamp:
+			return "int64", string(debezium.MicroTimestamp)
+		case protobuf.Duration:
+			// Durations are represented in microseconds, just like Debezium's io.debezium.time.MicroDuration.
+			return "int64", ""
+		case protobuf.Struct:
+			return "struct", string(debezium.JSON)
+		case protobuf.Value, protobuf.ListValue:
+			// These can be any JSON value, so we'll let typing infer it.
+			return "", ""
+		}
+
+		return "struct", ""
+	}
+
+	return "", ""
+}
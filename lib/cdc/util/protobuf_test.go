@@ -0,0 +1,159 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/schemaregistry"
+	"github.com/artie-labs/transfer/lib/typing"
+	"github.com/artie-labs/transfer/lib/typing/ext"
+)
+
+const protobufEnvelopeFile = `{
+	"name": "orders.proto",
+	"package": "dbserver1.public.orders",
+	"syntax": "proto3",
+	"dependency": ["google/protobuf/timestamp.proto", "google/protobuf/duration.proto", "google/protobuf/wrappers.proto"],
+	"messageType": [{
+		"name": "Envelope",
+		"field": [
+			{"name": "before", "number": 1, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".dbserver1.public.orders.Value"},
+			{"name": "after", "number": 2, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".dbserver1.public.orders.Value"},
+			{"name": "source", "number": 3, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".dbserver1.public.orders.Source"},
+			{"name": "op", "number": 4, "label": "LABEL_OPTIONAL", "type": "TYPE_STRING"}
+		]
+	}, {
+		"name": "Value",
+		"field": [
+			{"name": "id", "number": 1, "label": "LABEL_OPTIONAL", "type": "TYPE_INT64"},
+			{"name": "name", "number": 2, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".google.protobuf.StringValue"},
+			{"name": "created_at", "number": 3, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".google.protobuf.Timestamp"},
+			{"name": "ttl", "number": 4, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".google.protobuf.Duration"},
+			{"name": "active", "number": 5, "label": "LABEL_OPTIONAL", "type": "TYPE_BOOL"},
+			{"name": "price", "number": 6, "label": "LABEL_OPTIONAL", "type": "TYPE_DOUBLE"},
+			{"name": "tags", "number": 7, "label": "LABEL_REPEATED", "type": "TYPE_STRING"}
+		]
+	}, {
+		"name": "Source",
+		"field": [
+			{"name": "connector", "number": 1, "label": "LABEL_OPTIONAL", "type": "TYPE_STRING"},
+			{"name": "ts_ms", "number": 2, "label": "LABEL_OPTIONAL", "type": "TYPE_INT64"},
+			{"name": "db", "number": 3, "label": "LABEL_OPTIONAL", "type": "TYPE_STRING"},
+			{"name": "schema", "number": 4, "label": "LABEL_OPTIONAL", "type": "TYPE_STRING"},
+			{"name": "table", "number": 5, "label": "LABEL_OPTIONAL", "type": "TYPE_STRING"}
+		]
+	}]
+}`
+
+const protobufEnvelope = `{
+	"after": {"id": "1", "name": "robin", "created_at": "2023-06-27T19:33:44.123456Z", "ttl": "60s", "active": true, "price": 9.99, "tags": ["a"]},
+	"source": {"connector": "postgresql", "ts_ms": "1687894424000", "db": "postgres", "schema": "public", "table": "orders"},
+	"op": "u"
+}`
+
+func (u *UtilTestSuite) protobufEnvelopeDescriptor() (*descriptorpb.FileDescriptorProto, protoreflect.MessageDescriptor) {
+	var fileProto descriptorpb.FileDescriptorProto
+	assert.NoError(u.T(), protojson.Unmarshal([]byte(protobufEnvelopeFile), &fileProto))
+
+	file, err := protodesc.NewFile(&fileProto, protoregistry.GlobalFiles)
+	assert.NoError(u.T(), err)
+	return &fileProto, file.Messages().ByName("Envelope")
+}
+
+func (u *UtilTestSuite) encodeProtobuf(messageDesc protoreflect.MessageDescriptor, envelope string) []byte {
+	msg := dynamicpb.NewMessage(messageDesc)
+	assert.NoError(u.T(), protojson.Unmarshal([]byte(envelope), msg))
+
+	bytes, err := proto.Marshal(msg)
+	assert.NoError(u.T(), err)
+	return bytes
+}
+
+func (u *UtilTestSuite) assertProtobufEvent(event *SchemaEventPayload) {
+	assert.Equal(u.T(), "orders", event.GetTableName())
+	assert.Equal(u.T(), "u", event.Operation())
+	assert.Equal(u.T(), time.UnixMilli(1687894424000).UTC(), event.GetExecutionTime())
+
+	optionalSchema := event.GetOptionalSchema(u.ctx)
+	assert.Equal(u.T(), typing.Integer, optionalSchema["id"])
+	assert.Equal(u.T(), typing.String, optionalSchema["name"])
+	assert.Equal(u.T(), typing.NewKindDetailsFromTemplate(typing.ETime, ext.DateTimeKindType), optionalSchema["created_at"])
+	assert.Equal(u.T(), typing.Integer, optionalSchema["ttl"])
+	assert.Equal(u.T(), typing.Boolean, optionalSchema["active"])
+	assert.Equal(u.T(), typing.Float, optionalSchema["price"])
+	assert.Equal(u.T(), typing.Array, optionalSchema["tags"])
+
+	data := event.GetData(u.ctx, map[string]interface{}{"id": 1}, &kafkalib.TopicConfig{})
+	assert.Equal(u.T(), int64(1), data["id"])
+	assert.Equal(u.T(), "robin", data["name"])
+	assert.Equal(u.T(), time.Date(2023, 6, 27, 19, 33, 44, 123456000, time.UTC), data["created_at"].(*ext.ExtendedTime).Time)
+	assert.Equal(u.T(), int64(60_000_000), data["ttl"])
+	assert.Equal(u.T(), true, data["active"])
+	assert.Equal(u.T(), 9.99, data["price"])
+	assert.Equal(u.T(), []interface{}{"a"}, data["tags"])
+	assert.Equal(u.T(), false, data[constants.DeleteColumnMarker])
+}
+
+func (u *UtilTestSuite) TestFromProtobuf_DescriptorSet() {
+	_, messageDesc := u.protobufEnvelopeDescriptor()
+	bytes := u.encodeProtobuf(messageDesc, protobufEnvelope)
+
+	event, err := FromProtobuf(u.ctx, bytes, messageDesc)
+	assert.NoError(u.T(), err)
+	u.assertProtobufEvent(event)
+
+	// Messages in the wire format can also be decoded with a local descriptor, the schema ID is ignored.
+	event, err = FromProtobuf(u.ctx, append([]byte{0, 0, 0, 0, 1, 0}, bytes...), messageDesc)
+	assert.NoError(u.T(), err)
+	u.assertProtobufEvent(event)
+}
+
+func (u *UtilTestSuite) TestFromProtobuf_SchemaRegistry() {
+	fileProto, messageDesc := u.protobufEnvelopeDescriptor()
+	fileBytes, err := proto.Marshal(fileProto)
+	assert.NoError(u.T(), err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.String() != "/schemas/ids/7?format=serialized" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		assert.NoError(u.T(), json.NewEncoder(w).Encode(schemaregistry.Schema{
+			Schema:     base64.StdEncoding.EncodeToString(fileBytes),
+			SchemaType: schemaregistry.SchemaTypeProtobuf,
+		}))
+	}))
+	defer server.Close()
+
+	// Schema ID: 7, message indexes: [0]
+	bytes := append([]byte{0, 0, 0, 0, 7, 0}, u.encodeProtobuf(messageDesc, protobufEnvelope)...)
+	_, err = FromProtobuf(u.ctx, bytes, nil)
+	assert.ErrorContains(u.T(), err, "schema registry is not configured")
+
+	ctx := schemaregistry.InjectClientIntoCtx(u.ctx, schemaregistry.NewClient(server.URL, "", ""))
+	event, err := FromProtobuf(ctx, bytes, nil)
+	assert.NoError(u.T(), err)
+	u.assertProtobufEvent(event)
+
+	// Message indexes: [1] points to Value, which is not an envelope.
+	_, err = FromProtobuf(ctx, []byte{0, 0, 0, 0, 7, 2, 2, 0xff}, nil)
+	assert.Error(u.T(), err)
+
+	_, err = FromProtobuf(ctx, bytes[6:], nil)
+	assert.ErrorContains(u.T(), err, "unknown magic byte")
+}
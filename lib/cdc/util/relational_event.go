@@ -77,6 +77,14 @@ func (s *SchemaEventPayload) GetTableName() string {
 	return s.Payload.Source.Table
 }
 
+func (s *SchemaEventPayload) GetSourceMetadata() cdc.SourceMetadata {
+	return cdc.SourceMetadata{
+		Database: s.Payload.Source.Database,
+		Schema:   s.Payload.Source.Schema,
+		Table:    s.Payload.Source.Table,
+	}
+}
+
 func (s *SchemaEventPayload) GetData(ctx context.Context, pkMap map[string]interface{}, tc *kafkalib.TopicConfig) map[string]interface{} {
 	var retMap map[string]interface{}
 	if len(s.Payload.After) == 0 {
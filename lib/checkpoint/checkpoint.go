@@ -0,0 +1,79 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore keeps track of how far each source (e.g. a Kinesis stream's shard) has been flushed and persists it as JSON.
+// Every Set will rewrite the file, so the checkpoints survive a restart.
+type FileStore struct {
+	path string
+	// source -> partition -> position
+	checkpoints map[string]map[string]string
+	sync.Mutex
+}
+
+// NewFileStore will load the existing checkpoints from path, if the file does not exist, we'll start without any.
+func NewFileStore(path string) (*FileStore, error) {
+	store := &FileStore{
+		path:        path,
+		checkpoints: make(map[string]map[string]string),
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+
+		return nil, fmt.Errorf("failed to read checkpoint file: %s, err: %v", path, err)
+	}
+
+	if len(bytes) == 0 {
+		return store, nil
+	}
+
+	if err = json.Unmarshal(bytes, &store.checkpoints); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint file: %s, err: %v", path, err)
+	}
+
+	return store, nil
+}
+
+// Get returns the position for the source's partition, and false if it has not been checkpointed before.
+func (f *FileStore) Get(source, partition string) (string, bool) {
+	f.Lock()
+	defer f.Unlock()
+	position, isOk := f.checkpoints[source][partition]
+	return position, isOk
+}
+
+func (f *FileStore) Set(source, partition, position string) error {
+	f.Lock()
+	defer f.Unlock()
+	if _, isOk := f.checkpoints[source]; !isOk {
+		f.checkpoints[source] = make(map[string]string)
+	}
+
+	f.checkpoints[source][partition] = position
+	bytes, err := json.Marshal(f.checkpoints)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoints, err: %v", err)
+	}
+
+	// Write to a temporary file first and rename it, so we never leave behind a partially written file.
+	tmpPath := filepath.Join(filepath.Dir(f.path), fmt.Sprintf(".%s.tmp", filepath.Base(f.path)))
+	if err = os.WriteFile(tmpPath, bytes, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint file: %s, err: %v", tmpPath, err)
+	}
+
+	if err = os.Rename(tmpPath, f.path); err != nil {
+		return fmt.Errorf("failed to rename checkpoint file: %s, err: %v", tmpPath, err)
+	}
+
+	return nil
+}
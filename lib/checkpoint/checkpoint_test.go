@@ -0,0 +1,55 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+	store, err := NewFileStore(path)
+	assert.NoError(t, err)
+
+	_, isOk := store.Get("stream", "shardId-000000000000")
+	assert.False(t, isOk)
+
+	assert.NoError(t, store.Set("stream", "shardId-000000000000", "100"))
+	assert.NoError(t, store.Set("stream", "shardId-000000000001", "200"))
+	assert.NoError(t, store.Set("stream", "shardId-000000000000", "101"))
+	assert.NoError(t, store.Set("other_stream", "shardId-000000000000", "5"))
+
+	sequenceNumber, isOk := store.Get("stream", "shardId-000000000000")
+	assert.True(t, isOk)
+	assert.Equal(t, "101", sequenceNumber)
+
+	// Checkpoints should survive a restart.
+	store, err = NewFileStore(path)
+	assert.NoError(t, err)
+
+	sequenceNumber, isOk = store.Get("stream", "shardId-000000000000")
+	assert.True(t, isOk)
+	assert.Equal(t, "101", sequenceNumber)
+
+	sequenceNumber, isOk = store.Get("stream", "shardId-000000000001")
+	assert.True(t, isOk)
+	assert.Equal(t, "200", sequenceNumber)
+
+	sequenceNumber, isOk = store.Get("other_stream", "shardId-000000000000")
+	assert.True(t, isOk)
+	assert.Equal(t, "5", sequenceNumber)
+}
+
+func TestNewFileStore_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+	assert.NoError(t, os.WriteFile(path, []byte("not json"), 0644))
+
+	_, err := NewFileStore(path)
+	assert.ErrorContains(t, err, "failed to parse checkpoint file")
+
+	assert.NoError(t, os.WriteFile(path, nil, 0644))
+	_, err = NewFileStore(path)
+	assert.NoError(t, err)
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/artie-labs/transfer/lib/stringutil"
 
@@ -16,8 +18,20 @@ import (
 )
 
 const (
-	defaultFlushTimeSeconds = 10
-	defaultFlushSizeKb      = 25 * 1024 // 25 mb
+	defaultFlushTimeSeconds       = 10
+	defaultFlushSizeKb            = 25 * 1024 // 25 mb
+	defaultShutdownTimeoutSeconds = 30
+	defaultTopicDiscoverySeconds  = 60
+	// kafka-go defaults to 30 seconds as well.
+	defaultKafkaSessionTimeoutSeconds = 30
+	// defaultKafkaRebalanceTimeoutSeconds has to cover flushing the revoked partitions, see pubsubMergeAllowanceSeconds.
+	defaultKafkaRebalanceTimeoutSeconds = 5 * 60
+	// Pub/Sub's client library defaults to 60 mins as well.
+	defaultPubsubMaxAckExtensionSeconds = 60 * 60
+	// pubsubMergeAllowanceSeconds is how long we expect a flush to take once it has started.
+	// The ack extension has to cover the flush interval and this, otherwise messages would be redelivered while they're still buffered.
+	pubsubMergeAllowanceSeconds    = 5 * 60
+	defaultRedactionTruncateLength = 16
 
 	flushIntervalSecondsStart = 5
 	flushIntervalSecondsEnd   = 6 * 60 * 60
@@ -26,6 +40,29 @@ const (
 	bufferPoolSizeEnd   = 30000
 )
 
+// Admin is the optional HTTP server for health checks, table status and forcing flushes.
+type Admin struct {
+	// Address to listen on, e.g. ":8080".
+	Address string `yaml:"address"`
+	// Token is required to call the flush endpoints, which are disabled if it's not set.
+	Token string `yaml:"token"`
+}
+
+func (a *Admin) String() string {
+	// Don't log the token.
+	return fmt.Sprintf("address=%s, token_set=%v", a.Address, a.Token != "")
+}
+
+// Logging is the policy for data that may end up in our logs (and Sentry).
+type Logging struct {
+	// Redaction applies to message keys and values, as well as string literals within queries and errors.
+	// It defaults to off, hash will replace them with an HMAC-SHA256 digest keyed by HashSalt and truncate will only keep the first TruncateLength characters.
+	Redaction      constants.RedactionMode `yaml:"redaction"`
+	TruncateLength int                     `yaml:"truncateLength"`
+	// HashSalt is required by hash, so that the digests cannot be looked up in a precomputed table. Keep it secret.
+	HashSalt string `yaml:"hashSalt"`
+}
+
 type Sentry struct {
 	DSN string `yaml:"dsn"`
 }
@@ -34,6 +71,9 @@ type Pubsub struct {
 	ProjectID         string                  `yaml:"projectID"`
 	TopicConfigs      []*kafkalib.TopicConfig `yaml:"topicConfigs"`
 	PathToCredentials string                  `yaml:"pathToCredentials"`
+	// MaxAckExtensionSeconds is how long the ack deadline of a message will be extended for while its rows are waiting to be flushed.
+	// If the rows have not been flushed by then, the message will be redelivered.
+	MaxAckExtensionSeconds int `yaml:"maxAckExtensionSeconds"`
 }
 
 type Kafka struct {
@@ -46,6 +86,21 @@ type Kafka struct {
 	Password        string                  `yaml:"password"`
 	EnableAWSMSKIAM bool                    `yaml:"enableAWSMKSIAM"`
 	TopicConfigs    []*kafkalib.TopicConfig `yaml:"topicConfigs"`
+	// TopicPatterns are optional, matching topics are discovered from the brokers every TopicDiscoveryIntervalSeconds.
+	// If a topic has an explicit topic config, that will take precedence.
+	TopicPatterns                 []*kafkalib.TopicPattern `yaml:"topicPatterns"`
+	TopicDiscoveryIntervalSeconds int                      `yaml:"topicDiscoveryIntervalSeconds"`
+	// SessionTimeoutSeconds is how long the group will wait for a heartbeat before removing us from the group.
+	SessionTimeoutSeconds int `yaml:"sessionTimeoutSeconds"`
+	// RebalanceTimeoutSeconds is how long the group will wait for us to rejoin once it's rebalancing.
+	// The revoked partitions are flushed before we rejoin, so this has to cover a merge.
+	RebalanceTimeoutSeconds int `yaml:"rebalanceTimeoutSeconds"`
+
+	// SASLMechanism is optional, see Mechanism() for how it's inferred for backwards compatibility.
+	SASLMechanism SASLMechanism `yaml:"saslMechanism"`
+	TLS           *KafkaTLS     `yaml:"tls"`
+	// DisableTLS is used when SASL is enabled on a plaintext listener (SASL_PLAINTEXT).
+	DisableTLS bool `yaml:"disableTLS"`
 }
 
 type S3Settings struct {
@@ -98,6 +153,12 @@ type SharedTransferConfig struct {
 	CreateAllColumnsIfAvailable bool `yaml:"createAllColumnsIfAvailable"`
 }
 
+type SchemaRegistry struct {
+	URL      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
 type Snowflake struct {
 	AccountID string `yaml:"account"`
 	Username  string `yaml:"username"`
@@ -107,14 +168,51 @@ type Snowflake struct {
 	Host      string `yaml:"host"`
 }
 
+func (c *Config) defaultPubsubMaxAckExtensionSeconds() int {
+	if seconds := c.FlushIntervalSeconds + pubsubMergeAllowanceSeconds; seconds > defaultPubsubMaxAckExtensionSeconds {
+		return seconds
+	}
+
+	return defaultPubsubMaxAckExtensionSeconds
+}
+
+// PubsubMaxAckExtension is how long the client library will keep extending the ack deadline of a message that has not been flushed yet.
+// If it's not set, it defaults to 60 mins, or the flush interval plus the time to merge if that's longer.
+func (c *Config) PubsubMaxAckExtension() time.Duration {
+	seconds := c.Pubsub.MaxAckExtensionSeconds
+	if seconds == 0 {
+		seconds = c.defaultPubsubMaxAckExtensionSeconds()
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// SessionTimeout defaults to 30 seconds if it's not set.
+func (k *Kafka) SessionTimeout() time.Duration {
+	if k.SessionTimeoutSeconds == 0 {
+		return defaultKafkaSessionTimeoutSeconds * time.Second
+	}
+
+	return time.Duration(k.SessionTimeoutSeconds) * time.Second
+}
+
+// RebalanceTimeout defaults to 5 mins if it's not set.
+func (k *Kafka) RebalanceTimeout() time.Duration {
+	if k.RebalanceTimeoutSeconds == 0 {
+		return defaultKafkaRebalanceTimeoutSeconds * time.Second
+	}
+
+	return time.Duration(k.RebalanceTimeoutSeconds) * time.Second
+}
+
 func (p *Pubsub) String() string {
 	return fmt.Sprintf("project_id=%s, pathToCredentials=%s", p.ProjectID, p.PathToCredentials)
 }
 
 func (k *Kafka) String() string {
 	// Don't log credentials.
-	return fmt.Sprintf("bootstrapServer=%s, groupID=%s, user_set=%v, pass_set=%v",
-		k.BootstrapServer, k.GroupID, k.Username != "", k.Password != "")
+	return fmt.Sprintf("bootstrapServer=%s, groupID=%s, user_set=%v, pass_set=%v, saslMechanism=%s, tls_set=%v",
+		k.BootstrapServer, k.GroupID, k.Username != "", k.Password != "", k.Mechanism(), k.TLS != nil)
 }
 
 func (c *Config) TopicConfigs() ([]*kafkalib.TopicConfig, error) {
@@ -122,11 +220,19 @@ func (c *Config) TopicConfigs() ([]*kafkalib.TopicConfig, error) {
 		return nil, err
 	}
 
+	return c.queueTopicConfigs()
+}
+
+func (c *Config) queueTopicConfigs() ([]*kafkalib.TopicConfig, error) {
 	switch c.Queue {
 	case constants.Kafka:
 		return c.Kafka.TopicConfigs, nil
 	case constants.PubSub:
 		return c.Pubsub.TopicConfigs, nil
+	case constants.Kinesis:
+		return c.Kinesis.TopicConfigs, nil
+	case constants.FileQueue:
+		return c.File.TopicConfigs, nil
 	}
 
 	return nil, fmt.Errorf("unsupported queue: %v", c.Queue)
@@ -140,10 +246,21 @@ type Config struct {
 	FlushIntervalSeconds int  `yaml:"flushIntervalSeconds"`
 	FlushSizeKb          int  `yaml:"flushSizeKb"`
 	BufferRows           uint `yaml:"bufferRows"`
+	// MemoryBudgetKb is optional and caps the rows that are buffered across every table.
+	// Once exceeded, the largest tables are flushed first and the consumers are paused until we are back under budget.
+	MemoryBudgetKb int `yaml:"memoryBudgetKb"`
+
+	// ShutdownTimeoutSeconds is how long we will wait for the final flush on SIGTERM / SIGINT before exiting.
+	ShutdownTimeoutSeconds int `yaml:"shutdownTimeoutSeconds"`
 
 	// Supported message queues
-	Pubsub *Pubsub
-	Kafka  *Kafka
+	Pubsub  *Pubsub
+	Kafka   *Kafka
+	Kinesis *Kinesis   `yaml:"kinesis"`
+	File    *FileQueue `yaml:"file"`
+
+	// SchemaRegistry is required when decoding Avro.
+	SchemaRegistry *SchemaRegistry `yaml:"schemaRegistry"`
 
 	// Shared Transfer settings
 	SharedTransferConfig SharedTransferConfig `yaml:"sharedTransferConfig"`
@@ -151,12 +268,20 @@ type Config struct {
 	// Shared destination configuration
 	SharedDestinationConfig SharedDestinationConfig `yaml:"sharedDestinationConfig"`
 
+	// Destinations are optional, if set, each topic config can write to one or more of them (see kafkalib.TopicConfig.Destinations).
+	// Otherwise, Output is the only destination.
+	Destinations []*Destination `yaml:"destinations"`
+
 	// Supported destinations
 	BigQuery  *BigQuery   `yaml:"bigquery"`
 	Snowflake *Snowflake  `yaml:"snowflake"`
 	Redshift  *Redshift   `yaml:"redshift"`
 	S3        *S3Settings `yaml:"s3"`
 
+	Admin *Admin `yaml:"admin"`
+
+	Logging Logging `yaml:"logging"`
+
 	Reporting struct {
 		Sentry *Sentry `yaml:"sentry"`
 	}
@@ -166,6 +291,10 @@ type Config struct {
 			Provider constants.ExporterKind `yaml:"provider"`
 			Settings map[string]interface{} `yaml:"settings,omitempty"`
 		}
+		Tracing struct {
+			Provider constants.ExporterKind `yaml:"provider"`
+			Settings map[string]interface{} `yaml:"settings,omitempty"`
+		}
 	}
 }
 
@@ -206,6 +335,38 @@ func readFileToConfig(pathToConfig string) (*Config, error) {
 		config.FlushSizeKb = defaultFlushSizeKb
 	}
 
+	if config.ShutdownTimeoutSeconds == 0 {
+		config.ShutdownTimeoutSeconds = defaultShutdownTimeoutSeconds
+	}
+
+	if config.Kinesis != nil && config.Kinesis.PollIntervalMs == 0 {
+		config.Kinesis.PollIntervalMs = defaultKinesisPollIntervalMs
+	}
+
+	if config.Pubsub != nil && config.Pubsub.MaxAckExtensionSeconds == 0 {
+		config.Pubsub.MaxAckExtensionSeconds = config.defaultPubsubMaxAckExtensionSeconds()
+	}
+
+	if config.Logging.Redaction == "" {
+		config.Logging.Redaction = constants.RedactionOff
+	}
+
+	if config.Logging.Redaction == constants.RedactionTruncate && config.Logging.TruncateLength == 0 {
+		config.Logging.TruncateLength = defaultRedactionTruncateLength
+	}
+
+	if config.Kafka != nil && config.Kafka.TopicDiscoveryIntervalSeconds == 0 {
+		config.Kafka.TopicDiscoveryIntervalSeconds = defaultTopicDiscoverySeconds
+	}
+
+	if config.Kafka != nil && config.Kafka.SessionTimeoutSeconds == 0 {
+		config.Kafka.SessionTimeoutSeconds = defaultKafkaSessionTimeoutSeconds
+	}
+
+	if config.Kafka != nil && config.Kafka.RebalanceTimeoutSeconds == 0 {
+		config.Kafka.RebalanceTimeoutSeconds = defaultKafkaRebalanceTimeoutSeconds
+	}
+
 	return &config, nil
 }
 
@@ -230,6 +391,31 @@ func (c *Config) ValidateRedshift() error {
 	return nil
 }
 
+// validateDeadLetterQueue - a dead-letter queue can either be a local file or a topic within the same message queue we are consuming from.
+func validateDeadLetterQueue(tc *kafkalib.TopicConfig, queueKind kafkalib.DeadLetterQueueKind) error {
+	if tc.DeadLetterQueue == nil {
+		return nil
+	}
+
+	if tc.DeadLetterQueue.Kind != kafkalib.DeadLetterQueueFile && tc.DeadLetterQueue.Kind != queueKind {
+		return fmt.Errorf("config is invalid, dead letter queue kind: %s is not supported, tc: %s", tc.DeadLetterQueue.Kind, tc.String())
+	}
+
+	return nil
+}
+
+func (c *Config) validateSchemaRegistry(tc *kafkalib.TopicConfig) error {
+	if !tc.RequiresSchemaRegistry() {
+		return nil
+	}
+
+	if c.SchemaRegistry == nil || c.SchemaRegistry.URL == "" {
+		return fmt.Errorf("config is invalid, schema registry is required, tc: %s", tc.String())
+	}
+
+	return nil
+}
+
 // Validate will check the output source validity
 // It will also check if a topic exists + iterate over each topic to make sure it's valid.
 // The actual output source (like Snowflake) and CDC parser will be loaded and checked by other funcs.
@@ -251,40 +437,94 @@ func (c *Config) Validate() error {
 			c.FlushIntervalSeconds, flushIntervalSecondsStart, flushIntervalSecondsEnd)
 	}
 
-	if bufferPoolSizeStart > int(c.BufferRows) {
-		return fmt.Errorf("config is invalid, buffer pool is too small, min value: %v, actual: %v", bufferPoolSizeStart, int(c.BufferRows))
+	if c.MemoryBudgetKb < 0 {
+		return fmt.Errorf("config is invalid, memory budget cannot be negative, current value: %v", c.MemoryBudgetKb)
 	}
 
-	if !constants.IsValidDestination(c.Output) {
-		return fmt.Errorf("config is invalid, output: %s is invalid", c.Output)
+	if c.ShutdownTimeoutSeconds < 0 {
+		return fmt.Errorf("config is invalid, shutdown timeout cannot be negative, current value: %v", c.ShutdownTimeoutSeconds)
 	}
 
-	switch c.Output {
-	case constants.Redshift:
-		if err := c.ValidateRedshift(); err != nil {
-			return err
+	if c.Admin != nil && c.Admin.Address == "" {
+		return fmt.Errorf("config is invalid, admin address is empty")
+	}
+
+	switch c.Logging.Redaction {
+	case "", constants.RedactionOff:
+	case constants.RedactionHash:
+		if c.Logging.HashSalt == "" {
+			return fmt.Errorf("config is invalid, redaction hash requires a hash salt")
+		}
+	case constants.RedactionTruncate:
+		if c.Logging.TruncateLength <= 0 {
+			return fmt.Errorf("config is invalid, redaction truncate length has to be a positive number, current value: %v", c.Logging.TruncateLength)
 		}
-	case constants.S3:
-		if err := c.S3.Validate(); err != nil {
+	default:
+		return fmt.Errorf("config is invalid, redaction: %s is not supported", c.Logging.Redaction)
+	}
+
+	if bufferPoolSizeStart > int(c.BufferRows) {
+		return fmt.Errorf("config is invalid, buffer pool is too small, min value: %v, actual: %v", bufferPoolSizeStart, int(c.BufferRows))
+	}
+
+	// If there are named destinations, the output is set by each of them.
+	if len(c.Destinations) == 0 {
+		if err := c.validateOutput(); err != nil {
 			return err
 		}
 	}
 
 	if c.Queue == constants.Kafka {
-		if c.Kafka == nil || len(c.Kafka.TopicConfigs) == 0 {
+		if c.Kafka == nil || (len(c.Kafka.TopicConfigs) == 0 && len(c.Kafka.TopicPatterns) == 0) {
 			return fmt.Errorf("config is invalid, no kafka topic configs, kafka: %v", c.Kafka)
 		}
 
+		for _, topicPattern := range c.Kafka.TopicPatterns {
+			if valid := topicPattern.Valid(); !valid {
+				return fmt.Errorf("config is invalid, topic pattern is invalid, tp: %s", topicPattern.String())
+			}
+
+			if err := validateDeadLetterQueue(&topicPattern.TopicConfig, kafkalib.DeadLetterQueueKafka); err != nil {
+				return err
+			}
+
+			if err := c.validateSchemaRegistry(&topicPattern.TopicConfig); err != nil {
+				return err
+			}
+		}
+
+		if len(c.Kafka.TopicPatterns) > 0 && c.Kafka.TopicDiscoveryIntervalSeconds <= 0 {
+			return fmt.Errorf("config is invalid, topic discovery interval has to be a positive number, current value: %v", c.Kafka.TopicDiscoveryIntervalSeconds)
+		}
+
+		// Zero will use our defaults, see SessionTimeout and RebalanceTimeout.
+		if c.Kafka.SessionTimeoutSeconds < 0 || c.Kafka.RebalanceTimeoutSeconds < 0 {
+			return fmt.Errorf("config is invalid, kafka session and rebalance timeouts cannot be negative, session: %v, rebalance: %v",
+				c.Kafka.SessionTimeoutSeconds, c.Kafka.RebalanceTimeoutSeconds)
+		}
+
 		for _, topicConfig := range c.Kafka.TopicConfigs {
 			if valid := topicConfig.Valid(); !valid {
 				return fmt.Errorf("config is invalid, topic config is invalid, tc: %s", topicConfig.String())
 			}
+
+			if err := validateDeadLetterQueue(topicConfig, kafkalib.DeadLetterQueueKafka); err != nil {
+				return err
+			}
+
+			if err := c.validateSchemaRegistry(topicConfig); err != nil {
+				return err
+			}
 		}
 
 		// Username and password are not required (if it's within the same VPC or connecting locally
 		if array.Empty([]string{c.Kafka.GroupID, c.Kafka.BootstrapServer}) {
 			return fmt.Errorf("config is invalid, kafka settings is invalid, kafka: %s", c.Kafka.String())
 		}
+
+		if err := c.Kafka.validateAuth(); err != nil {
+			return err
+		}
 	}
 
 	if c.Queue == constants.PubSub {
@@ -296,12 +536,150 @@ func (c *Config) Validate() error {
 			if valid := topicConfig.Valid(); !valid {
 				return fmt.Errorf("config is invalid, topic config is invalid, tc: %s", topicConfig.String())
 			}
+
+			if err := validateDeadLetterQueue(topicConfig, kafkalib.DeadLetterQueuePubSub); err != nil {
+				return err
+			}
+
+			if err := c.validateSchemaRegistry(topicConfig); err != nil {
+				return err
+			}
 		}
 
 		if array.Empty([]string{c.Pubsub.ProjectID, c.Pubsub.PathToCredentials}) {
 			return fmt.Errorf("config is invalid, pubsub settings is invalid, pubsub: %s", c.Pubsub.String())
 		}
+
+		// Zero will use our default, see PubsubMaxAckExtension.
+		if c.Pubsub.MaxAckExtensionSeconds != 0 && c.Pubsub.MaxAckExtensionSeconds < c.FlushIntervalSeconds+pubsubMergeAllowanceSeconds {
+			return fmt.Errorf("config is invalid, pubsub max ack extension cannot be shorter than the flush interval plus %v seconds to merge, seconds: %v, flush interval: %v",
+				pubsubMergeAllowanceSeconds, c.Pubsub.MaxAckExtensionSeconds, c.FlushIntervalSeconds)
+		}
+	}
+
+	if c.Queue == constants.Kinesis {
+		if c.Kinesis == nil || len(c.Kinesis.TopicConfigs) == 0 {
+			return fmt.Errorf("config is invalid, no kinesis topic configs, kinesis: %v", c.Kinesis)
+		}
+
+		for _, topicConfig := range c.Kinesis.TopicConfigs {
+			if valid := topicConfig.Valid(); !valid {
+				return fmt.Errorf("config is invalid, topic config is invalid, tc: %s", topicConfig.String())
+			}
+
+			// Kinesis only supports the file dead-letter queue.
+			if err := validateDeadLetterQueue(topicConfig, kafkalib.DeadLetterQueueFile); err != nil {
+				return err
+			}
+
+			if err := c.validateSchemaRegistry(topicConfig); err != nil {
+				return err
+			}
+		}
+
+		if err := c.Kinesis.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Queue == constants.FileQueue {
+		if c.File == nil || len(c.File.TopicConfigs) == 0 {
+			return fmt.Errorf("config is invalid, no file topic configs, file: %v", c.File)
+		}
+
+		for _, topicConfig := range c.File.TopicConfigs {
+			if valid := topicConfig.Valid(); !valid {
+				return fmt.Errorf("config is invalid, topic config is invalid, tc: %s", topicConfig.String())
+			}
+
+			if err := validateDeadLetterQueue(topicConfig, kafkalib.DeadLetterQueueFile); err != nil {
+				return err
+			}
+
+			if err := c.validateSchemaRegistry(topicConfig); err != nil {
+				return err
+			}
+		}
+
+		if err := c.File.validate(); err != nil {
+			return err
+		}
+	}
+
+	tcs, err := c.queueTopicConfigs()
+	if err != nil {
+		return err
+	}
+
+	if err = validateFanIn(tcs); err != nil {
+		return err
+	}
+
+	if c.Queue == constants.Kafka {
+		for _, topicPattern := range c.Kafka.TopicPatterns {
+			tc := topicPattern.TopicConfig
+			tc.Topic = topicPattern.Pattern
+			tcs = append(tcs, &tc)
+		}
+	}
+
+	return c.validateDestinations(tcs)
+}
+
+// validateFanIn - if more than one topic config writes to the same table, they all need the same source identifier column,
+// and each of them needs to write a different value to it. Otherwise, rows with the same primary key from different sources will overwrite each other.
+// Templated names are only known once the events come in, so they're not checked here. Neither are values that come from the source database.
+func validateFanIn(tcs []*kafkalib.TopicConfig) error {
+	tableToTopicConfig := make(map[string]*kafkalib.TopicConfig)
+	// tableToSourceValues maps the source identifier values that are written to each table to the topic writing them.
+	tableToSourceValues := make(map[string]map[string]string)
+	for _, tc := range tcs {
+		if tc.TableName == "" || tc.NameTemplates != nil {
+			continue
+		}
+
+		key := strings.ToLower(fmt.Sprintf("%s.%s.%s", tc.Database, tc.Schema, tc.TableName))
+		prevTc, isOk := tableToTopicConfig[key]
+		if !isOk {
+			tableToTopicConfig[key] = tc
+			tableToSourceValues[key] = make(map[string]string)
+			if value, isOk := sourceIdentifierValue(tc); isOk {
+				tableToSourceValues[key][value] = tc.Topic
+			}
+
+			continue
+		}
+
+		if prevTc.SourceIdentifier == nil || tc.SourceIdentifier == nil ||
+			prevTc.SourceIdentifier.ColumnName() != tc.SourceIdentifier.ColumnName() {
+			return fmt.Errorf("config is invalid, topics: %s and %s write to the same table: %s, which requires the same source identifier column",
+				prevTc.Topic, tc.Topic, key)
+		}
+
+		if value, isOk := sourceIdentifierValue(tc); isOk {
+			if topic, isOk := tableToSourceValues[key][value]; isOk {
+				return fmt.Errorf("config is invalid, topics: %s and %s write the same source identifier value: %s to the same table: %s",
+					topic, tc.Topic, value, key)
+			}
+
+			tableToSourceValues[key][value] = tc.Topic
+		}
+
+		// The table is flushed with one of the topic configs, so they need to write to the same destinations.
+		if !sameDestinations(prevTc.Destinations, tc.Destinations) {
+			return fmt.Errorf("config is invalid, topics: %s and %s write to the same table: %s, which requires the same destinations",
+				prevTc.Topic, tc.Topic, key)
+		}
 	}
 
 	return nil
 }
+
+// sourceIdentifierValue returns the value that the topic config's source identifier writes, if it's known before any events come in.
+func sourceIdentifierValue(tc *kafkalib.TopicConfig) (string, bool) {
+	if tc.SourceIdentifier == nil || tc.SourceIdentifier.Kind == kafkalib.SourceIdentifierDatabase {
+		return "", false
+	}
+
+	return tc.SourceIdentifier.Identify(kafkalib.SourceNames{Topic: tc.Topic}), true
+}
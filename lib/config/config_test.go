@@ -206,6 +206,7 @@ kafka:
 	assert.Nil(t, err)
 
 	assert.Equal(t, config.FlushIntervalSeconds, defaultFlushTimeSeconds)
+	assert.Equal(t, config.ShutdownTimeoutSeconds, defaultShutdownTimeoutSeconds)
 	assert.Equal(t, int(config.BufferRows), bufferPoolSizeEnd)
 
 	validErr = config.Validate()
@@ -452,6 +453,25 @@ func TestConfig_Validate(t *testing.T) {
 	pubsub.PathToCredentials = "/tmp/abc"
 	assert.Nil(t, cfg.Validate())
 
+	// If it's not set, the ack extension defaults to 60 mins.
+	assert.Equal(t, time.Hour, cfg.PubsubMaxAckExtension())
+
+	// The ack extension cannot be shorter than the flush interval and the merge, otherwise messages would be redelivered before they are flushed.
+	pubsub.MaxAckExtensionSeconds = 1
+	assert.ErrorContains(t, cfg.Validate(), "pubsub max ack extension cannot be shorter than the flush interval plus 300 seconds to merge")
+	pubsub.MaxAckExtensionSeconds = 304
+	assert.ErrorContains(t, cfg.Validate(), "pubsub max ack extension cannot be shorter than the flush interval plus 300 seconds to merge")
+	pubsub.MaxAckExtensionSeconds = 3600
+	assert.Nil(t, cfg.Validate())
+	assert.Equal(t, time.Hour, cfg.PubsubMaxAckExtension())
+
+	// With a long flush interval, the default will cover the flush interval and the merge.
+	pubsub.MaxAckExtensionSeconds = 0
+	cfg.FlushIntervalSeconds = 2 * 60 * 60
+	assert.Equal(t, 2*time.Hour+5*time.Minute, cfg.PubsubMaxAckExtension())
+	cfg.FlushIntervalSeconds = 5
+	pubsub.MaxAckExtensionSeconds = 3600
+
 	tcs, err := cfg.TopicConfigs()
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(tcs))
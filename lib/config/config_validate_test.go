@@ -2,8 +2,10 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/kafkalib"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -149,3 +151,446 @@ func TestCfg_ValidateRedshift(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateDeadLetterQueue(t *testing.T) {
+	tc := &kafkalib.TopicConfig{
+		Database:  "db",
+		Schema:    "schema",
+		Topic:     "topic",
+		CDCFormat: "debezium.postgres",
+	}
+
+	assert.NoError(t, validateDeadLetterQueue(tc, kafkalib.DeadLetterQueueKafka))
+
+	tc.DeadLetterQueue = &kafkalib.DeadLetterQueue{Kind: kafkalib.DeadLetterQueueFile, Path: "/tmp/dlq.jsonl"}
+	assert.NoError(t, validateDeadLetterQueue(tc, kafkalib.DeadLetterQueueKafka))
+	assert.NoError(t, validateDeadLetterQueue(tc, kafkalib.DeadLetterQueuePubSub))
+
+	tc.DeadLetterQueue = &kafkalib.DeadLetterQueue{Kind: kafkalib.DeadLetterQueueKafka, Topic: "dlq"}
+	assert.NoError(t, validateDeadLetterQueue(tc, kafkalib.DeadLetterQueueKafka))
+	assert.Error(t, validateDeadLetterQueue(tc, kafkalib.DeadLetterQueuePubSub))
+
+	tc.DeadLetterQueue = &kafkalib.DeadLetterQueue{Kind: kafkalib.DeadLetterQueuePubSub, Topic: "dlq"}
+	assert.Error(t, validateDeadLetterQueue(tc, kafkalib.DeadLetterQueueKafka))
+	assert.NoError(t, validateDeadLetterQueue(tc, kafkalib.DeadLetterQueuePubSub))
+}
+
+func TestValidateSchemaRegistry(t *testing.T) {
+	cfg := &Config{}
+	tc := &kafkalib.TopicConfig{
+		Database:     "db",
+		Schema:       "schema",
+		Topic:        "topic",
+		CDCFormat:    constants.DBZPostgresFormat,
+		CDCKeyFormat: "org.apache.kafka.connect.json.JsonConverter",
+	}
+
+	assert.NoError(t, cfg.validateSchemaRegistry(tc))
+
+	tc.CDCFormat = constants.DBZPostgresAvroFormat
+	assert.ErrorContains(t, cfg.validateSchemaRegistry(tc), "schema registry is required")
+
+	tc.CDCFormat = constants.DBZMySQLFormat
+	tc.CDCKeyFormat = "io.confluent.connect.avro.AvroConverter"
+	assert.ErrorContains(t, cfg.validateSchemaRegistry(tc), "schema registry is required")
+
+	cfg.SchemaRegistry = &SchemaRegistry{}
+	assert.ErrorContains(t, cfg.validateSchemaRegistry(tc), "schema registry is required")
+
+	cfg.SchemaRegistry.URL = "http://localhost:8081"
+	assert.NoError(t, cfg.validateSchemaRegistry(tc))
+}
+
+func TestCfg_ValidateTopicPatterns(t *testing.T) {
+	cfg := &Config{
+		Output:               constants.Snowflake,
+		Queue:                constants.Kafka,
+		FlushIntervalSeconds: 10,
+		FlushSizeKb:          5,
+		BufferRows:           500,
+		Kafka: &Kafka{
+			BootstrapServer: "localhost:9092",
+			GroupID:         "group",
+			TopicPatterns: []*kafkalib.TopicPattern{
+				{
+					Pattern: `^dbserver\.public\.(\w+)$`,
+					TopicConfig: kafkalib.TopicConfig{
+						Database:  "db",
+						Schema:    "public",
+						TableName: "$1",
+						CDCFormat: constants.DBZPostgresFormat,
+					},
+				},
+			},
+			TopicDiscoveryIntervalSeconds: 60,
+		},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Kafka.TopicDiscoveryIntervalSeconds = 0
+	assert.ErrorContains(t, cfg.Validate(), "topic discovery interval has to be a positive number")
+
+	cfg.Kafka.TopicDiscoveryIntervalSeconds = 60
+	cfg.Kafka.RebalanceTimeoutSeconds = -1
+	assert.ErrorContains(t, cfg.Validate(), "kafka session and rebalance timeouts cannot be negative")
+
+	cfg.Kafka.RebalanceTimeoutSeconds = 0
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, 30*time.Second, cfg.Kafka.SessionTimeout())
+	assert.Equal(t, 5*time.Minute, cfg.Kafka.RebalanceTimeout())
+
+	cfg.Kafka.SessionTimeoutSeconds = 45
+	cfg.Kafka.RebalanceTimeoutSeconds = 900
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, 45*time.Second, cfg.Kafka.SessionTimeout())
+	assert.Equal(t, 15*time.Minute, cfg.Kafka.RebalanceTimeout())
+
+	cfg.Kafka.TopicPatterns[0].Pattern = `^dbserver\.(`
+	assert.ErrorContains(t, cfg.Validate(), "topic pattern is invalid")
+
+	cfg.Kafka.TopicPatterns = nil
+	assert.ErrorContains(t, cfg.Validate(), "no kafka topic configs")
+}
+
+func TestCfg_ValidateKinesis(t *testing.T) {
+	tc := &kafkalib.TopicConfig{
+		Database:     "db",
+		Schema:       "public",
+		Topic:        "stream",
+		CDCFormat:    constants.DBZPostgresFormat,
+		CDCKeyFormat: "org.apache.kafka.connect.json.JsonConverter",
+	}
+
+	cfg := &Config{
+		Output:               constants.Snowflake,
+		Queue:                constants.Kinesis,
+		FlushIntervalSeconds: 10,
+		FlushSizeKb:          5,
+		BufferRows:           500,
+	}
+	assert.ErrorContains(t, cfg.Validate(), "no kinesis topic configs")
+
+	cfg.Kinesis = &Kinesis{
+		Region:         "us-east-1",
+		CheckpointPath: "/tmp/checkpoints.json",
+		PollIntervalMs: 1000,
+		TopicConfigs:   []*kafkalib.TopicConfig{tc},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	tcs, err := cfg.TopicConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, []*kafkalib.TopicConfig{tc}, tcs)
+
+	tc.DeadLetterQueue = &kafkalib.DeadLetterQueue{Kind: kafkalib.DeadLetterQueueKafka, Topic: "dlq"}
+	assert.ErrorContains(t, cfg.Validate(), "dead letter queue kind: kafka is not supported")
+
+	tc.DeadLetterQueue = &kafkalib.DeadLetterQueue{Kind: kafkalib.DeadLetterQueueFile, Path: "/tmp/dlq.jsonl"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Kinesis.AwsAccessKeyID = "key"
+	assert.ErrorContains(t, cfg.Validate(), "have to be set together")
+
+	cfg.Kinesis.AwsSecretAccessKey = "secret"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Kinesis.PollIntervalMs = 0
+	assert.ErrorContains(t, cfg.Validate(), "kinesis poll interval has to be a positive number")
+
+	cfg.Kinesis.PollIntervalMs = 1000
+	cfg.Kinesis.CheckpointPath = ""
+	assert.ErrorContains(t, cfg.Validate(), "kinesis settings is invalid")
+}
+
+func TestCfg_ValidateFileQueue(t *testing.T) {
+	cfg := &Config{
+		Output:               constants.Snowflake,
+		Queue:                constants.FileQueue,
+		FlushIntervalSeconds: 10,
+		FlushSizeKb:          5,
+		BufferRows:           500,
+	}
+	assert.ErrorContains(t, cfg.Validate(), "no file topic configs")
+
+	cfg.File = &FileQueue{
+		TopicConfigs: []*kafkalib.TopicConfig{
+			{
+				Database:  "db",
+				Schema:    "public",
+				Topic:     "topic",
+				CDCFormat: constants.DBZPostgresFormat,
+			},
+		},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "file queue settings is invalid")
+
+	cfg.File.Paths = []string{"/tmp/replay"}
+	cfg.File.CheckpointPath = "/tmp/checkpoints.json"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.File.TopicConfigs[0].DeadLetterQueue = &kafkalib.DeadLetterQueue{Kind: kafkalib.DeadLetterQueuePubSub, Topic: "dlq"}
+	assert.ErrorContains(t, cfg.Validate(), "dead letter queue kind: pubsub is not supported")
+}
+
+func TestCfg_ValidateMemoryBudget(t *testing.T) {
+	cfg := &Config{
+		Output:               constants.Snowflake,
+		Queue:                constants.FileQueue,
+		FlushIntervalSeconds: 10,
+		FlushSizeKb:          5,
+		BufferRows:           500,
+		File: &FileQueue{
+			Paths:          []string{"/tmp/replay"},
+			CheckpointPath: "/tmp/checkpoints.json",
+			TopicConfigs: []*kafkalib.TopicConfig{
+				{
+					Database:  "db",
+					Schema:    "public",
+					Topic:     "topic",
+					CDCFormat: constants.DBZPostgresFormat,
+				},
+			},
+		},
+	}
+	// The memory budget is optional.
+	assert.NoError(t, cfg.Validate())
+
+	cfg.MemoryBudgetKb = 512 * 1024
+	assert.NoError(t, cfg.Validate())
+
+	cfg.MemoryBudgetKb = -1
+	assert.ErrorContains(t, cfg.Validate(), "memory budget cannot be negative")
+}
+
+func TestCfg_ValidateAdmin(t *testing.T) {
+	cfg := &Config{
+		Output:               constants.Snowflake,
+		Queue:                constants.FileQueue,
+		FlushIntervalSeconds: 10,
+		FlushSizeKb:          5,
+		BufferRows:           500,
+		File: &FileQueue{
+			Paths:          []string{"/tmp/replay"},
+			CheckpointPath: "/tmp/checkpoints.json",
+			TopicConfigs: []*kafkalib.TopicConfig{
+				{
+					Database:  "db",
+					Schema:    "public",
+					Topic:     "topic",
+					CDCFormat: constants.DBZPostgresFormat,
+				},
+			},
+		},
+		Admin: &Admin{},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "admin address is empty")
+
+	// The token is optional, flush endpoints are disabled without it.
+	cfg.Admin.Address = ":8080"
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, "address=:8080, token_set=false", cfg.Admin.String())
+}
+
+func TestCfg_ValidateLogging(t *testing.T) {
+	cfg := &Config{
+		Output:               constants.Snowflake,
+		Queue:                constants.FileQueue,
+		FlushIntervalSeconds: 10,
+		FlushSizeKb:          5,
+		BufferRows:           500,
+		File: &FileQueue{
+			Paths:          []string{"/tmp/replay"},
+			CheckpointPath: "/tmp/checkpoints.json",
+			TopicConfigs: []*kafkalib.TopicConfig{
+				{
+					Database:  "db",
+					Schema:    "public",
+					Topic:     "topic",
+					CDCFormat: constants.DBZPostgresFormat,
+				},
+			},
+		},
+	}
+
+	// Redaction is off by default.
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Logging.Redaction = constants.RedactionHash
+	assert.ErrorContains(t, cfg.Validate(), "redaction hash requires a hash salt")
+
+	cfg.Logging.HashSalt = "salt"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Logging.Redaction = constants.RedactionTruncate
+	assert.ErrorContains(t, cfg.Validate(), "redaction truncate length has to be a positive number")
+
+	cfg.Logging.TruncateLength = 8
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Logging.Redaction = "mask"
+	assert.ErrorContains(t, cfg.Validate(), "redaction: mask is not supported")
+}
+
+func TestCfg_ValidateFanIn(t *testing.T) {
+	shard := func(topic string, sourceIdentifier *kafkalib.SourceIdentifier) *kafkalib.TopicConfig {
+		return &kafkalib.TopicConfig{
+			Database:         "db",
+			Schema:           "public",
+			TableName:        "orders",
+			Topic:            topic,
+			CDCFormat:        constants.DBZPostgresFormat,
+			SourceIdentifier: sourceIdentifier,
+		}
+	}
+
+	cfg := &Config{
+		Output:               constants.Snowflake,
+		Queue:                constants.FileQueue,
+		FlushIntervalSeconds: 10,
+		FlushSizeKb:          5,
+		BufferRows:           500,
+		File: &FileQueue{
+			Paths:          []string{"/tmp/replay"},
+			CheckpointPath: "/tmp/checkpoints.json",
+			TopicConfigs: []*kafkalib.TopicConfig{
+				shard("shard_1.public.orders", nil),
+				shard("shard_2.public.orders", nil),
+			},
+		},
+	}
+
+	assert.ErrorContains(t, cfg.Validate(), "topics: shard_1.public.orders and shard_2.public.orders write to the same table: db.public.orders")
+
+	cfg.File.TopicConfigs = []*kafkalib.TopicConfig{
+		shard("shard_1.public.orders", &kafkalib.SourceIdentifier{Column: "shard", Kind: kafkalib.SourceIdentifierTopic}),
+		shard("shard_2.public.orders", &kafkalib.SourceIdentifier{Column: "source_db", Kind: kafkalib.SourceIdentifierDatabase}),
+	}
+	assert.ErrorContains(t, cfg.Validate(), "requires the same source identifier column")
+
+	cfg.File.TopicConfigs[1].SourceIdentifier = &kafkalib.SourceIdentifier{Column: "SHARD", Kind: kafkalib.SourceIdentifierConstant, Value: "shard_2"}
+	assert.NoError(t, cfg.Validate())
+
+	// Every source needs to write a different value.
+	cfg.File.TopicConfigs[1].SourceIdentifier.Value = "shard_1.public.orders"
+	assert.ErrorContains(t, cfg.Validate(), "topics: shard_1.public.orders and shard_2.public.orders write the same source identifier value: shard_1.public.orders to the same table: db.public.orders")
+
+	cfg.File.TopicConfigs[0].SourceIdentifier = &kafkalib.SourceIdentifier{Column: "shard", Kind: kafkalib.SourceIdentifierConstant, Value: "shard_1.public.orders"}
+	assert.ErrorContains(t, cfg.Validate(), "write the same source identifier value")
+
+	// Values that come from the source database are only known once the events come in.
+	cfg.File.TopicConfigs[0].SourceIdentifier = &kafkalib.SourceIdentifier{Column: "shard", Kind: kafkalib.SourceIdentifierDatabase}
+	cfg.File.TopicConfigs[1].SourceIdentifier = &kafkalib.SourceIdentifier{Column: "shard", Kind: kafkalib.SourceIdentifierDatabase}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.File.TopicConfigs[1].SourceIdentifier = &kafkalib.SourceIdentifier{Column: "SHARD", Kind: kafkalib.SourceIdentifierConstant, Value: "shard_2"}
+
+	cfg.File.TopicConfigs[1].Destinations = []string{"lake"}
+	assert.ErrorContains(t, cfg.Validate(), "requires the same destinations")
+	cfg.File.TopicConfigs[1].Destinations = nil
+
+	// Different tables don't need a source identifier.
+	cfg.File.TopicConfigs = []*kafkalib.TopicConfig{shard("shard_1.public.orders", nil), shard("shard_2.public.orders", nil)}
+	cfg.File.TopicConfigs[1].TableName = "orders_2"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestCfg_ValidateDestinations(t *testing.T) {
+	cfg := &Config{
+		Queue:                constants.FileQueue,
+		FlushIntervalSeconds: 10,
+		FlushSizeKb:          5,
+		BufferRows:           500,
+		Snowflake:            &Snowflake{AccountID: "account"},
+		File: &FileQueue{
+			Paths:          []string{"/tmp/replay"},
+			CheckpointPath: "/tmp/checkpoints.json",
+			TopicConfigs: []*kafkalib.TopicConfig{
+				{
+					Database:     "db",
+					Schema:       "public",
+					Topic:        "orders",
+					CDCFormat:    constants.DBZPostgresFormat,
+					Destinations: []string{"analytics"},
+				},
+			},
+		},
+	}
+
+	// Without any named destinations, the output is required and topic configs cannot select destinations.
+	assert.ErrorContains(t, cfg.Validate(), "output:  is invalid")
+	cfg.Output = constants.Snowflake
+	assert.ErrorContains(t, cfg.Validate(), "topic: orders selects destinations: [analytics], but no destinations are configured")
+
+	cfg.Output = ""
+	cfg.Destinations = []*Destination{
+		{Name: "analytics", Output: constants.Snowflake},
+		{Name: "lake", Output: constants.S3},
+	}
+	assert.ErrorContains(t, cfg.Validate(), "destination: lake, err: s3 settings are nil")
+
+	cfg.Destinations[1].S3 = &S3Settings{
+		Bucket:             "bucket",
+		AwsAccessKeyID:     "id",
+		AwsSecretAccessKey: "secret",
+		OutputFormat:       constants.ParquetFormat,
+	}
+	assert.ErrorContains(t, cfg.Validate(), "destination: lake is not used by any topic config")
+
+	cfg.File.TopicConfigs[0].Destinations = []string{"analytics", "lake", "warehouse"}
+	assert.ErrorContains(t, cfg.Validate(), "topic: orders selects destination: warehouse, which does not exist")
+
+	// No destinations means all of them.
+	cfg.File.TopicConfigs[0].Destinations = nil
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Destinations = append(cfg.Destinations, &Destination{Name: "lake", Output: constants.Snowflake})
+	assert.ErrorContains(t, cfg.Validate(), "destination: lake is duplicated")
+
+	cfg.Destinations[2] = &Destination{Output: constants.Snowflake}
+	assert.ErrorContains(t, cfg.Validate(), "destination name is empty")
+}
+
+func TestCfg_ForDestination(t *testing.T) {
+	lake := &S3Settings{Bucket: "lake"}
+	cfg := &Config{
+		Queue:     constants.Kafka,
+		Output:    constants.Snowflake,
+		Snowflake: &Snowflake{AccountID: "account"},
+		S3:        &S3Settings{Bucket: "bucket"},
+		Kafka: &Kafka{
+			TopicConfigs: []*kafkalib.TopicConfig{
+				{Topic: "orders", Destinations: []string{"analytics", "lake"}},
+				{Topic: "events", Destinations: []string{"lake"}},
+				{Topic: "users"},
+			},
+			TopicPatterns: []*kafkalib.TopicPattern{
+				{Pattern: "^shard_.*", TopicConfig: kafkalib.TopicConfig{Destinations: []string{"analytics"}}},
+			},
+		},
+		Destinations: []*Destination{
+			{Name: "analytics", Output: constants.Snowflake},
+			{Name: "lake", Output: constants.S3, S3: lake},
+		},
+	}
+
+	analytics := cfg.ForDestination(cfg.Destinations[0])
+	assert.Nil(t, analytics.Destinations)
+	assert.Equal(t, constants.Snowflake, analytics.Output)
+	assert.Equal(t, "account", analytics.Snowflake.AccountID)
+	assert.Len(t, analytics.Kafka.TopicConfigs, 2)
+	assert.Equal(t, "orders", analytics.Kafka.TopicConfigs[0].Topic)
+	assert.Nil(t, analytics.Kafka.TopicConfigs[0].Destinations)
+	assert.Equal(t, "users", analytics.Kafka.TopicConfigs[1].Topic)
+	assert.Len(t, analytics.Kafka.TopicPatterns, 1)
+
+	lakeCfg := cfg.ForDestination(cfg.Destinations[1])
+	assert.Equal(t, constants.S3, lakeCfg.Output)
+	assert.Equal(t, lake, lakeCfg.S3)
+	assert.Len(t, lakeCfg.Kafka.TopicConfigs, 3)
+	assert.Len(t, lakeCfg.Kafka.TopicPatterns, 0)
+
+	// The original config is left as-is.
+	assert.Equal(t, []string{"analytics", "lake"}, cfg.Kafka.TopicConfigs[0].Destinations)
+	assert.Equal(t, "bucket", cfg.S3.Bucket)
+	assert.Len(t, cfg.Kafka.TopicConfigs, 3)
+}
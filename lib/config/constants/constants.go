@@ -23,6 +23,14 @@ const (
 	DBZPostgresAltFormat = "debezium.postgres.wal2json"
 	DBZMongoFormat       = "debezium.mongodb"
 	DBZMySQLFormat       = "debezium.mysql"
+
+	// Avro formats are serialized by Confluent's AvroConverter and require a schema registry to decode.
+	DBZPostgresAvroFormat = "debezium.postgres.avro"
+	DBZMySQLAvroFormat    = "debezium.mysql.avro"
+
+	// Protobuf formats are serialized by Confluent's ProtobufConverter, descriptors are fetched from the schema registry or a local descriptor set.
+	DBZPostgresProtobufFormat = "debezium.postgres.protobuf"
+	DBZMySQLProtobufFormat    = "debezium.mysql.protobuf"
 )
 
 // ReservedKeywords is populated from: https://docs.snowflake.com/en/sql-reference/reserved-keywords
@@ -91,7 +99,21 @@ var ReservedKeywords = []string{
 type ExporterKind string
 
 const (
-	Datadog ExporterKind = "datadog"
+	Datadog    ExporterKind = "datadog"
+	Prometheus ExporterKind = "prometheus"
+
+	// OTLP and Stdout are used to export traces.
+	OTLP   ExporterKind = "otlp"
+	Stdout ExporterKind = "stdout"
+)
+
+// RedactionMode is how message bodies and query literals are redacted before they are logged.
+type RedactionMode string
+
+const (
+	RedactionOff      RedactionMode = "off"
+	RedactionHash     RedactionMode = "hash"
+	RedactionTruncate RedactionMode = "truncate"
 )
 
 // ColumnOperation is a type used for DDL operations
@@ -105,8 +127,11 @@ const (
 type QueueKind string
 
 const (
-	Kafka  QueueKind = "kafka"
-	PubSub QueueKind = "pubsub"
+	Kafka   QueueKind = "kafka"
+	PubSub  QueueKind = "pubsub"
+	Kinesis QueueKind = "kinesis"
+	// FileQueue will replay newline-delimited records from local files, see config.FileQueue.
+	FileQueue QueueKind = "file"
 )
 
 type DestinationKind string
@@ -13,7 +13,7 @@ type Settings struct {
 	VerboseLogging bool
 }
 
-// InjectSettingsIntoContext is used for tests ONLY
+// InjectSettingsIntoContext is used for tests, and to scope the settings to one of the named destinations.
 func InjectSettingsIntoContext(ctx context.Context, settings *Settings) context.Context {
 	return context.WithValue(ctx, settingsKey, settings)
 }
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+)
+
+// Destination is a named destination that topic configs can write to, see kafkalib.TopicConfig.Destinations.
+// The destination's settings are optional and default to the top level ones, e.g. two Snowflake destinations will need their own,
+// but a Snowflake and an S3 destination can reuse `snowflake` and `s3`.
+type Destination struct {
+	Name   string                    `yaml:"name"`
+	Output constants.DestinationKind `yaml:"outputSource"`
+
+	BigQuery  *BigQuery   `yaml:"bigquery"`
+	Snowflake *Snowflake  `yaml:"snowflake"`
+	Redshift  *Redshift   `yaml:"redshift"`
+	S3        *S3Settings `yaml:"s3"`
+}
+
+func (d *Destination) String() string {
+	return fmt.Sprintf("name=%s, output=%s", d.Name, d.Output)
+}
+
+func topicConfigsForDestination(tcs []*kafkalib.TopicConfig, destination string) []*kafkalib.TopicConfig {
+	var filtered []*kafkalib.TopicConfig
+	for _, tc := range tcs {
+		if !tc.WritesTo(destination) {
+			continue
+		}
+
+		tcCopy := *tc
+		tcCopy.Destinations = nil
+		filtered = append(filtered, &tcCopy)
+	}
+
+	return filtered
+}
+
+// ForDestination returns a copy of the config as if the destination was the only output, along with the topic configs that write to it.
+// This way, the destination's client can be loaded (and swept) the same way as a single destination.
+func (c *Config) ForDestination(d *Destination) *Config {
+	cfg := *c
+	cfg.Destinations = nil
+	cfg.Output = d.Output
+	if d.BigQuery != nil {
+		cfg.BigQuery = d.BigQuery
+	}
+
+	if d.Snowflake != nil {
+		cfg.Snowflake = d.Snowflake
+	}
+
+	if d.Redshift != nil {
+		cfg.Redshift = d.Redshift
+	}
+
+	if d.S3 != nil {
+		cfg.S3 = d.S3
+	}
+
+	switch c.Queue {
+	case constants.Kafka:
+		if c.Kafka != nil {
+			kafka := *c.Kafka
+			kafka.TopicConfigs = topicConfigsForDestination(c.Kafka.TopicConfigs, d.Name)
+			kafka.TopicPatterns = nil
+			for _, topicPattern := range c.Kafka.TopicPatterns {
+				if !topicPattern.WritesTo(d.Name) {
+					continue
+				}
+
+				tpCopy := *topicPattern
+				tpCopy.Destinations = nil
+				kafka.TopicPatterns = append(kafka.TopicPatterns, &tpCopy)
+			}
+
+			cfg.Kafka = &kafka
+		}
+	case constants.PubSub:
+		if c.Pubsub != nil {
+			pubsub := *c.Pubsub
+			pubsub.TopicConfigs = topicConfigsForDestination(c.Pubsub.TopicConfigs, d.Name)
+			cfg.Pubsub = &pubsub
+		}
+	case constants.Kinesis:
+		if c.Kinesis != nil {
+			kinesis := *c.Kinesis
+			kinesis.TopicConfigs = topicConfigsForDestination(c.Kinesis.TopicConfigs, d.Name)
+			cfg.Kinesis = &kinesis
+		}
+	case constants.FileQueue:
+		if c.File != nil {
+			file := *c.File
+			file.TopicConfigs = topicConfigsForDestination(c.File.TopicConfigs, d.Name)
+			cfg.File = &file
+		}
+	}
+
+	return &cfg
+}
+
+// sameDestinations returns whether both topic configs select the same destinations, regardless of the order.
+func sameDestinations(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for idx := range sortedA {
+		if sortedA[idx] != sortedB[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateOutput checks that the output is supported and that its settings are set.
+func (c *Config) validateOutput() error {
+	if !constants.IsValidDestination(c.Output) {
+		return fmt.Errorf("config is invalid, output: %s is invalid", c.Output)
+	}
+
+	switch c.Output {
+	case constants.Redshift:
+		if err := c.ValidateRedshift(); err != nil {
+			return err
+		}
+	case constants.S3:
+		if err := c.S3.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateDestinations - every destination needs a unique name, valid settings and at least one topic config that writes to it.
+// Topic configs can only select destinations that exist.
+func (c *Config) validateDestinations(tcs []*kafkalib.TopicConfig) error {
+	if len(c.Destinations) == 0 {
+		for _, tc := range tcs {
+			if len(tc.Destinations) > 0 {
+				return fmt.Errorf("config is invalid, topic: %s selects destinations: %v, but no destinations are configured", tc.Topic, tc.Destinations)
+			}
+		}
+
+		return nil
+	}
+
+	destinations := make(map[string]bool)
+	for _, destination := range c.Destinations {
+		if destination == nil || destination.Name == "" {
+			return fmt.Errorf("config is invalid, destination name is empty")
+		}
+
+		if destinations[destination.Name] {
+			return fmt.Errorf("config is invalid, destination: %s is duplicated", destination.Name)
+		}
+
+		destinations[destination.Name] = true
+		if err := c.ForDestination(destination).validateOutput(); err != nil {
+			return fmt.Errorf("config is invalid, destination: %s, err: %v", destination.Name, err)
+		}
+
+		var used bool
+		for _, tc := range tcs {
+			if tc.WritesTo(destination.Name) {
+				used = true
+				break
+			}
+		}
+
+		if !used {
+			return fmt.Errorf("config is invalid, destination: %s is not used by any topic config", destination.Name)
+		}
+	}
+
+	for _, tc := range tcs {
+		for _, destination := range tc.Destinations {
+			if !destinations[destination] {
+				return fmt.Errorf("config is invalid, topic: %s selects destination: %s, which does not exist", tc.Topic, destination)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/artie-labs/transfer/lib/kafkalib"
+)
+
+// FileQueue replays records from local files, each line is a JSON object in the same shape as a dead-letter queue record.
+// The key and value are only base64 decoded if the record's encoding is base64 (as it is within the dead-letter queue),
+// otherwise they're used as is, for plain CDC dumps.
+type FileQueue struct {
+	// Paths can either be files or directories. Files within a directory are read in lexical order, hidden files are skipped.
+	Paths []string `yaml:"paths"`
+	// CheckpointPath is the file that we'll store the number of lines that have been flushed for each file in.
+	CheckpointPath string                  `yaml:"checkpointPath"`
+	TopicConfigs   []*kafkalib.TopicConfig `yaml:"topicConfigs"`
+}
+
+func (f *FileQueue) String() string {
+	return fmt.Sprintf("paths=%v, checkpointPath=%s", f.Paths, f.CheckpointPath)
+}
+
+func (f *FileQueue) validate() error {
+	if len(f.Paths) == 0 || f.CheckpointPath == "" {
+		return fmt.Errorf("config is invalid, file queue settings is invalid, file: %s", f.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+type SASLMechanism string
+
+const (
+	SASLPlain       SASLMechanism = "plain"
+	SASLScramSHA256 SASLMechanism = "scram-sha-256"
+	SASLScramSHA512 SASLMechanism = "scram-sha-512"
+	SASLAWSMSKIAM   SASLMechanism = "aws-msk-iam"
+)
+
+type KafkaTLS struct {
+	// CAFile is optional, if it's not set we will use the system's root CAs.
+	CAFile string `yaml:"caFile"`
+	// CertFile and KeyFile are used for mutual TLS.
+	CertFile           string `yaml:"certFile"`
+	KeyFile            string `yaml:"keyFile"`
+	ServerName         string `yaml:"serverName"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+}
+
+// Mechanism returns the SASL mechanism, an empty string means no auth.
+// If it's not explicitly set, we'll fall back to PLAIN if a username is set or AWS MSK IAM if it's enabled.
+func (k *Kafka) Mechanism() SASLMechanism {
+	if k.SASLMechanism != "" {
+		return k.SASLMechanism
+	}
+
+	if k.Username != "" {
+		return SASLPlain
+	}
+
+	if k.EnableAWSMSKIAM {
+		return SASLAWSMSKIAM
+	}
+
+	return ""
+}
+
+// TLSConfig returns nil if TLS is not enabled.
+// TLS is enabled if it's configured, or if we are using SASL (unless `disableTLS` is set).
+func (k *Kafka) TLSConfig() (*tls.Config, error) {
+	if k.TLS == nil {
+		if k.Mechanism() == "" || k.DisableTLS {
+			return nil, nil
+		}
+
+		return &tls.Config{}, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         k.TLS.ServerName,
+		InsecureSkipVerify: k.TLS.InsecureSkipVerify,
+	}
+
+	if k.TLS.CAFile != "" {
+		caBundle, err := os.ReadFile(k.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file, err: %v", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("failed to parse ca file, no certificates were found")
+		}
+	}
+
+	if k.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(k.TLS.CertFile, k.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate, err: %v", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func (k *Kafka) validateAuth() error {
+	switch k.Mechanism() {
+	case "", SASLAWSMSKIAM:
+	case SASLPlain, SASLScramSHA256, SASLScramSHA512:
+		if k.Username == "" || k.Password == "" {
+			return fmt.Errorf("config is invalid, username and password are required for sasl mechanism: %s", k.Mechanism())
+		}
+	default:
+		return fmt.Errorf("config is invalid, sasl mechanism: %s is not supported", k.SASLMechanism)
+	}
+
+	if k.TLS != nil {
+		if k.DisableTLS {
+			return fmt.Errorf("config is invalid, tls cannot be configured when it's disabled")
+		}
+
+		if (k.TLS.CertFile == "") != (k.TLS.KeyFile == "") {
+			return fmt.Errorf("config is invalid, tls cert file and key file have to be set together")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKafka_Mechanism(t *testing.T) {
+	k := &Kafka{}
+	assert.Equal(t, SASLMechanism(""), k.Mechanism())
+
+	k.EnableAWSMSKIAM = true
+	assert.Equal(t, SASLAWSMSKIAM, k.Mechanism())
+
+	// Username takes precedence for backwards compatibility.
+	k.Username = "user"
+	assert.Equal(t, SASLPlain, k.Mechanism())
+
+	k.SASLMechanism = SASLScramSHA512
+	assert.Equal(t, SASLScramSHA512, k.Mechanism())
+}
+
+func TestKafka_ValidateAuth(t *testing.T) {
+	k := &Kafka{}
+	assert.NoError(t, k.validateAuth())
+
+	for _, mechanism := range []SASLMechanism{SASLPlain, SASLScramSHA256, SASLScramSHA512} {
+		k = &Kafka{SASLMechanism: mechanism, Username: "user"}
+		assert.ErrorContains(t, k.validateAuth(), "username and password are required", mechanism)
+
+		k.Password = "pass"
+		assert.NoError(t, k.validateAuth(), mechanism)
+	}
+
+	k = &Kafka{SASLMechanism: SASLAWSMSKIAM}
+	assert.NoError(t, k.validateAuth())
+
+	k = &Kafka{SASLMechanism: "gssapi"}
+	assert.ErrorContains(t, k.validateAuth(), "sasl mechanism: gssapi is not supported")
+
+	k = &Kafka{TLS: &KafkaTLS{CertFile: "client.pem"}}
+	assert.ErrorContains(t, k.validateAuth(), "cert file and key file have to be set together")
+
+	k.TLS.KeyFile = "client.key"
+	assert.NoError(t, k.validateAuth())
+
+	k.DisableTLS = true
+	assert.ErrorContains(t, k.validateAuth(), "tls cannot be configured when it's disabled")
+}
+
+// writeCertificate writes a self-signed certificate and its key as PEM files.
+func writeCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes}), 0600))
+	assert.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}), 0600))
+	return certPath, keyPath
+}
+
+func TestKafka_TLSConfig(t *testing.T) {
+	// No auth, no TLS.
+	k := &Kafka{}
+	tlsConfig, err := k.TLSConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+
+	// SASL will enable TLS by default.
+	k.Username = "user"
+	tlsConfig, err = k.TLSConfig()
+	assert.NoError(t, err)
+	assert.NotNil(t, tlsConfig)
+
+	k.DisableTLS = true
+	tlsConfig, err = k.TLSConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+
+	dir := t.TempDir()
+	certPath, keyPath := writeCertificate(t, dir)
+	k = &Kafka{
+		TLS: &KafkaTLS{
+			CAFile:             certPath,
+			CertFile:           certPath,
+			KeyFile:            keyPath,
+			ServerName:         "kafka.internal",
+			InsecureSkipVerify: true,
+		},
+	}
+
+	tlsConfig, err = k.TLSConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "kafka.internal", tlsConfig.ServerName)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+	assert.NotNil(t, tlsConfig.RootCAs)
+	assert.Len(t, tlsConfig.Certificates, 1)
+
+	k.TLS.KeyFile = certPath
+	_, err = k.TLSConfig()
+	assert.ErrorContains(t, err, "failed to load client certificate")
+
+	k.TLS.CAFile = keyPath
+	_, err = k.TLSConfig()
+	assert.ErrorContains(t, err, "no certificates were found")
+
+	k.TLS.CAFile = filepath.Join(dir, "missing.pem")
+	_, err = k.TLSConfig()
+	assert.ErrorContains(t, err, "failed to read ca file")
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/stringutil"
+)
+
+const defaultKinesisPollIntervalMs = 1000
+
+type Kinesis struct {
+	Region string `yaml:"region"`
+	// Endpoint is optional, this is used to point to a local emulator such as LocalStack or kinesalite.
+	Endpoint string `yaml:"endpoint"`
+	// AwsAccessKeyID and AwsSecretAccessKey are optional, if not set, we'll use the default credential chain.
+	AwsAccessKeyID     string `yaml:"awsAccessKeyID"`
+	AwsSecretAccessKey string `yaml:"awsSecretAccessKey"`
+	// CheckpointPath is the file that we'll store the last flushed sequence number of each shard in.
+	CheckpointPath string `yaml:"checkpointPath"`
+	// PollIntervalMs is how long we'll wait before polling a shard again when it has no new records.
+	PollIntervalMs int `yaml:"pollIntervalMs"`
+	// TopicConfigs - Topic is the name of the Kinesis stream.
+	TopicConfigs []*kafkalib.TopicConfig `yaml:"topicConfigs"`
+}
+
+func (k *Kinesis) String() string {
+	// Don't log credentials.
+	return fmt.Sprintf("region=%s, endpoint=%s, checkpointPath=%s, credentials_set=%v",
+		k.Region, k.Endpoint, k.CheckpointPath, k.AwsAccessKeyID != "")
+}
+
+func (k *Kinesis) validate() error {
+	if stringutil.Empty(k.Region, k.CheckpointPath) {
+		return fmt.Errorf("config is invalid, kinesis settings is invalid, kinesis: %s", k.String())
+	}
+
+	if (k.AwsAccessKeyID == "") != (k.AwsSecretAccessKey == "") {
+		return fmt.Errorf("config is invalid, kinesis access key id and secret access key have to be set together")
+	}
+
+	if k.PollIntervalMs <= 0 {
+		return fmt.Errorf("config is invalid, kinesis poll interval has to be a positive number, current value: %v", k.PollIntervalMs)
+	}
+
+	return nil
+}
@@ -1,30 +1,39 @@
 package debezium
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 
+	"github.com/artie-labs/transfer/lib/avro"
+	"github.com/artie-labs/transfer/lib/protobuf"
+	"github.com/artie-labs/transfer/lib/schemaregistry"
 	"github.com/artie-labs/transfer/lib/typing/columns"
 
 	"github.com/artie-labs/transfer/lib/config/constants"
 )
 
 const (
-	KeyFormatJSON   = "org.apache.kafka.connect.json.JsonConverter"
-	KeyFormatString = "org.apache.kafka.connect.storage.StringConverter"
+	KeyFormatJSON     = "org.apache.kafka.connect.json.JsonConverter"
+	KeyFormatString   = "org.apache.kafka.connect.storage.StringConverter"
+	KeyFormatAvro     = "io.confluent.connect.avro.AvroConverter"
+	KeyFormatProtobuf = "io.confluent.connect.protobuf.ProtobufConverter"
 
 	stringPrefix = "Struct{"
 	stringSuffix = "}"
 )
 
-func ParsePartitionKey(key []byte, cdcKeyFormat string) (map[string]interface{}, error) {
+func ParsePartitionKey(ctx context.Context, key []byte, cdcKeyFormat string) (map[string]interface{}, error) {
 	switch cdcKeyFormat {
 	case KeyFormatJSON:
 		return parsePartitionKeyStruct(key)
 	case KeyFormatString:
 		return parsePartitionKeyString(key)
-
+	case KeyFormatAvro:
+		return parsePartitionKeyAvro(ctx, key)
+	case KeyFormatProtobuf:
+		return parsePartitionKeyProtobuf(ctx, key)
 	}
 	return nil, fmt.Errorf("format: %s is not supported", cdcKeyFormat)
 }
@@ -96,6 +105,94 @@ func parsePartitionKeyStruct(keyBytes []byte) (map[string]interface{}, error) {
 	return sanitizePayload(pkStruct), nil
 }
 
+// parsePartitionKeyAvro is used to parse the partition key when it is serialized with the Confluent wire format.
+// The key schema will be fetched from the schema registry.
+func parsePartitionKeyAvro(ctx context.Context, keyBytes []byte) (map[string]interface{}, error) {
+	if len(keyBytes) == 0 {
+		return nil, fmt.Errorf("key is nil")
+	}
+
+	client := schemaregistry.FromContext(ctx)
+	if client == nil {
+		return nil, fmt.Errorf("schema registry is not configured")
+	}
+
+	schemaID, payload, err := schemaregistry.ParseWireFormat(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := client.GetSchema(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := avro.GetCodec(schema.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := codec.Decode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	pkStruct, isOk := decoded.(map[string]interface{})
+	if !isOk || len(pkStruct) == 0 {
+		return nil, fmt.Errorf("key object is malformated")
+	}
+
+	// Skip this key.
+	delete(pkStruct, constants.DebeziumTopicRoutingKey)
+	return sanitizePayload(pkStruct), nil
+}
+
+// parsePartitionKeyProtobuf is used to parse the partition key when it is serialized with the Confluent wire format.
+// The key's message descriptor will be resolved from the schema registry.
+func parsePartitionKeyProtobuf(ctx context.Context, keyBytes []byte) (map[string]interface{}, error) {
+	if len(keyBytes) == 0 {
+		return nil, fmt.Errorf("key is nil")
+	}
+
+	client := schemaregistry.FromContext(ctx)
+	if client == nil {
+		return nil, fmt.Errorf("schema registry is not configured")
+	}
+
+	schemaID, payload, err := schemaregistry.ParseWireFormat(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes, payload, err := protobuf.ParseMessageIndexes(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := protobuf.FileFromRegistry(ctx, client, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	messageDesc, err := protobuf.MessageByIndexes(file, indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	pkStruct, err := protobuf.Decode(messageDesc, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkStruct) == 0 {
+		return nil, fmt.Errorf("key object is malformated")
+	}
+
+	// Skip this key.
+	delete(pkStruct, constants.DebeziumTopicRoutingKey)
+	return sanitizePayload(pkStruct), nil
+}
+
 func sanitizePayload(retMap map[string]interface{}) map[string]interface{} {
 	escapedRetMap := make(map[string]interface{})
 	for key, value := range retMap {
@@ -1,9 +1,23 @@
 package debezium
 
 import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"testing"
 
+	"github.com/linkedin/goavro/v2"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/artie-labs/transfer/lib/schemaregistry"
 )
 
 func TestParsePartitionKeyString(t *testing.T) {
@@ -152,3 +166,82 @@ func TestParsePartitionKeyStruct(t *testing.T) {
 	assert.Equal(t, kv["id"], float64(1001))
 	assert.Equal(t, 1, len(kv))
 }
+
+func TestParsePartitionKeyAvro(t *testing.T) {
+	keySchema := `{"type": "record", "name": "Key", "namespace": "dbserver1.public.orders", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "Region", "type": "string"},
+		{"name": "__dbz__physicalTableIdentifier", "type": "string"}
+	]}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"schema": %s}`, strconv.Quote(keySchema))
+	}))
+	defer server.Close()
+
+	codec, err := goavro.NewCodec(keySchema)
+	assert.NoError(t, err)
+
+	keyBytes, err := codec.BinaryFromNative([]byte{0, 0, 0, 0, 1}, map[string]interface{}{
+		"id":                             int32(47),
+		"Region":                         "us-east-1",
+		"__dbz__physicalTableIdentifier": "dbserver1.public.orders",
+	})
+	assert.NoError(t, err)
+
+	_, err = ParsePartitionKey(context.Background(), keyBytes, KeyFormatAvro)
+	assert.ErrorContains(t, err, "schema registry is not configured")
+
+	ctx := schemaregistry.InjectClientIntoCtx(context.Background(), schemaregistry.NewClient(server.URL, "", ""))
+	kv, err := ParsePartitionKey(ctx, keyBytes, KeyFormatAvro)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": int32(47), "region": "us-east-1"}, kv)
+
+	_, err = ParsePartitionKey(ctx, nil, KeyFormatAvro)
+	assert.ErrorContains(t, err, "key is nil")
+
+	_, err = ParsePartitionKey(ctx, []byte("Struct{id=47}"), KeyFormatAvro)
+	assert.ErrorContains(t, err, "unknown magic byte")
+}
+
+func TestParsePartitionKeyProtobuf(t *testing.T) {
+	var fileProto descriptorpb.FileDescriptorProto
+	assert.NoError(t, protojson.Unmarshal([]byte(`{
+		"name": "key.proto",
+		"package": "dbserver1.public.orders",
+		"syntax": "proto3",
+		"messageType": [{"name": "Key", "field": [
+			{"name": "id", "number": 1, "label": "LABEL_OPTIONAL", "type": "TYPE_INT32"},
+			{"name": "Region", "number": 2, "label": "LABEL_OPTIONAL", "type": "TYPE_STRING"}
+		]}]
+	}`), &fileProto))
+
+	fileBytes, err := proto.Marshal(&fileProto)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"schemaType": "PROTOBUF", "schema": %s}`, strconv.Quote(base64.StdEncoding.EncodeToString(fileBytes)))
+	}))
+	defer server.Close()
+
+	file, err := protodesc.NewFile(&fileProto, nil)
+	assert.NoError(t, err)
+
+	msg := dynamicpb.NewMessage(file.Messages().ByName("Key"))
+	assert.NoError(t, protojson.Unmarshal([]byte(`{"id": 47, "Region": "us-east-1"}`), msg))
+
+	// Schema ID: 100, message indexes: [0]
+	keyBytes, err := proto.MarshalOptions{}.MarshalAppend([]byte{0, 0, 0, 0, 100, 0}, msg)
+	assert.NoError(t, err)
+
+	_, err = ParsePartitionKey(context.Background(), keyBytes, KeyFormatProtobuf)
+	assert.ErrorContains(t, err, "schema registry is not configured")
+
+	ctx := schemaregistry.InjectClientIntoCtx(context.Background(), schemaregistry.NewClient(server.URL, "", ""))
+	kv, err := ParsePartitionKey(ctx, keyBytes, KeyFormatProtobuf)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": int32(47), "region": "us-east-1"}, kv)
+
+	_, err = ParsePartitionKey(ctx, nil, KeyFormatProtobuf)
+	assert.ErrorContains(t, err, "key is nil")
+}
@@ -17,5 +17,7 @@ type DataWarehouse interface {
 
 type Baseline interface {
 	Label() constants.DestinationKind
+	// Merge is given its own copy of the table data, but the rows are shared with the other destinations that are being merged into
+	// at the same time, so they must be treated as read-only. Only the in-memory columns may be updated.
 	Merge(ctx context.Context, tableData *optimization.TableData) error
 }
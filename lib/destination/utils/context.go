@@ -3,11 +3,32 @@ package utils
 import (
 	"context"
 
+	"github.com/artie-labs/transfer/lib/config"
 	"github.com/artie-labs/transfer/lib/destination"
 	"github.com/artie-labs/transfer/lib/logger"
 )
 
-const destKey = "_dest"
+const (
+	destKey         = "_dest"
+	destinationsKey = "_destinations"
+)
+
+// Destination is one of the config's named destinations.
+// Settings are scoped to the destination (see config.Config.ForDestination), so they need to be injected when merging into it.
+type Destination struct {
+	Name     string
+	Settings *config.Settings
+	Baseline destination.Baseline
+}
+
+// Context returns ctx with the destination's settings, if it has any.
+func (d Destination) Context(ctx context.Context) context.Context {
+	if d.Settings == nil {
+		return ctx
+	}
+
+	return config.InjectSettingsIntoContext(ctx, d.Settings)
+}
 
 func InjectDwhIntoCtx(dwh destination.DataWarehouse, ctx context.Context) context.Context {
 	return context.WithValue(ctx, destKey, dwh)
@@ -36,3 +57,17 @@ func FromContext(ctx context.Context) destination.Baseline {
 
 	return dwh
 }
+
+func InjectDestinationsIntoCtx(destinations []Destination, ctx context.Context) context.Context {
+	return context.WithValue(ctx, destinationsKey, destinations)
+}
+
+// DestinationsFromContext returns the named destinations. If there aren't any, the destination from InjectDwhIntoCtx or
+// InjectBaselineIntoCtx is returned on its own without a name.
+func DestinationsFromContext(ctx context.Context) []Destination {
+	if destinations, isOk := ctx.Value(destinationsKey).([]Destination); isOk {
+		return destinations
+	}
+
+	return []Destination{{Baseline: FromContext(ctx)}}
+}
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/artie-labs/transfer/clients/snowflake"
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/config/constants"
 	"github.com/artie-labs/transfer/lib/db"
 	"github.com/artie-labs/transfer/lib/db/mock"
 	"github.com/artie-labs/transfer/lib/mocks"
@@ -29,3 +31,26 @@ func TestInjectDwhIntoCtx(t *testing.T) {
 	assert.NotNil(t, dwhCtx)
 	assert.Equal(t, dwhCtx, _dwh)
 }
+
+func TestDestinationsFromContext(t *testing.T) {
+	ctx := context.Background()
+	store := db.Store(&mock.DB{
+		Fake: mocks.FakeStore{},
+	})
+
+	_dwh := snowflake.LoadSnowflake(ctx, &store)
+
+	// Without named destinations, the injected destination is returned on its own.
+	destinations := DestinationsFromContext(InjectDwhIntoCtx(_dwh, ctx))
+	assert.Len(t, destinations, 1)
+	assert.Empty(t, destinations[0].Name)
+	assert.Equal(t, _dwh, destinations[0].Baseline)
+	assert.Equal(t, ctx, destinations[0].Context(ctx))
+
+	settings := &config.Settings{Config: &config.Config{Output: constants.Snowflake}}
+	ctx = InjectDestinationsIntoCtx([]Destination{{Name: "analytics", Settings: settings, Baseline: _dwh}}, ctx)
+	destinations = DestinationsFromContext(ctx)
+	assert.Len(t, destinations, 1)
+	assert.Equal(t, "analytics", destinations[0].Name)
+	assert.Equal(t, settings, config.FromContext(destinations[0].Context(ctx)))
+}
@@ -75,3 +75,32 @@ func DataWarehouse(ctx context.Context, store *db.Store) destination.DataWarehou
 
 	return nil
 }
+
+// LoadDestinations loads each of the config's named destinations with its own settings, as if it was the only output.
+func LoadDestinations(ctx context.Context) []Destination {
+	settings := config.FromContext(ctx)
+	var destinations []Destination
+	for _, dest := range settings.Config.Destinations {
+		destSettings := &config.Settings{
+			Config:         settings.Config.ForDestination(dest),
+			VerboseLogging: settings.VerboseLogging,
+		}
+
+		destCtx := config.InjectSettingsIntoContext(ctx, destSettings)
+		var baseline destination.Baseline
+		if IsOutputBaseline(destCtx) {
+			baseline = Baseline(destCtx)
+		} else {
+			baseline = DataWarehouse(destCtx, nil)
+		}
+
+		logger.FromContext(ctx).WithField("destination", dest.String()).Info("destination is loaded")
+		destinations = append(destinations, Destination{
+			Name:     dest.Name,
+			Settings: destSettings,
+			Baseline: baseline,
+		})
+	}
+
+	return destinations
+}
@@ -0,0 +1,74 @@
+package dlq
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/config/constants"
+)
+
+const (
+	HeaderTopic     = constants.ArtiePrefix + "_dlq_topic"
+	HeaderPartition = constants.ArtiePrefix + "_dlq_partition"
+	HeaderOffset    = constants.ArtiePrefix + "_dlq_offset"
+	HeaderReason    = constants.ArtiePrefix + "_dlq_reason"
+)
+
+// EncodingBase64 means that the record's key and value are base64 encoded within JSON, which is how []byte is encoded.
+const EncodingBase64 = "base64"
+
+// Record is a message that Transfer was not able to process, along with where it came from and why it failed.
+// The original key and value are kept as is, so the record can be inspected and replayed.
+type Record struct {
+	Key       []byte            `json:"key"`
+	Value     []byte            `json:"value"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Topic     string            `json:"topic"`
+	Partition string            `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+	Reason    string            `json:"reason"`
+	// Encoding is how the key and value are encoded within JSON, so that the record can be replayed, see EncodingBase64.
+	Encoding string `json:"encoding"`
+}
+
+func NewRecord(msg artie.Message, reason error) Record {
+	record := Record{
+		Key:       msg.Key(),
+		Value:     msg.Value(),
+		Headers:   msg.Headers(),
+		Topic:     msg.Topic(),
+		Partition: msg.Partition(),
+		Offset:    msg.Offset(),
+		Timestamp: msg.PublishTime(),
+		Encoding:  EncodingBase64,
+	}
+
+	if reason != nil {
+		record.Reason = reason.Error()
+	}
+
+	return record
+}
+
+// sourceHeaders returns the original headers along with the source topic, partition, offset and the error reason.
+// This is used by the Kafka and Pub/Sub writers since they do not have a separate field to store the metadata.
+func (r Record) sourceHeaders() map[string]string {
+	headers := make(map[string]string)
+	for key, value := range r.Headers {
+		headers[key] = value
+	}
+
+	headers[HeaderTopic] = r.Topic
+	headers[HeaderPartition] = r.Partition
+	headers[HeaderOffset] = fmt.Sprint(r.Offset)
+	headers[HeaderReason] = r.Reason
+	return headers
+}
+
+type Writer interface {
+	Write(ctx context.Context, record Record) error
+	Close() error
+}
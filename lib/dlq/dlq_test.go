@@ -0,0 +1,126 @@
+package dlq
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gcp_pubsub "cloud.google.com/go/pubsub"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRecord(t *testing.T) {
+	kafkaMsg := &kafka.Message{
+		Topic:     "orders",
+		Partition: 3,
+		Offset:    55,
+		Key:       []byte("Struct{id=1}"),
+		Value:     []byte("not json"),
+		Headers:   []kafka.Header{{Key: "foo", Value: []byte("bar")}},
+	}
+
+	record := NewRecord(artie.NewMessage(kafkaMsg, nil, kafkaMsg.Topic), fmt.Errorf("cannot unmarshall event"))
+	assert.Equal(t, "orders", record.Topic)
+	assert.Equal(t, "3", record.Partition)
+	assert.Equal(t, int64(55), record.Offset)
+	assert.Equal(t, kafkaMsg.Key, record.Key)
+	assert.Equal(t, kafkaMsg.Value, record.Value)
+	assert.Equal(t, "cannot unmarshall event", record.Reason)
+	assert.Equal(t, EncodingBase64, record.Encoding)
+
+	headers := record.sourceHeaders()
+	assert.Equal(t, "bar", headers["foo"])
+	assert.Equal(t, "orders", headers[HeaderTopic])
+	assert.Equal(t, "3", headers[HeaderPartition])
+	assert.Equal(t, "55", headers[HeaderOffset])
+	assert.Equal(t, "cannot unmarshall event", headers[HeaderReason])
+	// Original headers should not be mutated.
+	assert.Equal(t, 1, len(record.Headers))
+}
+
+func TestFileWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dlq.jsonl")
+	writer, err := NewFileWriter(path)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, writer.Write(context.Background(), Record{
+			Key:    []byte(fmt.Sprintf("key-%d", i)),
+			Value:  []byte("value"),
+			Topic:  "orders",
+			Offset: int64(i),
+			Reason: "bad",
+		}))
+	}
+
+	assert.NoError(t, writer.Close())
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+
+	assert.Equal(t, 3, len(records))
+	for i, record := range records {
+		assert.Equal(t, fmt.Sprintf("key-%d", i), string(record.Key))
+		assert.Equal(t, "value", string(record.Value))
+		assert.Equal(t, int64(i), record.Offset)
+		assert.Equal(t, "bad", record.Reason)
+	}
+}
+
+// fakePublisher behaves like a topic with message ordering, where the ordering key is paused once a publish has failed.
+type fakePublisher struct {
+	failures  int
+	paused    map[string]bool
+	published []*gcp_pubsub.Message
+}
+
+func (f *fakePublisher) publish(_ context.Context, msg *gcp_pubsub.Message) error {
+	if f.paused[msg.OrderingKey] {
+		return fmt.Errorf("publishing for ordering key, %s, paused due to previous error", msg.OrderingKey)
+	}
+
+	if f.failures > 0 {
+		f.failures -= 1
+		f.paused[msg.OrderingKey] = true
+		return fmt.Errorf("deadline exceeded")
+	}
+
+	f.published = append(f.published, msg)
+	return nil
+}
+
+func (f *fakePublisher) ResumePublish(orderingKey string) {
+	delete(f.paused, orderingKey)
+}
+
+func (f *fakePublisher) Stop() {}
+
+func TestPubSubWriter(t *testing.T) {
+	topic := &fakePublisher{failures: 1, paused: map[string]bool{}}
+	writer := &PubSubWriter{topic: topic}
+	record := Record{Key: []byte("Struct{id=1}"), Value: []byte("not json"), Topic: "orders", Reason: "cannot unmarshall event"}
+
+	// The retry succeeds, since the ordering key is resumed after the first failure.
+	assert.ErrorContains(t, writer.Write(context.Background(), record), "deadline exceeded")
+	assert.NoError(t, writer.Write(context.Background(), record))
+	assert.Len(t, topic.published, 1)
+	assert.Equal(t, "Struct{id=1}", topic.published[0].OrderingKey)
+	assert.Equal(t, []byte("not json"), topic.published[0].Data)
+	assert.NoError(t, writer.Close())
+}
@@ -0,0 +1,44 @@
+package dlq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileWriter will append each record as a JSON line to a local file.
+type FileWriter struct {
+	file *os.File
+	sync.Mutex
+}
+
+func NewFileWriter(path string) (*FileWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead letter file: %s, err: %v", path, err)
+	}
+
+	return &FileWriter{
+		file: file,
+	}, nil
+}
+
+func (f *FileWriter) Write(_ context.Context, record Record) error {
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record, err: %v", err)
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	_, err = f.file.Write(append(bytes, '\n'))
+	return err
+}
+
+func (f *FileWriter) Close() error {
+	f.Lock()
+	defer f.Unlock()
+	return f.file.Close()
+}
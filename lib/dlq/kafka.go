@@ -0,0 +1,51 @@
+package dlq
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaWriter will produce the record back into a Kafka topic with the original key and value.
+// The source metadata and error reason are added as message headers.
+type KafkaWriter struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaWriter(brokers []string, topic string, dialer *kafka.Dialer) *KafkaWriter {
+	transport := &kafka.Transport{}
+	if dialer != nil {
+		transport.DialTimeout = dialer.Timeout
+		transport.SASL = dialer.SASLMechanism
+		transport.TLS = dialer.TLS
+	}
+
+	return &KafkaWriter{
+		writer: &kafka.Writer{
+			Addr:      kafka.TCP(brokers...),
+			Topic:     topic,
+			Balancer:  &kafka.Hash{},
+			Transport: transport,
+		},
+	}
+}
+
+func (k *KafkaWriter) Write(ctx context.Context, record Record) error {
+	var headers []kafka.Header
+	for key, value := range record.sourceHeaders() {
+		headers = append(headers, kafka.Header{
+			Key:   key,
+			Value: []byte(value),
+		})
+	}
+
+	return k.writer.WriteMessages(ctx, kafka.Message{
+		Key:     record.Key,
+		Value:   record.Value,
+		Headers: headers,
+	})
+}
+
+func (k *KafkaWriter) Close() error {
+	return k.writer.Close()
+}
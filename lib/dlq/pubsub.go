@@ -0,0 +1,56 @@
+package dlq
+
+import (
+	"context"
+
+	gcp_pubsub "cloud.google.com/go/pubsub"
+)
+
+// publisher is the part of *gcp_pubsub.Topic that we use, so that it can be faked.
+type publisher interface {
+	publish(ctx context.Context, msg *gcp_pubsub.Message) error
+	ResumePublish(orderingKey string)
+	Stop()
+}
+
+type gcpTopic struct {
+	*gcp_pubsub.Topic
+}
+
+func (g gcpTopic) publish(ctx context.Context, msg *gcp_pubsub.Message) error {
+	_, err := g.Publish(ctx, msg).Get(ctx)
+	return err
+}
+
+// PubSubWriter will publish the record into a Pub/Sub topic with the original key as the ordering key.
+// The source metadata and error reason are added as message attributes.
+type PubSubWriter struct {
+	topic publisher
+}
+
+func NewPubSubWriter(client *gcp_pubsub.Client, topic string) *PubSubWriter {
+	topicClient := client.Topic(topic)
+	topicClient.EnableMessageOrdering = true
+	return &PubSubWriter{
+		topic: gcpTopic{Topic: topicClient},
+	}
+}
+
+func (p *PubSubWriter) Write(ctx context.Context, record Record) error {
+	err := p.topic.publish(ctx, &gcp_pubsub.Message{
+		Data:        record.Value,
+		OrderingKey: string(record.Key),
+		Attributes:  record.sourceHeaders(),
+	})
+	if err != nil {
+		// Publishing is paused for the ordering key once it fails, so the retry would fail as well unless it's resumed.
+		p.topic.ResumePublish(string(record.Key))
+	}
+
+	return err
+}
+
+func (p *PubSubWriter) Close() error {
+	p.topic.Stop()
+	return nil
+}
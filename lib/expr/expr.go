@@ -0,0 +1,538 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expression is a SQL-like expression over a row's columns, such as `tenant_id IN (1, 2) AND is_test = false`
+// or `concat(tenant_id, '-', id)`. Same as SQL, operating on NULL yields NULL rather than true or false.
+type Expression struct {
+	source string
+	root   node
+}
+
+func Parse(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, fmt.Errorf("expression is empty")
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s", tok)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval returns the expression's value for the row, nil is NULL.
+func (e *Expression) Eval(row map[string]interface{}) (interface{}, error) {
+	return e.root.eval(row)
+}
+
+// Matches returns true if the expression evaluates to true for the row, NULL is treated as false.
+func (e *Expression) Matches(row map[string]interface{}) (bool, error) {
+	val, err := e.Eval(row)
+	if err != nil {
+		return false, err
+	}
+
+	if val == nil {
+		return false, nil
+	}
+
+	boolVal, isOk := val.(bool)
+	if !isOk {
+		return false, fmt.Errorf("expression: %s did not evaluate to a boolean, got: %v", e.source, val)
+	}
+
+	return boolVal, nil
+}
+
+// Columns returns the names of the columns referenced by the expression.
+func (e *Expression) Columns() []string {
+	var cols []string
+	seen := make(map[string]bool)
+	e.root.walk(func(n node) {
+		if col, isOk := n.(columnNode); isOk && !seen[col.name] {
+			seen[col.name] = true
+			cols = append(cols, col.name)
+		}
+	})
+
+	return cols
+}
+
+type node interface {
+	eval(row map[string]interface{}) (interface{}, error)
+	walk(fn func(n node))
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (l literalNode) eval(_ map[string]interface{}) (interface{}, error) {
+	return l.value, nil
+}
+
+func (l literalNode) walk(fn func(n node)) {
+	fn(l)
+}
+
+type columnNode struct {
+	name string
+}
+
+func (c columnNode) eval(row map[string]interface{}) (interface{}, error) {
+	if val, isOk := row[c.name]; isOk {
+		return val, nil
+	}
+
+	// Column names are lower cased before being buffered, so fall back to a case-insensitive lookup.
+	for key, val := range row {
+		if strings.EqualFold(key, c.name) {
+			return val, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (c columnNode) walk(fn func(n node)) {
+	fn(c)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n notNode) eval(row map[string]interface{}) (interface{}, error) {
+	val, err := evalBool(n.operand, row)
+	if err != nil || val == nil {
+		return nil, err
+	}
+
+	return !*val, nil
+}
+
+func (n notNode) walk(fn func(n node)) {
+	fn(n)
+	n.operand.walk(fn)
+}
+
+type logicalNode struct {
+	// or is false for AND.
+	or          bool
+	left, right node
+}
+
+func (l logicalNode) eval(row map[string]interface{}) (interface{}, error) {
+	left, err := evalBool(l.left, row)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short circuit: false AND x is false, true OR x is true.
+	if left != nil && *left == l.or {
+		return l.or, nil
+	}
+
+	right, err := evalBool(l.right, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if right != nil && *right == l.or {
+		return l.or, nil
+	}
+
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	return !l.or, nil
+}
+
+func (l logicalNode) walk(fn func(n node)) {
+	fn(l)
+	l.left.walk(fn)
+	l.right.walk(fn)
+}
+
+type comparisonNode struct {
+	op          string
+	left, right node
+}
+
+func (c comparisonNode) eval(row map[string]interface{}) (interface{}, error) {
+	left, err := c.left.eval(row)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := c.right.eval(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	switch c.op {
+	case "=":
+		return equal(left, right), nil
+	case "!=", "<>":
+		return !equal(left, right), nil
+	}
+
+	cmp, err := compare(left, right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator: %s", c.op)
+}
+
+func (c comparisonNode) walk(fn func(n node)) {
+	fn(c)
+	c.left.walk(fn)
+	c.right.walk(fn)
+}
+
+type inNode struct {
+	operand node
+	list    []node
+	negate  bool
+}
+
+func (i inNode) eval(row map[string]interface{}) (interface{}, error) {
+	val, err := i.operand.eval(row)
+	if err != nil || val == nil {
+		return nil, err
+	}
+
+	var sawNull bool
+	for _, item := range i.list {
+		itemVal, err := item.eval(row)
+		if err != nil {
+			return nil, err
+		}
+
+		if itemVal == nil {
+			sawNull = true
+			continue
+		}
+
+		if equal(val, itemVal) {
+			return !i.negate, nil
+		}
+	}
+
+	// Same as SQL, x IN (1, NULL) is NULL rather than false when x is not 1.
+	if sawNull {
+		return nil, nil
+	}
+
+	return i.negate, nil
+}
+
+func (i inNode) walk(fn func(n node)) {
+	fn(i)
+	i.operand.walk(fn)
+	for _, item := range i.list {
+		item.walk(fn)
+	}
+}
+
+type arithmeticNode struct {
+	// op is one of +, -, *, / or || to concatenate strings.
+	op          string
+	left, right node
+}
+
+func (a arithmeticNode) eval(row map[string]interface{}) (interface{}, error) {
+	left, err := a.left.eval(row)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := a.right.eval(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	if a.op == "||" {
+		return toString(left) + toString(right), nil
+	}
+
+	leftFloat, rightFloat, isOk := numbers(left, right)
+	if !isOk {
+		return nil, fmt.Errorf("cannot apply %s to %v (%T) and %v (%T)", a.op, left, left, right, right)
+	}
+
+	switch a.op {
+	case "+":
+		return leftFloat + rightFloat, nil
+	case "-":
+		return leftFloat - rightFloat, nil
+	case "*":
+		return leftFloat * rightFloat, nil
+	case "/":
+		if rightFloat == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+
+		return leftFloat / rightFloat, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator: %s", a.op)
+}
+
+func (a arithmeticNode) walk(fn func(n node)) {
+	fn(a)
+	a.left.walk(fn)
+	a.right.walk(fn)
+}
+
+type functionNode struct {
+	name string
+	fn   function
+	args []node
+}
+
+func (f functionNode) eval(row map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(f.args))
+	for idx, arg := range f.args {
+		val, err := arg.eval(row)
+		if err != nil {
+			return nil, err
+		}
+
+		args[idx] = val
+	}
+
+	val, err := f.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", f.name, err)
+	}
+
+	return val, nil
+}
+
+func (f functionNode) walk(fn func(n node)) {
+	fn(f)
+	for _, arg := range f.args {
+		arg.walk(fn)
+	}
+}
+
+type isNullNode struct {
+	operand node
+	negate  bool
+}
+
+func (i isNullNode) eval(row map[string]interface{}) (interface{}, error) {
+	val, err := i.operand.eval(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return (val == nil) != i.negate, nil
+}
+
+func (i isNullNode) walk(fn func(n node)) {
+	fn(i)
+	i.operand.walk(fn)
+}
+
+func evalBool(n node, row map[string]interface{}) (*bool, error) {
+	val, err := n.eval(row)
+	if err != nil || val == nil {
+		return nil, err
+	}
+
+	boolVal, isOk := toBool(val)
+	if !isOk {
+		return nil, fmt.Errorf("expected a boolean, got: %v", val)
+	}
+
+	return &boolVal, nil
+}
+
+func toBool(val interface{}) (bool, bool) {
+	switch castedVal := val.(type) {
+	case bool:
+		return castedVal, true
+	case string:
+		boolVal, err := strconv.ParseBool(castedVal)
+		return boolVal, err == nil
+	}
+
+	return false, false
+}
+
+func toFloat(val interface{}) (float64, bool) {
+	switch castedVal := val.(type) {
+	case int:
+		return float64(castedVal), true
+	case int8:
+		return float64(castedVal), true
+	case int16:
+		return float64(castedVal), true
+	case int32:
+		return float64(castedVal), true
+	case int64:
+		return float64(castedVal), true
+	case uint:
+		return float64(castedVal), true
+	case uint8:
+		return float64(castedVal), true
+	case uint16:
+		return float64(castedVal), true
+	case uint32:
+		return float64(castedVal), true
+	case uint64:
+		return float64(castedVal), true
+	case float32:
+		return float64(castedVal), true
+	case float64:
+		return castedVal, true
+	case json.Number:
+		floatVal, err := castedVal.Float64()
+		return floatVal, err == nil
+	}
+
+	return 0, false
+}
+
+func toString(val interface{}) string {
+	switch castedVal := val.(type) {
+	case string:
+		return castedVal
+	case float32:
+		return strconv.FormatFloat(float64(castedVal), 'f', -1, 32)
+	case float64:
+		// Avoid the exponent, so 1000000 is not turned into 1e+06.
+		return strconv.FormatFloat(castedVal, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(val)
+}
+
+func isNumber(val interface{}) bool {
+	_, isOk := toFloat(val)
+	return isOk
+}
+
+// numbers returns both values as floats if either one of them is a number, and the other one is a number or a numeric string.
+func numbers(left, right interface{}) (float64, float64, bool) {
+	if !isNumber(left) && !isNumber(right) {
+		return 0, 0, false
+	}
+
+	leftFloat, isOk := toFloat(left)
+	if !isOk {
+		leftString, isString := left.(string)
+		if !isString {
+			return 0, 0, false
+		}
+
+		var err error
+		if leftFloat, err = strconv.ParseFloat(leftString, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	rightFloat, isOk := toFloat(right)
+	if !isOk {
+		rightString, isString := right.(string)
+		if !isString {
+			return 0, 0, false
+		}
+
+		var err error
+		if rightFloat, err = strconv.ParseFloat(rightString, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	return leftFloat, rightFloat, true
+}
+
+func equal(left, right interface{}) bool {
+	if leftFloat, rightFloat, isOk := numbers(left, right); isOk {
+		return leftFloat == rightFloat
+	}
+
+	if leftBool, isOk := left.(bool); isOk {
+		rightBool, isOk := toBool(right)
+		return isOk && leftBool == rightBool
+	}
+
+	if rightBool, isOk := right.(bool); isOk {
+		leftBool, isOk := toBool(left)
+		return isOk && leftBool == rightBool
+	}
+
+	leftString, isLeftString := left.(string)
+	rightString, isRightString := right.(string)
+	if isLeftString && isRightString {
+		return leftString == rightString
+	}
+
+	return fmt.Sprint(left) == fmt.Sprint(right)
+}
+
+func compare(left, right interface{}) (int, error) {
+	if leftFloat, rightFloat, isOk := numbers(left, right); isOk {
+		switch {
+		case leftFloat < rightFloat:
+			return -1, nil
+		case leftFloat > rightFloat:
+			return 1, nil
+		}
+
+		return 0, nil
+	}
+
+	leftString, isLeftString := left.(string)
+	rightString, isRightString := right.(string)
+	if isLeftString && isRightString {
+		return strings.Compare(leftString, rightString), nil
+	}
+
+	return 0, fmt.Errorf("cannot compare %v (%T) with %v (%T)", left, left, right, right)
+}
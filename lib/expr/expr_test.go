@@ -0,0 +1,110 @@
+package expr
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	invalidExprs := []string{
+		"",
+		"   ",
+		"tenant_id =",
+		"tenant_id IN 1, 2",
+		"tenant_id IN (1, 2",
+		"tenant_id IN ()",
+		"(tenant_id = 1",
+		"tenant_id = 1)",
+		"tenant_id NOT 1",
+		"is_test IS false",
+		"name = 'abc",
+		"a ! b",
+		"a = 1 AND",
+		"a = 1 b = 2",
+		"a = 1.2.3",
+		"a @ b",
+	}
+
+	for _, invalidExpr := range invalidExprs {
+		_, err := Parse(invalidExpr)
+		assert.Error(t, err, invalidExpr)
+	}
+}
+
+func TestExpression_Matches(t *testing.T) {
+	row := map[string]interface{}{
+		"tenant_id":  float64(1),
+		"is_test":    false,
+		"name":       "robin",
+		"quote":      "it's",
+		"deleted_at": nil,
+		"score":      json.Number("9.5"),
+		"count":      int64(3),
+		"id_string":  "42",
+	}
+
+	type _tc struct {
+		expr     string
+		expected bool
+	}
+
+	tcs := []_tc{
+		{expr: "tenant_id IN (1,2) AND is_test = false", expected: true},
+		{expr: "tenant_id in (2, 3)", expected: false},
+		{expr: "tenant_id NOT IN (2, 3)", expected: true},
+		{expr: "TENANT_ID = 1", expected: true},
+		{expr: `"tenant_id" = 1 and ` + "`name`" + ` = 'robin'`, expected: true},
+		{expr: "name != 'robin' OR count >= 3", expected: true},
+		{expr: "name <> 'robin'", expected: false},
+		{expr: "name < 'z' AND name > 'a'", expected: true},
+		{expr: "quote = 'it''s'", expected: true},
+		{expr: "deleted_at IS NULL", expected: true},
+		{expr: "deleted_at IS NOT NULL", expected: false},
+		{expr: "missing_col IS NULL", expected: true},
+		{expr: "score > 9 AND score <= 9.5", expected: true},
+		{expr: "count > -1", expected: true},
+		{expr: "id_string = 42", expected: true},
+		{expr: "NOT is_test", expected: true},
+		{expr: "NOT (tenant_id = 1 OR is_test)", expected: false},
+		{expr: "is_test = FALSE AND (tenant_id = 2 OR name = 'robin')", expected: true},
+		// Comparisons against NULL are NULL, which does not match.
+		{expr: "deleted_at = 1", expected: false},
+		{expr: "NOT (deleted_at = 1)", expected: false},
+		{expr: "deleted_at = 1 OR tenant_id = 1", expected: true},
+		{expr: "tenant_id IN (2, NULL)", expected: false},
+		{expr: "tenant_id NOT IN (2, NULL)", expected: false},
+	}
+
+	for _, tc := range tcs {
+		expression, err := Parse(tc.expr)
+		assert.NoError(t, err, tc.expr)
+
+		matches, err := expression.Matches(row)
+		assert.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.expected, matches, tc.expr)
+	}
+}
+
+func TestExpression_MatchesErrors(t *testing.T) {
+	row := map[string]interface{}{
+		"name":    "robin",
+		"is_test": true,
+	}
+
+	for _, invalidExpr := range []string{"name", "name > 1", "is_test > false", "name AND is_test"} {
+		expression, err := Parse(invalidExpr)
+		assert.NoError(t, err, invalidExpr)
+
+		_, err = expression.Matches(row)
+		assert.Error(t, err, invalidExpr)
+	}
+}
+
+func TestExpression_Columns(t *testing.T) {
+	expression, err := Parse("tenant_id IN (1, 2) AND (is_test = false OR tenant_id IS NULL) AND 'abc' = name")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tenant_id", "is_test", "name"}, expression.Columns())
+	assert.Equal(t, "tenant_id IN (1, 2) AND (is_test = false OR tenant_id IS NULL) AND 'abc' = name", expression.String())
+}
@@ -0,0 +1,166 @@
+package expr
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type function struct {
+	minArgs int
+	// maxArgs is -1 if the function is variadic.
+	maxArgs int
+	call    func(args []interface{}) (interface{}, error)
+}
+
+// functions are looked up by their lower cased name.
+var functions = map[string]function{
+	// coalesce returns the first argument that is not NULL.
+	"coalesce": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		for _, arg := range args {
+			if arg != nil {
+				return arg, nil
+			}
+		}
+
+		return nil, nil
+	}},
+	// concat concatenates every argument as a string, NULLs are skipped.
+	"concat": {minArgs: 1, maxArgs: -1, call: func(args []interface{}) (interface{}, error) {
+		var sb strings.Builder
+		for _, arg := range args {
+			if arg != nil {
+				sb.WriteString(toString(arg))
+			}
+		}
+
+		return sb.String(), nil
+	}},
+	"lower": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+
+		return strings.ToLower(toString(args[0])), nil
+	}},
+	"upper": {minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+
+		return strings.ToUpper(toString(args[0])), nil
+	}},
+	"date_trunc":   {minArgs: 2, maxArgs: 2, call: dateTrunc},
+	"json_extract": {minArgs: 2, maxArgs: 2, call: jsonExtract},
+}
+
+// timeLayouts are used to parse timestamps that have been passed in as strings.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func toTime(val interface{}) (time.Time, error) {
+	switch castedVal := val.(type) {
+	case time.Time:
+		return castedVal, nil
+	case interface{ UTC() time.Time }:
+		// Timestamps from the CDC event are *ext.ExtendedTime, which embeds time.Time.
+		return castedVal.UTC(), nil
+	case string:
+		for _, layout := range timeLayouts {
+			if ts, err := time.Parse(layout, castedVal); err == nil {
+				return ts, nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("cannot parse %v (%T) as a timestamp", val, val)
+}
+
+// dateTrunc truncates the timestamp in UTC, date_trunc('day', created_at). Day, week (starting on Monday), month and
+// year return a date, and the rest return a timestamp.
+func dateTrunc(args []interface{}) (interface{}, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+
+	ts, err := toTime(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	ts = ts.UTC()
+	switch unit := strings.ToLower(toString(args[0])); unit {
+	case "second":
+		return ts.Truncate(time.Second).Format(time.RFC3339), nil
+	case "minute":
+		return ts.Truncate(time.Minute).Format(time.RFC3339), nil
+	case "hour":
+		return ts.Truncate(time.Hour).Format(time.RFC3339), nil
+	case "day":
+		return ts.Format("2006-01-02"), nil
+	case "week":
+		daysSinceMonday := (int(ts.Weekday()) + 6) % 7
+		return ts.AddDate(0, 0, -daysSinceMonday).Format("2006-01-02"), nil
+	case "month":
+		return time.Date(ts.Year(), ts.Month(), 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02"), nil
+	case "year":
+		return time.Date(ts.Year(), time.January, 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02"), nil
+	default:
+		return nil, fmt.Errorf("unsupported unit: %s", unit)
+	}
+}
+
+// jsonExtract returns the value at the path, json_extract(payload, '$.address.zip') or json_extract(payload, 'items[0].sku').
+// The JSON can either be a string or an object that has already been decoded, NULL is returned if the path does not exist.
+func jsonExtract(args []interface{}) (interface{}, error) {
+	if args[0] == nil || args[1] == nil {
+		return nil, nil
+	}
+
+	current := args[0]
+	if jsonString, isOk := current.(string); isOk {
+		if err := json.Unmarshal([]byte(jsonString), &current); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal json, err: %v", err)
+		}
+	}
+
+	path := strings.TrimPrefix(toString(args[1]), "$")
+	for _, part := range strings.Split(strings.ReplaceAll(path, "[", ".["), ".") {
+		if part == "" {
+			continue
+		}
+
+		if strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") {
+			idx, err := strconv.Atoi(part[1 : len(part)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index: %s", part)
+			}
+
+			list, isOk := current.([]interface{})
+			if !isOk || idx < 0 || idx >= len(list) {
+				return nil, nil
+			}
+
+			current = list[idx]
+			continue
+		}
+
+		object, isOk := current.(map[string]interface{})
+		if !isOk {
+			return nil, nil
+		}
+
+		if current, isOk = object[part]; !isOk {
+			return nil, nil
+		}
+	}
+
+	return current, nil
+}
@@ -0,0 +1,91 @@
+package expr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpression_Eval(t *testing.T) {
+	createdAt := time.Date(2023, time.March, 16, 13, 45, 30, 0, time.UTC)
+	row := map[string]interface{}{
+		"id":         float64(1000000),
+		"tenant_id":  "acme",
+		"nickname":   nil,
+		"created_at": createdAt,
+		"updated_at": "2023-03-16T13:45:30.123Z",
+		"payload":    `{"address": {"zip": "10001"}, "items": [{"sku": "abc"}, {"sku": "def"}]}`,
+		"attributes": map[string]interface{}{"color": "red"},
+		"price":      float64(10),
+		"quantity":   int64(3),
+	}
+
+	type _tc struct {
+		expr     string
+		expected interface{}
+	}
+
+	tcs := []_tc{
+		{expr: "'us-east-1'", expected: "us-east-1"},
+		{expr: "concat(tenant_id, '-', id)", expected: "acme-1000000"},
+		{expr: "concat(tenant_id, nickname)", expected: "acme"},
+		{expr: "tenant_id || '-' || id", expected: "acme-1000000"},
+		{expr: "tenant_id || nickname", expected: nil},
+		{expr: "coalesce(nickname, upper(tenant_id))", expected: "ACME"},
+		{expr: "LOWER('ABC')", expected: "abc"},
+		{expr: "price * quantity - 1", expected: float64(29)},
+		{expr: "price * (quantity - 1)", expected: float64(20)},
+		{expr: "-price / 4", expected: float64(-2.5)},
+		{expr: "price + nickname", expected: nil},
+		{expr: "price * quantity > 20", expected: true},
+		{expr: "date_trunc('day', created_at)", expected: "2023-03-16"},
+		{expr: "date_trunc('week', created_at)", expected: "2023-03-13"},
+		{expr: "date_trunc('month', created_at)", expected: "2023-03-01"},
+		{expr: "date_trunc('year', created_at)", expected: "2023-01-01"},
+		{expr: "date_trunc('hour', created_at)", expected: "2023-03-16T13:00:00Z"},
+		{expr: "date_trunc('minute', updated_at)", expected: "2023-03-16T13:45:00Z"},
+		{expr: "date_trunc('day', nickname)", expected: nil},
+		{expr: "json_extract(payload, '$.address.zip')", expected: "10001"},
+		{expr: "json_extract(payload, 'items[1].sku')", expected: "def"},
+		{expr: "json_extract(payload, '$.items[5].sku')", expected: nil},
+		{expr: "json_extract(payload, '$.missing')", expected: nil},
+		{expr: "json_extract(attributes, 'color')", expected: "red"},
+	}
+
+	for _, tc := range tcs {
+		expression, err := Parse(tc.expr)
+		assert.NoError(t, err, tc.expr)
+
+		val, err := expression.Eval(row)
+		assert.NoError(t, err, tc.expr)
+		assert.Equal(t, tc.expected, val, tc.expr)
+	}
+}
+
+func TestExpression_EvalErrors(t *testing.T) {
+	row := map[string]interface{}{
+		"tenant_id": "acme",
+		"price":     float64(10),
+		"payload":   "not json",
+	}
+
+	for _, invalidExpr := range []string{
+		"tenant_id + 1",
+		"price / 0",
+		"date_trunc('fortnight', '2023-03-16')",
+		"date_trunc('day', tenant_id)",
+		"json_extract(payload, 'a')",
+	} {
+		expression, err := Parse(invalidExpr)
+		assert.NoError(t, err, invalidExpr)
+
+		_, err = expression.Eval(row)
+		assert.Error(t, err, invalidExpr)
+	}
+
+	for _, invalidExpr := range []string{"unknown(tenant_id)", "lower(tenant_id, price)", "date_trunc('day')", "concat()", "a | b"} {
+		_, err := Parse(invalidExpr)
+		assert.Error(t, err, invalidExpr)
+	}
+}
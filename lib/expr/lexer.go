@@ -0,0 +1,136 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenKeyword
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+var keywords = map[string]bool{
+	"AND":   true,
+	"OR":    true,
+	"NOT":   true,
+	"IN":    true,
+	"IS":    true,
+	"NULL":  true,
+	"TRUE":  true,
+	"FALSE": true,
+}
+
+type token struct {
+	kind tokenKind
+	// value is upper cased for keywords, and is unquoted for strings and quoted identifiers.
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+
+	return fmt.Sprintf("%q at position %d", t.value, t.pos)
+}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, value: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, value: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, value: ",", pos: i})
+			i++
+		case strings.ContainsRune("=<>!+-*/|", r):
+			start := i
+			i++
+			// Two character operators: !=, <>, <=, >=, ||
+			if i < len(runes) && ((r == '!' && runes[i] == '=') || (r == '<' && (runes[i] == '>' || runes[i] == '=')) || (r == '>' && runes[i] == '=') || (r == '|' && runes[i] == '|')) {
+				i++
+			}
+
+			op := string(runes[start:i])
+			if op == "!" || op == "|" {
+				return nil, fmt.Errorf("unexpected %q at position %d", op, start)
+			}
+
+			tokens = append(tokens, token{kind: tokenOperator, value: op, pos: start})
+		case r == '\'' || r == '"' || r == '`':
+			// Single quotes are strings, double quotes and backticks are identifiers. Quotes are escaped by doubling them up.
+			start := i
+			var sb strings.Builder
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == r {
+					if i+1 < len(runes) && runes[i+1] == r {
+						sb.WriteRune(r)
+						i++
+						continue
+					}
+
+					closed = true
+					i++
+					break
+				}
+
+				sb.WriteRune(runes[i])
+			}
+
+			if !closed {
+				return nil, fmt.Errorf("unterminated quote at position %d", start)
+			}
+
+			kind := tokenIdent
+			if r == '\'' {
+				kind = tokenString
+			}
+
+			tokens = append(tokens, token{kind: kind, value: sb.String(), pos: start})
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, value: string(runes[start:i]), pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+
+			word := string(runes[start:i])
+			if keywords[strings.ToUpper(word)] {
+				tokens = append(tokens, token{kind: tokenKeyword, value: strings.ToUpper(word), pos: start})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdent, value: word, pos: start})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", r, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
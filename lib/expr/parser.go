@@ -0,0 +1,334 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parser is a recursive descent parser, from the lowest to the highest precedence:
+//
+//	or         = and { OR and }
+//	and        = not { AND not }
+//	not        = NOT not | comparison
+//	comparison = additive [ ( "=" | "!=" | "<>" | "<" | "<=" | ">" | ">=" ) additive | [ NOT ] IN "(" additive { "," additive } ")" | IS [ NOT ] NULL ]
+//	additive   = term { ( "+" | "-" | "||" ) term }
+//	term       = unary { ( "*" | "/" ) unary }
+//	unary      = "-" unary | operand
+//	operand    = number | string | TRUE | FALSE | NULL | column | function "(" [ or { "," or } ] ")" | "(" or ")"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == tokenKeyword && tok.value == keyword
+}
+
+func (p *parser) expect(kind tokenKind, description string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, fmt.Errorf("expected %s, got %s", description, tok)
+	}
+
+	return tok, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = logicalNode{or: true, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = logicalNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isKeyword("NOT") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return notNode{operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind == tokenOperator {
+		switch tok.value {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+
+			return comparisonNode{op: tok.value, left: left, right: right}, nil
+		}
+	}
+
+	if p.isKeyword("IS") {
+		p.next()
+		negate := p.isKeyword("NOT")
+		if negate {
+			p.next()
+		}
+
+		if !p.isKeyword("NULL") {
+			return nil, fmt.Errorf("expected NULL, got %s", p.peek())
+		}
+
+		p.next()
+		return isNullNode{operand: left, negate: negate}, nil
+	}
+
+	negate := p.isKeyword("NOT")
+	if negate {
+		// NOT has to be followed by IN here, since `a NOT b` is not valid.
+		if next := p.tokens[p.pos+1]; next.kind != tokenKeyword || next.value != "IN" {
+			return nil, fmt.Errorf("expected IN, got %s", next)
+		}
+
+		p.next()
+	}
+
+	if p.isKeyword("IN") {
+		p.next()
+		if _, err = p.expect(tokenLeftParen, "("); err != nil {
+			return nil, err
+		}
+
+		var list []node
+		for {
+			item, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, item)
+			if p.peek().kind != tokenComma {
+				break
+			}
+
+			p.next()
+		}
+
+		if _, err = p.expect(tokenRightParen, ")"); err != nil {
+			return nil, err
+		}
+
+		return inNode{operand: left, list: list, negate: negate}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokenOperator {
+		return false
+	}
+
+	for _, op := range ops {
+		if tok.value == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator("+", "-", "||") {
+		op := p.next().value
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		left = arithmeticNode{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseTerm() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator("*", "/") {
+		op := p.next().value
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = arithmeticNode{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if !p.isOperator("-") {
+		return p.parseOperand()
+	}
+
+	p.next()
+	if p.peek().kind == tokenNumber {
+		return parseNumber(p.next(), true)
+	}
+
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	return arithmeticNode{op: "-", left: literalNode{value: float64(0)}, right: operand}, nil
+}
+
+func (p *parser) parseOperand() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		return parseNumber(tok, false)
+	case tokenString:
+		return literalNode{value: tok.value}, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLeftParen {
+			return p.parseFunction(tok)
+		}
+
+		return columnNode{name: tok.value}, nil
+	case tokenKeyword:
+		switch tok.value {
+		case "TRUE":
+			return literalNode{value: true}, nil
+		case "FALSE":
+			return literalNode{value: false}, nil
+		case "NULL":
+			return literalNode{value: nil}, nil
+		}
+	case tokenLeftParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = p.expect(tokenRightParen, ")"); err != nil {
+			return nil, err
+		}
+
+		return inner, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %s", tok)
+}
+
+func (p *parser) parseFunction(name token) (node, error) {
+	fn, isOk := functions[strings.ToLower(name.value)]
+	if !isOk {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+
+	// Skip the left parenthesis.
+	p.next()
+	var args []node
+	if p.peek().kind != tokenRightParen {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, arg)
+			if p.peek().kind != tokenComma {
+				break
+			}
+
+			p.next()
+		}
+	}
+
+	if _, err := p.expect(tokenRightParen, ")"); err != nil {
+		return nil, err
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments for %s, got: %d", name, len(args))
+	}
+
+	return functionNode{name: strings.ToLower(name.value), fn: fn, args: args}, nil
+}
+
+func parseNumber(tok token, negative bool) (node, error) {
+	value, err := strconv.ParseFloat(tok.value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %s", tok)
+	}
+
+	if negative {
+		value = -value
+	}
+
+	return literalNode{value: value}, nil
+}
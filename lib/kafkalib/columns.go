@@ -0,0 +1,59 @@
+package kafkalib
+
+import (
+	"path"
+	"strings"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+)
+
+func validColumnPatterns(patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return false
+		}
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesAnyColumnPattern(patterns []string, colName string) bool {
+	for _, pattern := range patterns {
+		// Patterns have already been validated, and columns are lower cased before being buffered.
+		if isOk, _ := path.Match(strings.ToLower(pattern), strings.ToLower(colName)); isOk {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IncludeColumn returns whether the column should be written to the destination, based on IncludeColumns and ExcludeColumns.
+// Every column is included if IncludeColumns is empty, and ExcludeColumns takes precedence over IncludeColumns.
+func (t *TopicConfig) IncludeColumn(colName string) bool {
+	if len(t.IncludeColumns) > 0 && !matchesAnyColumnPattern(t.IncludeColumns, colName) {
+		return false
+	}
+
+	return !matchesAnyColumnPattern(t.ExcludeColumns, colName)
+}
+
+// WriteColumn is IncludeColumn, except that primary keys and our own columns (e.g. the delete marker) cannot be excluded.
+// This should be used instead of IncludeColumn whenever the column may be a primary key.
+func (t *TopicConfig) WriteColumn(colName string, primaryKeys []string) bool {
+	if strings.HasPrefix(strings.ToLower(colName), constants.ArtiePrefix) {
+		return true
+	}
+
+	for _, primaryKey := range primaryKeys {
+		if strings.EqualFold(primaryKey, colName) {
+			return true
+		}
+	}
+
+	return t.IncludeColumn(colName)
+}
@@ -0,0 +1,67 @@
+package kafkalib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+)
+
+func TestTopicConfig_IncludeColumn(t *testing.T) {
+	tc := TopicConfig{}
+	assert.True(t, tc.IncludeColumn("anything"))
+
+	tc.ExcludeColumns = []string{"*_blob", "internal_?"}
+	assert.True(t, tc.IncludeColumn("name"))
+	assert.False(t, tc.IncludeColumn("avatar_blob"))
+	assert.False(t, tc.IncludeColumn("Avatar_BLOB"))
+	assert.False(t, tc.IncludeColumn("internal_1"))
+	assert.True(t, tc.IncludeColumn("internal_12"))
+
+	// Exclusions take precedence.
+	tc.IncludeColumns = []string{"id", "name", "avatar_*"}
+	assert.True(t, tc.IncludeColumn("id"))
+	assert.True(t, tc.IncludeColumn("avatar_url"))
+	assert.False(t, tc.IncludeColumn("avatar_blob"))
+	assert.False(t, tc.IncludeColumn("email"))
+}
+
+func TestTopicConfig_WriteColumn(t *testing.T) {
+	// The primary key matches the exclusion, and isn't in the inclusions.
+	tc := TopicConfig{
+		IncludeColumns: []string{"name"},
+		ExcludeColumns: []string{"*_id"},
+	}
+	assert.False(t, tc.IncludeColumn("user_id"))
+	assert.True(t, tc.WriteColumn("user_id", []string{"user_id"}))
+	assert.True(t, tc.WriteColumn("USER_ID", []string{"user_id"}))
+	assert.False(t, tc.WriteColumn("account_id", []string{"user_id"}))
+	assert.True(t, tc.WriteColumn("name", []string{"user_id"}))
+	assert.False(t, tc.WriteColumn("email", []string{"user_id"}))
+
+	// Our own columns are always written.
+	tc.ExcludeColumns = []string{"__*"}
+	assert.True(t, tc.WriteColumn(constants.DeleteColumnMarker, nil))
+	assert.True(t, tc.WriteColumn(constants.UpdateColumnMarker, nil))
+	assert.False(t, tc.WriteColumn("__debezium_source", nil))
+}
+
+func TestTopicConfig_ValidColumnPatterns(t *testing.T) {
+	tc := TopicConfig{
+		Database:       "db",
+		Schema:         "public",
+		Topic:          "topic",
+		CDCFormat:      constants.DBZPostgresFormat,
+		IncludeColumns: []string{"id", "[a-z]*"},
+		ExcludeColumns: []string{"*_blob"},
+	}
+	assert.True(t, tc.Valid())
+
+	tc.ExcludeColumns = []string{"[a-z"}
+	assert.False(t, tc.Valid())
+
+	tc.ExcludeColumns = nil
+	tc.IncludeColumns = []string{""}
+	assert.False(t, tc.Valid())
+}
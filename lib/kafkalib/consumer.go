@@ -7,6 +7,5 @@ import (
 
 type Consumer interface {
 	Close() (err error)
-	ReadMessage(ctx context.Context) (kafka.Message, error)
 	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
 }
@@ -0,0 +1,52 @@
+package kafkalib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/expr"
+)
+
+// DerivedColumn is a column that's computed from the source row, e.g. `date_trunc('day', created_at)` or `concat(tenant_id, '-', id)`.
+// The column's type is inferred from the computed value.
+type DerivedColumn struct {
+	Name       string `yaml:"name"`
+	Expression string `yaml:"expression"`
+
+	// compiled is set by Valid.
+	compiled *expr.Expression
+}
+
+func (d *DerivedColumn) String() string {
+	return fmt.Sprintf("name=%s, expression=%s", d.Name, d.Expression)
+}
+
+func (d *DerivedColumn) Valid() bool {
+	if d.Name == "" || strings.HasPrefix(strings.ToLower(d.Name), constants.ArtiePrefix) {
+		return false
+	}
+
+	compiled, err := expr.Parse(d.Expression)
+	if err != nil {
+		return false
+	}
+
+	d.compiled = compiled
+	return true
+}
+
+// Compile returns the expression that was compiled by Valid. Derived columns are shared across the partition workers, so this does not
+// cache anything. If Valid has not been called, it's parsed every time.
+func (d *DerivedColumn) Compile() (*expr.Expression, error) {
+	if d.compiled != nil {
+		return d.compiled, nil
+	}
+
+	compiled, err := expr.Parse(d.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse derived column: %s, err: %v", d.String(), err)
+	}
+
+	return compiled, nil
+}
@@ -0,0 +1,60 @@
+package kafkalib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerivedColumn_Valid(t *testing.T) {
+	type _tc struct {
+		derivedColumn DerivedColumn
+		expected      bool
+	}
+
+	tcs := []_tc{
+		{derivedColumn: DerivedColumn{Name: "created_date", Expression: "date_trunc('day', created_at)"}, expected: true},
+		{derivedColumn: DerivedColumn{Name: "source_region", Expression: "'us-east-1'"}, expected: true},
+		{derivedColumn: DerivedColumn{Expression: "'us-east-1'"}},
+		{derivedColumn: DerivedColumn{Name: "source_region"}},
+		{derivedColumn: DerivedColumn{Name: "__artie_region", Expression: "'us-east-1'"}},
+		{derivedColumn: DerivedColumn{Name: "created_date", Expression: "date_trunc('day'"}},
+		{derivedColumn: DerivedColumn{Name: "created_date", Expression: "bucket(created_at)"}},
+	}
+
+	for _, tc := range tcs {
+		assert.Equal(t, tc.expected, tc.derivedColumn.Valid(), tc.derivedColumn.String())
+	}
+}
+
+func TestTopicConfig_DerivedColumns(t *testing.T) {
+	tc := TopicConfig{
+		Database:  "12",
+		Schema:    "56",
+		Topic:     "78",
+		CDCFormat: "aa",
+		DerivedColumns: []DerivedColumn{
+			{Name: "created_date", Expression: "date_trunc('day', created_at)"},
+			{Name: "Created_Date", Expression: "'duplicate'"},
+		},
+	}
+
+	assert.False(t, tc.Valid(), tc.String())
+
+	tc.DerivedColumns = tc.DerivedColumns[:1]
+	assert.True(t, tc.Valid(), tc.String())
+
+	// Valid will have compiled the expression.
+	assert.NotNil(t, tc.DerivedColumns[0].compiled)
+	compiled, err := tc.DerivedColumns[0].Compile()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"created_at"}, compiled.Columns())
+	assert.Same(t, tc.DerivedColumns[0].compiled, compiled)
+
+	// Derived columns are shared across workers, so Compile does not write to the derived column.
+	unvalidated := DerivedColumn{Name: "created_date", Expression: "date_trunc('day', created_at)"}
+	compiled, err = unvalidated.Compile()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"created_at"}, compiled.Columns())
+	assert.Nil(t, unvalidated.compiled)
+}
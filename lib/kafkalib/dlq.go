@@ -0,0 +1,34 @@
+package kafkalib
+
+import "github.com/artie-labs/transfer/lib/stringutil"
+
+type DeadLetterQueueKind string
+
+const (
+	DeadLetterQueueKafka  DeadLetterQueueKind = "kafka"
+	DeadLetterQueuePubSub DeadLetterQueueKind = "pubsub"
+	DeadLetterQueueFile   DeadLetterQueueKind = "file"
+)
+
+// DeadLetterQueue - if this is set, messages that cannot be decoded or saved will be written here instead of being skipped.
+// Topic is required for Kafka and Pub/Sub, Path is required for file.
+type DeadLetterQueue struct {
+	Kind  DeadLetterQueueKind `yaml:"kind"`
+	Topic string              `yaml:"topic"`
+	Path  string              `yaml:"path"`
+}
+
+func (d *DeadLetterQueue) Valid() bool {
+	if d == nil {
+		return false
+	}
+
+	switch d.Kind {
+	case DeadLetterQueueKafka, DeadLetterQueuePubSub:
+		return !stringutil.Empty(d.Topic)
+	case DeadLetterQueueFile:
+		return !stringutil.Empty(d.Path)
+	}
+
+	return false
+}
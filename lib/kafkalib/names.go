@@ -0,0 +1,152 @@
+package kafkalib
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/artie-labs/transfer/lib/stringutil"
+)
+
+type NameCase string
+
+const (
+	// NameCasePreserve keeps the names as-is.
+	NameCasePreserve NameCase = ""
+	NameCaseUpper    NameCase = "upper"
+	NameCaseLower    NameCase = "lower"
+)
+
+const (
+	sourceDatabasePlaceholder = "{source_db}"
+	sourceSchemaPlaceholder   = "{source_schema}"
+	sourceTablePlaceholder    = "{source_table}"
+	topicPlaceholder          = "{topic}"
+)
+
+var (
+	placeholderRegex = regexp.MustCompile(`\{[^{}]*}`)
+	// patternEscaper escapes the parts of a template that are not placeholders, see PatternEscape.
+	patternEscaper = strings.NewReplacer(PatternEscape, PatternEscape+PatternEscape, "%", PatternEscape+"%", "_", PatternEscape+"_")
+)
+
+// SourceNames is where the event came from, and is used to expand the NameTemplates.
+type SourceNames struct {
+	Database string
+	Schema   string
+	Table    string
+	Topic    string
+}
+
+// NameTemplates control how the source is mapped to the destination's database, schema and table names.
+// Templates can use {source_db}, {source_schema}, {source_table} and {topic}, e.g. routing `prod_a.public.users` to
+// `RAW.PROD_A__PUBLIC.USERS` with database: RAW, schema: {source_db}__{source_schema} and case: upper.
+// If a template is empty, the topic config's value is used instead.
+type NameTemplates struct {
+	Database string `yaml:"database"`
+	Schema   string `yaml:"schema"`
+	// Table takes precedence over TableName.
+	Table       string `yaml:"table"`
+	TablePrefix string `yaml:"tablePrefix"`
+	TableSuffix string `yaml:"tableSuffix"`
+	// Case is applied after the templates have been expanded.
+	Case NameCase `yaml:"case"`
+}
+
+func validTemplate(template string) bool {
+	for _, placeholder := range placeholderRegex.FindAllString(template, -1) {
+		switch placeholder {
+		case sourceDatabasePlaceholder, sourceSchemaPlaceholder, sourceTablePlaceholder, topicPlaceholder:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func (n *NameTemplates) Valid() bool {
+	if n == nil {
+		return false
+	}
+
+	switch n.Case {
+	case NameCasePreserve, NameCaseUpper, NameCaseLower:
+	default:
+		return false
+	}
+
+	for _, template := range []string{n.Database, n.Schema, n.Table, n.TablePrefix, n.TableSuffix} {
+		if !validTemplate(template) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (n *NameTemplates) applyCase(name string) string {
+	switch n.Case {
+	case NameCaseUpper:
+		return strings.ToUpper(name)
+	case NameCaseLower:
+		return strings.ToLower(name)
+	}
+
+	return name
+}
+
+func (n *NameTemplates) expand(template string, source SourceNames) string {
+	return strings.NewReplacer(
+		sourceDatabasePlaceholder, source.Database,
+		sourceSchemaPlaceholder, source.Schema,
+		sourceTablePlaceholder, source.Table,
+		topicPlaceholder, source.Topic,
+	).Replace(template)
+}
+
+// ForDestination returns a copy of the topic config with the database, schema and table name resolved from NameTemplates.
+// The topic config is returned as-is if it does not have any name templates.
+func (t *TopicConfig) ForDestination(source SourceNames) *TopicConfig {
+	if t.NameTemplates == nil {
+		return t
+	}
+
+	templates := t.NameTemplates
+	tc := *t
+	tc.Database = templates.applyCase(stringutil.Override(t.Database, templates.expand(templates.Database, source)))
+	tc.Schema = templates.applyCase(stringutil.Override(t.Schema, templates.expand(templates.Schema, source)))
+
+	tableName := stringutil.Override(source.Table, t.TableName, templates.expand(templates.Table, source))
+	tc.TableName = templates.applyCase(templates.expand(templates.TablePrefix, source) + tableName + templates.expand(templates.TableSuffix, source))
+	return &tc
+}
+
+// pattern returns the template as a LIKE pattern, placeholders are replaced with `%` since they depend on the source and the rest is escaped.
+// Templates without placeholders are returned as-is, since they're not patterns.
+func (n *NameTemplates) pattern(template string, fallback string) string {
+	locations := placeholderRegex.FindAllStringIndex(template, -1)
+	if len(locations) == 0 {
+		return n.applyCase(stringutil.Override(fallback, template))
+	}
+
+	var pattern strings.Builder
+	var last int
+	for _, location := range locations {
+		pattern.WriteString(patternEscaper.Replace(template[last:location[0]]))
+		pattern.WriteString("%")
+		last = location[1]
+	}
+
+	pattern.WriteString(patternEscaper.Replace(template[last:]))
+	return n.applyCase(pattern.String())
+}
+
+// destinationPatterns returns the destination's database and schema, templated names are returned as LIKE patterns.
+// This way, the results can be used to find the tables that have been written to.
+func (t *TopicConfig) destinationPatterns() (string, string) {
+	if t.NameTemplates == nil {
+		return t.Database, t.Schema
+	}
+
+	return t.NameTemplates.pattern(t.NameTemplates.Database, t.Database), t.NameTemplates.pattern(t.NameTemplates.Schema, t.Schema)
+}
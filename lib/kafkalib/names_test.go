@@ -0,0 +1,142 @@
+package kafkalib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameTemplates_Valid(t *testing.T) {
+	var nilTemplates *NameTemplates
+	assert.False(t, nilTemplates.Valid())
+
+	assert.True(t, (&NameTemplates{}).Valid())
+	assert.True(t, (&NameTemplates{Database: "raw", Schema: "{source_db}__{source_schema}", Table: "{topic}_{source_table}", Case: NameCaseUpper}).Valid())
+	assert.False(t, (&NameTemplates{Schema: "{source_database}"}).Valid())
+	assert.False(t, (&NameTemplates{TablePrefix: "{}"}).Valid())
+	assert.False(t, (&NameTemplates{Case: "camel"}).Valid())
+
+	tc := TopicConfig{
+		Database:      "12",
+		Schema:        "56",
+		Topic:         "78",
+		CDCFormat:     "aa",
+		NameTemplates: &NameTemplates{Table: "{table}"},
+	}
+
+	assert.False(t, tc.Valid(), tc.String())
+	tc.NameTemplates.Table = "{source_table}"
+	assert.True(t, tc.Valid(), tc.String())
+}
+
+func TestTopicConfig_ForDestination(t *testing.T) {
+	source := SourceNames{Database: "prod_a", Schema: "public", Table: "users", Topic: "dbserver1.public.users"}
+	tc := &TopicConfig{
+		Database: "analytics",
+		Schema:   "public",
+	}
+
+	// No templates, so nothing changes.
+	assert.Equal(t, tc, tc.ForDestination(source))
+
+	type _tc struct {
+		name              string
+		tableName         string
+		templates         *NameTemplates
+		expectedDatabase  string
+		expectedSchema    string
+		expectedTableName string
+	}
+
+	tcs := []_tc{
+		{
+			name:              "upper cased",
+			templates:         &NameTemplates{Database: "raw", Schema: "{source_db}__{source_schema}", Case: NameCaseUpper},
+			expectedDatabase:  "RAW",
+			expectedSchema:    "PROD_A__PUBLIC",
+			expectedTableName: "USERS",
+		},
+		{
+			name:              "prefix and suffix",
+			templates:         &NameTemplates{TablePrefix: "{source_db}_", TableSuffix: "_v2"},
+			expectedDatabase:  "analytics",
+			expectedSchema:    "public",
+			expectedTableName: "prod_a_users_v2",
+		},
+		{
+			name:              "table name from the topic config",
+			tableName:         "customers",
+			templates:         &NameTemplates{Case: NameCaseLower, TablePrefix: "RAW_"},
+			expectedDatabase:  "analytics",
+			expectedSchema:    "public",
+			expectedTableName: "raw_customers",
+		},
+		{
+			name:              "table template takes precedence",
+			tableName:         "customers",
+			templates:         &NameTemplates{Table: "{topic}"},
+			expectedDatabase:  "analytics",
+			expectedSchema:    "public",
+			expectedTableName: "dbserver1.public.users",
+		},
+	}
+
+	for _, testCase := range tcs {
+		templated := &TopicConfig{
+			Database:      "analytics",
+			Schema:        "public",
+			TableName:     testCase.tableName,
+			NameTemplates: testCase.templates,
+		}
+
+		destination := templated.ForDestination(source)
+		assert.Equal(t, testCase.expectedDatabase, destination.Database, testCase.name)
+		assert.Equal(t, testCase.expectedSchema, destination.Schema, testCase.name)
+		assert.Equal(t, testCase.expectedTableName, destination.TableName, testCase.name)
+		// The original topic config is not modified.
+		assert.Equal(t, testCase.tableName, templated.TableName, testCase.name)
+	}
+
+	// Placeholders that the source does not have fall back to the topic config.
+	templated := &TopicConfig{Database: "analytics", Schema: "public", NameTemplates: &NameTemplates{Schema: "{source_schema}"}}
+	assert.Equal(t, "public", templated.ForDestination(SourceNames{Database: "inventory", Table: "customers"}).Schema)
+}
+
+func TestGetUniqueDatabaseAndSchema_NameTemplates(t *testing.T) {
+	pairs := GetUniqueDatabaseAndSchema([]*TopicConfig{
+		{
+			Database:      "db",
+			Schema:        "schema",
+			NameTemplates: &NameTemplates{Database: "raw", Schema: "{source_db}__{source_schema}", Case: NameCaseUpper},
+		},
+	})
+
+	// Underscores within the template are escaped, otherwise they would match any character.
+	assert.Equal(t, []DatabaseSchemaPair{{Database: "RAW", Schema: "%!_!_%"}}, pairs)
+	assert.False(t, pairs[0].DatabaseIsPattern())
+	assert.True(t, pairs[0].SchemaIsPattern())
+
+	pairs = GetUniqueDatabaseAndSchema([]*TopicConfig{
+		{
+			Database:      "db",
+			Schema:        "schema",
+			NameTemplates: &NameTemplates{Database: "raw_{source_db}!%"},
+		},
+	})
+
+	assert.Equal(t, []DatabaseSchemaPair{{Database: "raw!_%!!!%", Schema: "schema"}}, pairs)
+	assert.True(t, pairs[0].DatabaseIsPattern())
+	assert.False(t, pairs[0].SchemaIsPattern())
+}
+
+func TestDatabaseSchemaPair_MatchesDatabase(t *testing.T) {
+	pair := DatabaseSchemaPair{Database: "raw!_%!!!%"}
+	assert.True(t, pair.MatchesDatabase("raw_prod!%"))
+	assert.True(t, pair.MatchesDatabase("RAW_!%"))
+	assert.False(t, pair.MatchesDatabase("rawxprod!%"))
+	assert.False(t, pair.MatchesDatabase("raw_prod"))
+
+	pair = DatabaseSchemaPair{Database: "%.db"}
+	assert.True(t, pair.MatchesDatabase("prod.db"))
+	assert.False(t, pair.MatchesDatabase("prodxdb"))
+}
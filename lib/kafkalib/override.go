@@ -0,0 +1,84 @@
+package kafkalib
+
+import (
+	"fmt"
+
+	"github.com/artie-labs/transfer/lib/kafkalib/partition"
+	"github.com/artie-labs/transfer/lib/stringutil"
+)
+
+// TableOverride is used when a topic carries more than one table (e.g. Debezium's topic routing SMT).
+// SourceDatabase, SourceSchema and SourceTable are matched against the event's source metadata, empty values will match anything.
+// The remaining fields are optional, and if set, will override the topic config for the matching table.
+type TableOverride struct {
+	SourceDatabase string `yaml:"sourceDb"`
+	SourceSchema   string `yaml:"sourceSchema"`
+	SourceTable    string `yaml:"sourceTable"`
+
+	TableName                 string                      `yaml:"tableName"`
+	IdempotentKey             string                      `yaml:"idempotentKey"`
+	SoftDelete                *bool                       `yaml:"softDelete"`
+	SkipDelete                *bool                       `yaml:"skipDelete"`
+	BigQueryPartitionSettings *partition.BigQuerySettings `yaml:"bigQueryPartitionSettings"`
+}
+
+func (t *TableOverride) String() string {
+	return fmt.Sprintf("sourceDb=%s, sourceSchema=%s, sourceTable=%s", t.SourceDatabase, t.SourceSchema, t.SourceTable)
+}
+
+func (t *TableOverride) Valid() bool {
+	if t == nil {
+		return false
+	}
+
+	// An override that matches every table should be set on the topic config instead.
+	return !stringutil.Empty(t.SourceDatabase) || !stringutil.Empty(t.SourceSchema) || !stringutil.Empty(t.SourceTable)
+}
+
+func (t *TableOverride) Matches(database, schema, table string) bool {
+	if t.SourceDatabase != "" && t.SourceDatabase != database {
+		return false
+	}
+
+	if t.SourceSchema != "" && t.SourceSchema != schema {
+		return false
+	}
+
+	return t.SourceTable == "" || t.SourceTable == table
+}
+
+// ForSource returns the topic config for the source table. If one of the table overrides matches, a copy of the topic config
+// with the override applied is returned, otherwise the topic config is returned as-is. The first matching override wins.
+func (t *TopicConfig) ForSource(database, schema, table string) *TopicConfig {
+	for _, override := range t.TableOverrides {
+		if !override.Matches(database, schema, table) {
+			continue
+		}
+
+		tc := *t
+		tc.TableOverrides = nil
+		if override.TableName != "" {
+			tc.TableName = override.TableName
+		}
+
+		if override.IdempotentKey != "" {
+			tc.IdempotentKey = override.IdempotentKey
+		}
+
+		if override.SoftDelete != nil {
+			tc.SoftDelete = *override.SoftDelete
+		}
+
+		if override.SkipDelete != nil {
+			tc.SkipDelete = *override.SkipDelete
+		}
+
+		if override.BigQueryPartitionSettings != nil {
+			tc.BigQueryPartitionSettings = override.BigQueryPartitionSettings
+		}
+
+		return &tc
+	}
+
+	return t
+}
@@ -0,0 +1,85 @@
+package kafkalib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/kafkalib/partition"
+	"github.com/artie-labs/transfer/lib/ptr"
+)
+
+func TestTableOverride_Matches(t *testing.T) {
+	override := &TableOverride{SourceSchema: "public", SourceTable: "orders"}
+	assert.True(t, override.Matches("db", "public", "orders"))
+	assert.True(t, override.Matches("", "public", "orders"))
+	assert.False(t, override.Matches("db", "inventory", "orders"))
+	assert.False(t, override.Matches("db", "public", "customers"))
+
+	override = &TableOverride{SourceDatabase: "db"}
+	assert.True(t, override.Matches("db", "public", "orders"))
+	assert.False(t, override.Matches("db2", "public", "orders"))
+}
+
+func TestTopicConfig_ForSource(t *testing.T) {
+	tc := &TopicConfig{
+		Database:      "db",
+		Schema:        "public",
+		Topic:         "routed",
+		IdempotentKey: "updated_at",
+		CDCFormat:     constants.DBZPostgresFormat,
+		SoftDelete:    true,
+		TableOverrides: []*TableOverride{
+			{
+				SourceTable:   "orders",
+				TableName:     "orders_v2",
+				IdempotentKey: "id",
+				SoftDelete:    ptr.ToBool(false),
+				SkipDelete:    ptr.ToBool(true),
+				BigQueryPartitionSettings: &partition.BigQuerySettings{
+					PartitionType:  "time",
+					PartitionField: "created_at",
+					PartitionBy:    "daily",
+				},
+			},
+			{
+				// Shadowed by the first override.
+				SourceSchema: "public",
+				SourceTable:  "orders",
+				TableName:    "orders_v3",
+			},
+			{
+				SourceTable: "customers",
+				TableName:   "customers_v2",
+			},
+		},
+	}
+	assert.True(t, tc.Valid())
+
+	// No overrides match, so the topic config is returned as-is.
+	assert.Same(t, tc, tc.ForSource("db", "public", "products"))
+
+	orders := tc.ForSource("db", "public", "orders")
+	assert.Equal(t, "orders_v2", orders.TableName)
+	assert.Equal(t, "id", orders.IdempotentKey)
+	assert.False(t, orders.SoftDelete)
+	assert.True(t, orders.SkipDelete)
+	assert.Equal(t, "created_at", orders.BigQueryPartitionSettings.PartitionField)
+	assert.Nil(t, orders.TableOverrides)
+	assert.Equal(t, "routed", orders.Topic)
+
+	customers := tc.ForSource("db", "public", "customers")
+	assert.Equal(t, "customers_v2", customers.TableName)
+	assert.Equal(t, "updated_at", customers.IdempotentKey)
+	assert.True(t, customers.SoftDelete)
+	assert.False(t, customers.SkipDelete)
+	assert.Nil(t, customers.BigQueryPartitionSettings)
+
+	// The topic config itself should not be modified.
+	assert.Equal(t, "", tc.TableName)
+	assert.True(t, tc.SoftDelete)
+
+	tc.TableOverrides = append(tc.TableOverrides, &TableOverride{TableName: "catch_all"})
+	assert.False(t, tc.Valid(), "overrides need to match on at least one source field")
+}
@@ -0,0 +1,77 @@
+package kafkalib
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TopicPattern will subscribe to every topic that matches the regular expression.
+// Database, Schema and TableName are templates that may reference the pattern's capture groups, e.g.
+// pattern: `^dbserver\.(?P<schema>\w+)\.(?P<table>\w+)$` with schema: `${schema}` and tableName: `${table}`.
+// The rest of the topic config (cdcFormat, softDelete, etc.) is shared across every matched topic.
+type TopicPattern struct {
+	Pattern     string `yaml:"pattern"`
+	TopicConfig `yaml:",inline"`
+
+	regexp *regexp.Regexp
+}
+
+func (t *TopicPattern) String() string {
+	if t == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("pattern=%s, %s", t.Pattern, t.TopicConfig.String())
+}
+
+// Valid will compile the pattern and check the templated topic config, this needs to be called before Match.
+func (t *TopicPattern) Valid() bool {
+	if t == nil || t.Pattern == "" || t.Topic != "" {
+		return false
+	}
+
+	re, err := regexp.Compile(t.Pattern)
+	if err != nil {
+		return false
+	}
+
+	tc := t.TopicConfig
+	tc.Topic = t.Pattern
+	if !tc.Valid() {
+		return false
+	}
+
+	t.regexp = re
+	// Valid() sets the default key format and compiles the filter.
+	t.CDCKeyFormat = tc.CDCKeyFormat
+	t.filter = tc.filter
+	return true
+}
+
+// Match returns the topic config for the topic, with the templates expanded.
+func (t *TopicPattern) Match(topic string) (*TopicConfig, bool) {
+	if t.regexp == nil {
+		return nil, false
+	}
+
+	submatches := t.regexp.FindStringSubmatchIndex(topic)
+	if submatches == nil {
+		return nil, false
+	}
+
+	expand := func(template string) string {
+		if !strings.Contains(template, "$") {
+			return template
+		}
+
+		return string(t.regexp.ExpandString(nil, template, topic, submatches))
+	}
+
+	tc := t.TopicConfig
+	tc.Topic = topic
+	tc.Database = expand(tc.Database)
+	tc.Schema = expand(tc.Schema)
+	tc.TableName = expand(tc.TableName)
+	return &tc, true
+}
@@ -0,0 +1,91 @@
+package kafkalib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+)
+
+func TestTopicPattern_Valid(t *testing.T) {
+	var tp *TopicPattern
+	assert.False(t, tp.Valid())
+
+	tp = &TopicPattern{
+		Pattern: `^dbserver\.(\w+)\.(\w+)$`,
+		TopicConfig: TopicConfig{
+			Database:  "db",
+			Schema:    "$1",
+			TableName: "$2",
+			CDCFormat: constants.DBZPostgresFormat,
+		},
+	}
+	assert.True(t, tp.Valid())
+	assert.Equal(t, defaultKeyFormat, tp.CDCKeyFormat)
+
+	tp.Topic = "dbserver.public.orders"
+	assert.False(t, tp.Valid(), "topic cannot be set on a pattern")
+
+	tp.Topic = ""
+	tp.Pattern = `^dbserver\.(\w+`
+	assert.False(t, tp.Valid(), "pattern does not compile")
+
+	tp.Pattern = `^dbserver\.(\w+)\.(\w+)$`
+	tp.CDCFormat = ""
+	assert.False(t, tp.Valid(), "templated topic config is invalid")
+}
+
+func TestTopicPattern_Match(t *testing.T) {
+	tp := &TopicPattern{
+		Pattern: `^dbserver\.(?P<schema>\w+)\.(?P<table>\w+)$`,
+		TopicConfig: TopicConfig{
+			Database:   "db",
+			Schema:     "${schema}",
+			TableName:  "${table}_history",
+			CDCFormat:  constants.DBZPostgresFormat,
+			SoftDelete: true,
+		},
+	}
+
+	_, isOk := tp.Match("dbserver.public.orders")
+	assert.False(t, isOk, "pattern has not been compiled yet")
+
+	assert.True(t, tp.Valid())
+	tc, isOk := tp.Match("dbserver.public.orders")
+	assert.True(t, isOk)
+	assert.Equal(t, "dbserver.public.orders", tc.Topic)
+	assert.Equal(t, "db", tc.Database)
+	assert.Equal(t, "public", tc.Schema)
+	assert.Equal(t, "orders_history", tc.TableName)
+	assert.True(t, tc.SoftDelete)
+	assert.Equal(t, defaultKeyFormat, tc.CDCKeyFormat)
+	assert.True(t, tc.Valid())
+
+	// The pattern itself should not be modified.
+	assert.Equal(t, "${schema}", tp.Schema)
+	assert.Equal(t, "", tp.Topic)
+
+	_, isOk = tp.Match("otherserver.public.orders")
+	assert.False(t, isOk)
+}
+
+func TestTopicPattern_YAML(t *testing.T) {
+	var tps []*TopicPattern
+	err := yaml.Unmarshal([]byte(`
+- pattern: ^dbserver\.public\.(\w+)$
+  db: db
+  schema: public
+  tableName: $1
+  cdcFormat: debezium.postgres.wal2json
+  skipDelete: true
+`), &tps)
+	assert.NoError(t, err)
+	assert.Len(t, tps, 1)
+	assert.Equal(t, `^dbserver\.public\.(\w+)$`, tps[0].Pattern)
+	assert.Equal(t, "db", tps[0].Database)
+	assert.Equal(t, "$1", tps[0].TableName)
+	assert.True(t, tps[0].SkipDelete)
+	assert.True(t, tps[0].Valid())
+}
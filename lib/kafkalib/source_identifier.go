@@ -0,0 +1,61 @@
+package kafkalib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+)
+
+type SourceIdentifierKind string
+
+const (
+	SourceIdentifierTopic    SourceIdentifierKind = "topic"
+	SourceIdentifierDatabase SourceIdentifierKind = "database"
+	SourceIdentifierConstant SourceIdentifierKind = "constant"
+)
+
+// SourceIdentifier is used to fan-in multiple sources with the same schema (e.g. Postgres shards) into one destination table.
+// The column is added to every row and is part of the primary key, so rows with the same primary key from different sources don't collide.
+type SourceIdentifier struct {
+	Column string               `yaml:"column"`
+	Kind   SourceIdentifierKind `yaml:"kind"`
+	// Value is required if Kind is constant.
+	Value string `yaml:"value"`
+}
+
+func (s *SourceIdentifier) String() string {
+	return fmt.Sprintf("column=%s, kind=%s, value=%s", s.Column, s.Kind, s.Value)
+}
+
+func (s *SourceIdentifier) Valid() bool {
+	if s == nil || s.Column == "" || strings.HasPrefix(strings.ToLower(s.Column), constants.ArtiePrefix) {
+		return false
+	}
+
+	switch s.Kind {
+	case SourceIdentifierTopic, SourceIdentifierDatabase:
+		return true
+	case SourceIdentifierConstant:
+		return s.Value != ""
+	}
+
+	return false
+}
+
+// ColumnName returns the lower cased column name, since columns are lower cased before being buffered.
+func (s *SourceIdentifier) ColumnName() string {
+	return strings.ToLower(s.Column)
+}
+
+// Identify returns the value of the source identifier column for the source.
+func (s *SourceIdentifier) Identify(source SourceNames) string {
+	switch s.Kind {
+	case SourceIdentifierTopic:
+		return source.Topic
+	case SourceIdentifierDatabase:
+		return source.Database
+	}
+
+	return s.Value
+}
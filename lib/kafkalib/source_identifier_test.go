@@ -0,0 +1,37 @@
+package kafkalib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceIdentifier_Valid(t *testing.T) {
+	type _tc struct {
+		sourceIdentifier *SourceIdentifier
+		expected         bool
+	}
+
+	tcs := []_tc{
+		{sourceIdentifier: nil},
+		{sourceIdentifier: &SourceIdentifier{Kind: SourceIdentifierTopic}},
+		{sourceIdentifier: &SourceIdentifier{Column: "__artie_shard", Kind: SourceIdentifierTopic}},
+		{sourceIdentifier: &SourceIdentifier{Column: "shard", Kind: "partition"}},
+		{sourceIdentifier: &SourceIdentifier{Column: "shard", Kind: SourceIdentifierConstant}},
+		{sourceIdentifier: &SourceIdentifier{Column: "shard", Kind: SourceIdentifierTopic}, expected: true},
+		{sourceIdentifier: &SourceIdentifier{Column: "shard", Kind: SourceIdentifierDatabase}, expected: true},
+		{sourceIdentifier: &SourceIdentifier{Column: "shard", Kind: SourceIdentifierConstant, Value: "us-east-1"}, expected: true},
+	}
+
+	for idx, tc := range tcs {
+		assert.Equal(t, tc.expected, tc.sourceIdentifier.Valid(), idx)
+	}
+}
+
+func TestSourceIdentifier_Identify(t *testing.T) {
+	source := SourceNames{Database: "shard_07", Schema: "public", Table: "orders", Topic: "shard_07.public.orders"}
+	assert.Equal(t, "shard_07.public.orders", (&SourceIdentifier{Column: "shard", Kind: SourceIdentifierTopic}).Identify(source))
+	assert.Equal(t, "shard_07", (&SourceIdentifier{Column: "shard", Kind: SourceIdentifierDatabase}).Identify(source))
+	assert.Equal(t, "us-east-1", (&SourceIdentifier{Column: "shard", Kind: SourceIdentifierConstant, Value: "us-east-1"}).Identify(source))
+	assert.Equal(t, "shard", (&SourceIdentifier{Column: "Shard"}).ColumnName())
+}
@@ -2,25 +2,67 @@ package kafkalib
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/expr"
 	"github.com/artie-labs/transfer/lib/kafkalib/partition"
 
 	"github.com/artie-labs/transfer/lib/array"
 )
 
+// PatternEscape escapes `_` and `%` within the LIKE patterns, which is why the patterns need to be queried with `ESCAPE '!'`.
+const PatternEscape = "!"
+
+// DatabaseSchemaPair is the destination's database and schema. If the topic config has name templates, either of them may be a
+// LIKE pattern with `%` wildcards, since the names depend on the source.
 type DatabaseSchemaPair struct {
 	Database string
 	Schema   string
 }
 
+func (d DatabaseSchemaPair) DatabaseIsPattern() bool {
+	return strings.Contains(d.Database, "%")
+}
+
+func (d DatabaseSchemaPair) SchemaIsPattern() bool {
+	return strings.Contains(d.Schema, "%")
+}
+
+// MatchesDatabase returns whether the database matches, this is for destinations that cannot filter databases with LIKE.
+// Like ILIKE, the match is case-insensitive.
+func (d DatabaseSchemaPair) MatchesDatabase(database string) bool {
+	var expression strings.Builder
+	var escaped bool
+	for _, char := range d.Database {
+		switch {
+		case escaped:
+			expression.WriteString(regexp.QuoteMeta(string(char)))
+			escaped = false
+		case string(char) == PatternEscape:
+			escaped = true
+		case char == '%':
+			expression.WriteString(".*")
+		case char == '_':
+			expression.WriteString(".")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+
+	return regexp.MustCompile("(?is)^" + expression.String() + "$").MatchString(database)
+}
+
 // GetUniqueDatabaseAndSchema - does not guarantee ordering.
 func GetUniqueDatabaseAndSchema(tcs []*TopicConfig) []DatabaseSchemaPair {
 	dbMap := make(map[string]DatabaseSchemaPair)
 	for _, tc := range tcs {
-		key := fmt.Sprintf("%s###%s", tc.Database, tc.Schema)
+		database, schema := tc.destinationPatterns()
+		key := fmt.Sprintf("%s###%s", database, schema)
 		dbMap[key] = DatabaseSchemaPair{
-			Database: tc.Database,
-			Schema:   tc.Schema,
+			Database: database,
+			Schema:   schema,
 		}
 	}
 
@@ -45,14 +87,54 @@ type TopicConfig struct {
 	SkipDelete                bool                        `yaml:"skipDelete"`
 	IncludeArtieUpdatedAt     bool                        `yaml:"includeArtieUpdatedAt"`
 	BigQueryPartitionSettings *partition.BigQuerySettings `yaml:"bigQueryPartitionSettings"`
+	DeadLetterQueue           *DeadLetterQueue            `yaml:"deadLetterQueue"`
+	// ProtobufDescriptor is optional, if set we'll use it instead of fetching descriptors from the schema registry.
+	ProtobufDescriptor *ProtobufDescriptor `yaml:"protobufDescriptor"`
+	// TableOverrides are optional, see ForSource.
+	TableOverrides []*TableOverride `yaml:"tableOverrides"`
+	// ColumnTransforms are optional, a column can only have one transform and primary keys can only be hashed.
+	// Transforms that turn the column into a string cannot be added to an existing column of another type, see ColumnTransform.
+	ColumnTransforms []ColumnTransform `yaml:"columnTransforms"`
+	// IncludeColumns and ExcludeColumns are optional glob patterns (e.g. `*_blob`), see IncludeColumn.
+	// Primary keys are always included.
+	IncludeColumns []string `yaml:"includeColumns"`
+	ExcludeColumns []string `yaml:"excludeColumns"`
+	// Filter is an optional SQL-like expression over the row's columns, e.g. `tenant_id IN (1, 2) AND is_test = false`.
+	// Rows that don't match are skipped, and updates that no longer match are deleted from the destination.
+	Filter string `yaml:"filter"`
+	// DerivedColumns are optional, they're computed from the source row before any columns are excluded or transformed.
+	DerivedColumns []DerivedColumn `yaml:"derivedColumns"`
+	// NameTemplates are optional, see ForDestination.
+	NameTemplates *NameTemplates `yaml:"nameTemplates"`
+	// SourceIdentifier is optional, and is required if more than one topic config writes to the same table.
+	SourceIdentifier *SourceIdentifier `yaml:"sourceIdentifier"`
+	// Destinations are optional, and are the names of the config's destinations to write to. If empty, we'll write to all of them.
+	Destinations []string `yaml:"destinations"`
+
+	// filter is compiled from Filter by Valid.
+	filter *expr.Expression
+}
+
+type ProtobufDescriptor struct {
+	// Path to a FileDescriptorSet, which can be generated with `protoc --include_imports --descriptor_set_out`.
+	Path string `yaml:"path"`
+	// Message is the full name of the Debezium envelope message.
+	Message string `yaml:"message"`
 }
 
 const (
 	defaultKeyFormat = "org.apache.kafka.connect.storage.StringConverter"
 	jsonFormat       = "org.apache.kafka.connect.json.JsonConverter"
+	avroFormat       = "io.confluent.connect.avro.AvroConverter"
+	protobufFormat   = "io.confluent.connect.protobuf.ProtobufConverter"
 )
 
-var validKeyFormats = []string{defaultKeyFormat, jsonFormat}
+var (
+	validKeyFormats = []string{defaultKeyFormat, jsonFormat, avroFormat, protobufFormat}
+
+	schemaRegistryFormats = []string{constants.DBZPostgresAvroFormat, constants.DBZMySQLAvroFormat}
+	protobufFormats       = []string{constants.DBZPostgresProtobufFormat, constants.DBZMySQLProtobufFormat}
+)
 
 func (t *TopicConfig) String() string {
 	if t == nil {
@@ -79,9 +161,115 @@ func (t *TopicConfig) Valid() bool {
 		t.CDCKeyFormat = defaultKeyFormat
 	}
 
+	if t.DeadLetterQueue != nil && !t.DeadLetterQueue.Valid() {
+		return false
+	}
+
+	for _, override := range t.TableOverrides {
+		if !override.Valid() {
+			return false
+		}
+	}
+
+	if !validColumnPatterns(t.IncludeColumns) || !validColumnPatterns(t.ExcludeColumns) {
+		return false
+	}
+
+	seenColumns := make(map[string]bool)
+	for _, transform := range t.ColumnTransforms {
+		colName := strings.ToLower(transform.Column)
+		if !transform.Valid() || seenColumns[colName] {
+			return false
+		}
+
+		seenColumns[colName] = true
+	}
+
+	if t.Filter != "" {
+		filter, err := expr.Parse(t.Filter)
+		if err != nil {
+			return false
+		}
+
+		t.filter = filter
+	}
+
+	if t.NameTemplates != nil && !t.NameTemplates.Valid() {
+		return false
+	}
+
+	if t.SourceIdentifier != nil && !t.SourceIdentifier.Valid() {
+		return false
+	}
+
+	for _, destination := range t.Destinations {
+		if destination == "" {
+			return false
+		}
+	}
+
+	seenDerivedColumns := make(map[string]bool)
+	for idx := range t.DerivedColumns {
+		// Index into the slice, since Valid compiles the expression.
+		derivedColumn := &t.DerivedColumns[idx]
+		colName := strings.ToLower(derivedColumn.Name)
+		if !derivedColumn.Valid() || seenDerivedColumns[colName] {
+			return false
+		}
+
+		seenDerivedColumns[colName] = true
+	}
+
+	if t.ProtobufDescriptor != nil {
+		if !t.IsProtobuf() || array.Empty([]string{t.ProtobufDescriptor.Path, t.ProtobufDescriptor.Message}) {
+			return false
+		}
+	}
+
 	return array.StringContains(validKeyFormats, t.CDCKeyFormat)
 }
 
+// WritesTo returns whether the topic config writes to the named destination.
+func (t *TopicConfig) WritesTo(destination string) bool {
+	return len(t.Destinations) == 0 || array.StringContains(t.Destinations, destination)
+}
+
+// RowFilter returns the Filter that was compiled by Valid, or nil if there isn't one.
+// Topic configs are shared across the partition workers, so this does not cache anything. If Valid has not been called, it's parsed every time.
+func (t *TopicConfig) RowFilter() (*expr.Expression, error) {
+	if t.Filter == "" {
+		return nil, nil
+	}
+
+	if t.filter != nil {
+		return t.filter, nil
+	}
+
+	filter, err := expr.Parse(t.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse filter: %s, err: %v", t.Filter, err)
+	}
+
+	return filter, nil
+}
+
+// RequiresSchemaRegistry returns true if either the key or the value has to be decoded with a schema from the schema registry.
+func (t *TopicConfig) RequiresSchemaRegistry() bool {
+	if t.CDCKeyFormat == avroFormat || t.CDCKeyFormat == protobufFormat {
+		return true
+	}
+
+	if t.IsProtobuf() {
+		return t.ProtobufDescriptor == nil
+	}
+
+	return array.StringContains(schemaRegistryFormats, t.CDCFormat)
+}
+
+func (t *TopicConfig) IsProtobuf() bool {
+	return array.StringContains(protobufFormats, t.CDCFormat)
+}
+
 func (t *TopicConfig) ToCacheKey(partition int64) string {
 	return fmt.Sprintf("%s#%d", t.Topic, partition)
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/artie-labs/transfer/lib/config/constants"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -148,3 +149,135 @@ func TestTopicConfig_Validate(t *testing.T) {
 		assert.True(t, tc.Valid(), tc.String())
 	}
 }
+
+func TestTopicConfig_Validate_DeadLetterQueue(t *testing.T) {
+	tc := TopicConfig{
+		Database:  "12",
+		TableName: "34",
+		Schema:    "56",
+		Topic:     "78",
+		CDCFormat: "aa",
+		DeadLetterQueue: &DeadLetterQueue{
+			Kind: DeadLetterQueueKafka,
+		},
+	}
+
+	// Missing topic
+	assert.False(t, tc.Valid(), tc.String())
+
+	tc.DeadLetterQueue.Topic = "78_dlq"
+	assert.True(t, tc.Valid(), tc.String())
+
+	tc.DeadLetterQueue = &DeadLetterQueue{Kind: DeadLetterQueueFile}
+	assert.False(t, tc.Valid(), tc.String())
+
+	tc.DeadLetterQueue.Path = "/tmp/dlq.jsonl"
+	assert.True(t, tc.Valid(), tc.String())
+
+	tc.DeadLetterQueue = &DeadLetterQueue{Kind: "foo", Topic: "bar"}
+	assert.False(t, tc.Valid(), tc.String())
+}
+
+func TestTopicConfig_RequiresSchemaRegistry(t *testing.T) {
+	tc := TopicConfig{
+		CDCFormat:    constants.DBZPostgresFormat,
+		CDCKeyFormat: defaultKeyFormat,
+	}
+
+	assert.False(t, tc.RequiresSchemaRegistry())
+
+	tc.CDCKeyFormat = avroFormat
+	assert.True(t, tc.RequiresSchemaRegistry())
+
+	tc.CDCKeyFormat = jsonFormat
+	for _, format := range []string{constants.DBZPostgresAvroFormat, constants.DBZMySQLAvroFormat} {
+		tc.CDCFormat = format
+		assert.True(t, tc.RequiresSchemaRegistry(), format)
+	}
+}
+
+func TestTopicConfig_ProtobufDescriptor(t *testing.T) {
+	tc := TopicConfig{
+		Database:     "12",
+		Schema:       "56",
+		Topic:        "78",
+		CDCFormat:    constants.DBZPostgresProtobufFormat,
+		CDCKeyFormat: jsonFormat,
+	}
+
+	assert.True(t, tc.Valid(), tc.String())
+	assert.True(t, tc.IsProtobuf())
+	assert.True(t, tc.RequiresSchemaRegistry())
+
+	tc.ProtobufDescriptor = &ProtobufDescriptor{Path: "/tmp/descriptors.pb"}
+	assert.False(t, tc.Valid(), tc.String())
+
+	tc.ProtobufDescriptor.Message = "dbserver1.public.orders.Envelope"
+	assert.True(t, tc.Valid(), tc.String())
+	assert.False(t, tc.RequiresSchemaRegistry())
+
+	// The key is still fetched from the schema registry.
+	tc.CDCKeyFormat = protobufFormat
+	assert.True(t, tc.RequiresSchemaRegistry())
+
+	// A descriptor cannot be used for other formats.
+	tc.CDCFormat = constants.DBZPostgresFormat
+	assert.False(t, tc.Valid(), tc.String())
+	assert.False(t, tc.IsProtobuf())
+}
+
+func TestTopicConfig_Filter(t *testing.T) {
+	tc := TopicConfig{
+		Database:  "12",
+		Schema:    "56",
+		Topic:     "78",
+		CDCFormat: "aa",
+	}
+
+	filter, err := tc.RowFilter()
+	assert.NoError(t, err)
+	assert.Nil(t, filter)
+
+	tc.Filter = "tenant_id IN (1, 2"
+	assert.False(t, tc.Valid(), tc.String())
+
+	_, err = tc.RowFilter()
+	assert.Error(t, err)
+
+	tc.Filter = "tenant_id IN (1, 2) AND is_test = false"
+	assert.True(t, tc.Valid(), tc.String())
+
+	filter, err = tc.RowFilter()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tenant_id", "is_test"}, filter.Columns())
+	assert.Same(t, tc.filter, filter)
+
+	// Topic configs are shared across workers, so RowFilter does not write to the topic config.
+	unvalidated := TopicConfig{Filter: "is_test = false"}
+	filter, err = unvalidated.RowFilter()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"is_test"}, filter.Columns())
+	assert.Nil(t, unvalidated.filter)
+}
+
+func TestTopicConfig_WritesTo(t *testing.T) {
+	tc := TopicConfig{
+		Database:  "12",
+		Schema:    "56",
+		Topic:     "78",
+		CDCFormat: "aa",
+	}
+
+	assert.True(t, tc.Valid(), tc.String())
+	// No destinations means all of them.
+	assert.True(t, tc.WritesTo("snowflake"))
+	assert.True(t, tc.WritesTo("lake"))
+
+	tc.Destinations = []string{"lake", ""}
+	assert.False(t, tc.Valid(), tc.String())
+
+	tc.Destinations = []string{"lake"}
+	assert.True(t, tc.Valid(), tc.String())
+	assert.False(t, tc.WritesTo("snowflake"))
+	assert.True(t, tc.WritesTo("lake"))
+}
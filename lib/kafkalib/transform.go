@@ -0,0 +1,114 @@
+package kafkalib
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+)
+
+type TransformKind string
+
+const (
+	// TransformHash replaces the value with the hex encoded SHA-256 of the salt and the value.
+	TransformHash TransformKind = "hash"
+	// TransformMask replaces the value with a fixed mask.
+	TransformMask TransformKind = "mask"
+	// TransformNullify replaces the value with null.
+	TransformNullify TransformKind = "nullify"
+	// TransformTruncate only keeps the first `length` characters of the value.
+	TransformTruncate TransformKind = "truncate"
+)
+
+const DefaultMask = "****"
+
+// ColumnTransform is applied to a column's value before it's buffered, so the raw value never makes it into the destination.
+// Every transform other than nullify will turn the column into a string. As such, they can only be added to columns that do not exist
+// in the destination yet or are already strings, merges will fail otherwise (see optimization.TableData.ValidateColumnTransforms).
+type ColumnTransform struct {
+	Column string        `yaml:"column"`
+	Kind   TransformKind `yaml:"kind"`
+	// Salt is required to hash, so the hashed values cannot be looked up from a precomputed table.
+	Salt string `yaml:"salt"`
+	// Mask is optional and defaults to DefaultMask.
+	Mask string `yaml:"mask"`
+	// Length is required to truncate.
+	Length int `yaml:"length"`
+}
+
+func (c ColumnTransform) String() string {
+	// Don't log the salt.
+	return fmt.Sprintf("column=%s, kind=%s", c.Column, c.Kind)
+}
+
+func (c ColumnTransform) Valid() bool {
+	if c.Column == "" || strings.EqualFold(c.Column, constants.DeleteColumnMarker) {
+		return false
+	}
+
+	switch c.Kind {
+	case TransformHash:
+		return c.Salt != ""
+	case TransformMask, TransformNullify:
+		return true
+	case TransformTruncate:
+		return c.Length > 0
+	}
+
+	return false
+}
+
+// Matches returns true if the transform applies to colName, column names are case-insensitive since they're lower cased before being buffered.
+func (c ColumnTransform) Matches(colName string) bool {
+	return strings.EqualFold(c.Column, colName)
+}
+
+// ChangesType returns true if the column will be a string once it's been transformed.
+func (c ColumnTransform) ChangesType() bool {
+	return c.Kind != TransformNullify
+}
+
+// canonicalString formats the value the same way regardless of its Go type. Numbers decoded from JSON are float64s,
+// and fmt.Sprint(1000000.0) is "1e+06", whereas the same value cast to an int is "1000000".
+func canonicalString(val interface{}) string {
+	switch castedVal := val.(type) {
+	case float64:
+		return strconv.FormatFloat(castedVal, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(castedVal), 'f', -1, 32)
+	}
+
+	return fmt.Sprint(val)
+}
+
+// Apply returns the transformed value. Nulls and TOAST placeholders are returned as-is, since there's nothing to leak
+// and the placeholder has to be preserved for the destination to skip the column.
+func (c ColumnTransform) Apply(val interface{}) interface{} {
+	if val == nil || val == constants.ToastUnavailableValuePlaceholder {
+		return val
+	}
+
+	switch c.Kind {
+	case TransformHash:
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(c.Salt+canonicalString(val))))
+	case TransformMask:
+		if c.Mask == "" {
+			return DefaultMask
+		}
+
+		return c.Mask
+	case TransformNullify:
+		return nil
+	case TransformTruncate:
+		runes := []rune(canonicalString(val))
+		if len(runes) > c.Length {
+			runes = runes[:c.Length]
+		}
+
+		return string(runes)
+	}
+
+	return val
+}
@@ -0,0 +1,69 @@
+package kafkalib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+)
+
+func TestColumnTransform_Valid(t *testing.T) {
+	assert.False(t, ColumnTransform{Kind: TransformMask}.Valid())
+	assert.False(t, ColumnTransform{Column: constants.DeleteColumnMarker, Kind: TransformNullify}.Valid())
+	assert.False(t, ColumnTransform{Column: "email", Kind: "encrypt"}.Valid())
+	assert.False(t, ColumnTransform{Column: "email", Kind: TransformHash}.Valid())
+	assert.True(t, ColumnTransform{Column: "email", Kind: TransformHash, Salt: "pepper"}.Valid())
+	assert.True(t, ColumnTransform{Column: "email", Kind: TransformMask}.Valid())
+	assert.True(t, ColumnTransform{Column: "email", Kind: TransformNullify}.Valid())
+	assert.False(t, ColumnTransform{Column: "email", Kind: TransformTruncate}.Valid())
+	assert.True(t, ColumnTransform{Column: "email", Kind: TransformTruncate, Length: 3}.Valid())
+
+	assert.Equal(t, "column=email, kind=hash", ColumnTransform{Column: "email", Kind: TransformHash, Salt: "pepper"}.String())
+}
+
+func TestColumnTransform_Apply(t *testing.T) {
+	hash := ColumnTransform{Column: "email", Kind: TransformHash, Salt: "pepper"}
+	assert.Len(t, hash.Apply("robin@artie.so"), 64)
+	assert.Equal(t, hash.Apply("robin@artie.so"), hash.Apply("robin@artie.so"))
+	assert.NotEqual(t, hash.Apply("robin@artie.so"), ColumnTransform{Kind: TransformHash, Salt: "salt"}.Apply("robin@artie.so"))
+	assert.Equal(t, hash.Apply("123"), hash.Apply(123))
+	// Numbers are hashed the same way, whether they were decoded from JSON or cast.
+	assert.Equal(t, hash.Apply(1000000), hash.Apply(1000000.0))
+	assert.Equal(t, hash.Apply(1000000), hash.Apply(float32(1000000)))
+	assert.Equal(t, hash.Apply("1.5"), hash.Apply(1.5))
+
+	assert.Equal(t, DefaultMask, ColumnTransform{Kind: TransformMask}.Apply("123-45-6789"))
+	assert.Equal(t, "XXX", ColumnTransform{Kind: TransformMask, Mask: "XXX"}.Apply("123-45-6789"))
+	assert.Nil(t, ColumnTransform{Kind: TransformNullify}.Apply("secret"))
+	assert.Equal(t, "héllo", ColumnTransform{Kind: TransformTruncate, Length: 5}.Apply("héllo world"))
+	assert.Equal(t, "hi", ColumnTransform{Kind: TransformTruncate, Length: 5}.Apply("hi"))
+	assert.Equal(t, "10000", ColumnTransform{Kind: TransformTruncate, Length: 5}.Apply(1000000.0))
+
+	// Nulls and TOAST placeholders are left alone.
+	for _, transform := range []ColumnTransform{hash, {Kind: TransformMask}, {Kind: TransformTruncate, Length: 1}} {
+		assert.Nil(t, transform.Apply(nil))
+		assert.Equal(t, constants.ToastUnavailableValuePlaceholder, transform.Apply(constants.ToastUnavailableValuePlaceholder))
+	}
+}
+
+func TestTopicConfig_ValidColumnTransforms(t *testing.T) {
+	tc := TopicConfig{
+		Database:  "db",
+		Schema:    "public",
+		Topic:     "topic",
+		CDCFormat: constants.DBZPostgresFormat,
+		ColumnTransforms: []ColumnTransform{
+			{Column: "email", Kind: TransformHash, Salt: "pepper"},
+			{Column: "ssn", Kind: TransformMask},
+		},
+	}
+	assert.True(t, tc.Valid())
+
+	// A column can only be transformed once.
+	tc.ColumnTransforms = append(tc.ColumnTransforms, ColumnTransform{Column: "EMAIL", Kind: TransformNullify})
+	assert.False(t, tc.Valid())
+
+	tc.ColumnTransforms = []ColumnTransform{{Column: "email", Kind: TransformHash}}
+	assert.False(t, tc.Valid())
+}
@@ -17,6 +17,11 @@ func new(settings *config.Settings) *logrus.Logger {
 		log.SetLevel(logrus.DebugLevel)
 	}
 
+	// This has to be added before Sentry's hook, so that Sentry will only receive redacted entries.
+	if redactor := newRedactor(settings); redactor.enabled() {
+		log.Hooks.Add(&redactionHook{redactor: redactor})
+	}
+
 	if settings != nil && settings.Config != nil && settings.Config.Reporting.Sentry != nil && settings.Config.Reporting.Sentry.DSN != "" {
 		hook, err := logrus_sentry.NewSentryHook(settings.Config.Reporting.Sentry.DSN, []logrus.Level{
 			logrus.PanicLevel,
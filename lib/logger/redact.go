@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/config/constants"
+)
+
+var (
+	// valueFields are log fields that may carry message bodies or column values.
+	valueFields = []string{"key", "value", "defaultValue"}
+	// queryFields are log fields that carry DDL / DML, only the literals within them will be redacted.
+	queryFields = []string{"query"}
+
+	// stringLiteral matches a single-quoted SQL string, where quotes are escaped by doubling them up.
+	stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+)
+
+type redactor struct {
+	mode           constants.RedactionMode
+	truncateLength int
+	hashSalt       []byte
+}
+
+func newRedactor(settings *config.Settings) redactor {
+	if settings == nil || settings.Config == nil {
+		return redactor{mode: constants.RedactionOff}
+	}
+
+	return redactor{
+		mode:           settings.Config.Logging.Redaction,
+		truncateLength: settings.Config.Logging.TruncateLength,
+		hashSalt:       []byte(settings.Config.Logging.HashSalt),
+	}
+}
+
+func (r redactor) enabled() bool {
+	return r.mode == constants.RedactionHash || r.mode == constants.RedactionTruncate
+}
+
+func (r redactor) value(val string) string {
+	switch r.mode {
+	case constants.RedactionHash:
+		// The digest is keyed so that it cannot be reversed with a precomputed table, and 16 bytes is plenty to tell values apart.
+		mac := hmac.New(sha256.New, r.hashSalt)
+		mac.Write([]byte(val))
+		return fmt.Sprintf("hmac-sha256:%x", mac.Sum(nil)[:16])
+	case constants.RedactionTruncate:
+		runes := []rune(val)
+		if len(runes) <= r.truncateLength {
+			return val
+		}
+
+		return fmt.Sprintf("%s...(%d chars)", string(runes[:r.truncateLength]), len(runes))
+	}
+
+	return val
+}
+
+func (r redactor) query(query string) string {
+	if !r.enabled() {
+		return query
+	}
+
+	return stringLiteral.ReplaceAllStringFunc(query, func(literal string) string {
+		return fmt.Sprintf("'%s'", r.value(literal[1:len(literal)-1]))
+	})
+}
+
+// RedactValue will apply the logging policy to a message body or a column value, this is for values that are embedded into errors.
+func RedactValue(ctx context.Context, val interface{}) string {
+	return newRedactor(config.FromContext(ctx)).value(fmt.Sprint(val))
+}
+
+// RedactQuery will apply the logging policy to the string literals within a query.
+func RedactQuery(ctx context.Context, query string) string {
+	return newRedactor(config.FromContext(ctx)).query(query)
+}
+
+// redactionHook redacts the fields of every entry before it is written out or sent to Sentry.
+// Errors are kept, but the string literals within them are redacted so that they're still diagnosable.
+type redactionHook struct {
+	redactor redactor
+}
+
+func (r *redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (r *redactionHook) Fire(entry *logrus.Entry) error {
+	for _, field := range valueFields {
+		if val, isOk := entry.Data[field]; isOk {
+			entry.Data[field] = r.redactor.value(fmt.Sprint(val))
+		}
+	}
+
+	for _, field := range queryFields {
+		if val, isOk := entry.Data[field]; isOk {
+			entry.Data[field] = r.redactor.query(fmt.Sprint(val))
+		}
+	}
+
+	if err, isOk := entry.Data[logrus.ErrorKey].(error); isOk {
+		entry.Data[logrus.ErrorKey] = errors.New(r.redactor.query(err.Error()))
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/config/constants"
+)
+
+func TestRedactor(t *testing.T) {
+	query := `UPDATE "orders" SET "note" = 'it''s a secret' WHERE "note" IS NULL;`
+
+	off := redactor{mode: constants.RedactionOff}
+	assert.False(t, off.enabled())
+	assert.Equal(t, "robin@artie.so", off.value("robin@artie.so"))
+	assert.Equal(t, query, off.query(query))
+
+	hash := redactor{mode: constants.RedactionHash, hashSalt: []byte("salt")}
+	assert.True(t, hash.enabled())
+	assert.Equal(t, "hmac-sha256:d8bc793c7e34056f6890aafc07a0cfd7", hash.value("robin@artie.so"))
+	assert.NotEqual(t, hash.value("robin@artie.so"), redactor{mode: constants.RedactionHash, hashSalt: []byte("pepper")}.value("robin@artie.so"))
+	assert.Equal(t, hash.value("robin@artie.so"), hash.value("robin@artie.so"))
+	assert.NotEqual(t, hash.value("robin@artie.so"), hash.value("robin@artie.com"))
+	assert.Equal(t, fmt.Sprintf(`UPDATE "orders" SET "note" = '%s' WHERE "note" IS NULL;`, hash.value("it''s a secret")), hash.query(query))
+
+	truncate := redactor{mode: constants.RedactionTruncate, truncateLength: 4}
+	assert.Equal(t, "abc", truncate.value("abc"))
+	assert.Equal(t, "robi...(14 chars)", truncate.value("robin@artie.so"))
+	assert.Equal(t, `UPDATE "orders" SET "note" = 'it''...(14 chars)' WHERE "note" IS NULL;`, truncate.query(query))
+}
+
+func TestRedactionHook(t *testing.T) {
+	settings := &config.Settings{Config: &config.Config{Logging: config.Logging{Redaction: constants.RedactionTruncate, TruncateLength: 4}}}
+	log := new(settings)
+
+	entry := log.WithFields(map[string]interface{}{
+		"topic": "orders",
+		"key":   `{"id": 1}`,
+		"value": `{"email": "robin@artie.so"}`,
+		"query": "INSERT INTO orders VALUES ('robin@artie.so')",
+	}).WithError(fmt.Errorf("failed to merge, query: UPDATE orders SET email = 'robin@artie.so'"))
+
+	hook := &redactionHook{redactor: newRedactor(settings)}
+	assert.NoError(t, hook.Fire(entry))
+	assert.Equal(t, "orders", entry.Data["topic"])
+	assert.Equal(t, `{"id...(9 chars)`, entry.Data["key"])
+	assert.Equal(t, `{"em...(27 chars)`, entry.Data["value"])
+	assert.Equal(t, "INSERT INTO orders VALUES ('robi...(14 chars)')", entry.Data["query"])
+	assert.Equal(t, "failed to merge, query: UPDATE orders SET email = 'robi...(14 chars)'", entry.Data[logrus.ErrorKey].(error).Error())
+
+	// The hook is only installed when redaction is enabled.
+	assert.Len(t, log.Hooks[logrus.WarnLevel], 1)
+	assert.Len(t, new(&config.Settings{Config: &config.Config{}}).Hooks[logrus.WarnLevel], 0)
+}
+
+func TestRedactValue(t *testing.T) {
+	ctx := config.InjectSettingsIntoContext(context.Background(), &config.Settings{
+		Config: &config.Config{Logging: config.Logging{Redaction: constants.RedactionHash, HashSalt: "salt"}},
+	})
+
+	assert.Equal(t, redactor{mode: constants.RedactionHash, hashSalt: []byte("salt")}.value("123"), RedactValue(ctx, 123))
+	assert.Equal(t, "123", RedactValue(config.InjectSettingsIntoContext(context.Background(), &config.Settings{Config: &config.Config{}}), 123))
+	assert.Equal(t, "SELECT 1", RedactQuery(ctx, "SELECT 1"))
+}
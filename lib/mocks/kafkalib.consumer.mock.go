@@ -32,19 +32,6 @@ type FakeConsumer struct {
 	commitMessagesReturnsOnCall map[int]struct {
 		result1 error
 	}
-	ReadMessageStub        func(context.Context) (kafka.Message, error)
-	readMessageMutex       sync.RWMutex
-	readMessageArgsForCall []struct {
-		arg1 context.Context
-	}
-	readMessageReturns struct {
-		result1 kafka.Message
-		result2 error
-	}
-	readMessageReturnsOnCall map[int]struct {
-		result1 kafka.Message
-		result2 error
-	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -164,70 +151,6 @@ func (fake *FakeConsumer) CommitMessagesReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeConsumer) ReadMessage(arg1 context.Context) (kafka.Message, error) {
-	fake.readMessageMutex.Lock()
-	ret, specificReturn := fake.readMessageReturnsOnCall[len(fake.readMessageArgsForCall)]
-	fake.readMessageArgsForCall = append(fake.readMessageArgsForCall, struct {
-		arg1 context.Context
-	}{arg1})
-	stub := fake.ReadMessageStub
-	fakeReturns := fake.readMessageReturns
-	fake.recordInvocation("ReadMessage", []interface{}{arg1})
-	fake.readMessageMutex.Unlock()
-	if stub != nil {
-		return stub(arg1)
-	}
-	if specificReturn {
-		return ret.result1, ret.result2
-	}
-	return fakeReturns.result1, fakeReturns.result2
-}
-
-func (fake *FakeConsumer) ReadMessageCallCount() int {
-	fake.readMessageMutex.RLock()
-	defer fake.readMessageMutex.RUnlock()
-	return len(fake.readMessageArgsForCall)
-}
-
-func (fake *FakeConsumer) ReadMessageCalls(stub func(context.Context) (kafka.Message, error)) {
-	fake.readMessageMutex.Lock()
-	defer fake.readMessageMutex.Unlock()
-	fake.ReadMessageStub = stub
-}
-
-func (fake *FakeConsumer) ReadMessageArgsForCall(i int) context.Context {
-	fake.readMessageMutex.RLock()
-	defer fake.readMessageMutex.RUnlock()
-	argsForCall := fake.readMessageArgsForCall[i]
-	return argsForCall.arg1
-}
-
-func (fake *FakeConsumer) ReadMessageReturns(result1 kafka.Message, result2 error) {
-	fake.readMessageMutex.Lock()
-	defer fake.readMessageMutex.Unlock()
-	fake.ReadMessageStub = nil
-	fake.readMessageReturns = struct {
-		result1 kafka.Message
-		result2 error
-	}{result1, result2}
-}
-
-func (fake *FakeConsumer) ReadMessageReturnsOnCall(i int, result1 kafka.Message, result2 error) {
-	fake.readMessageMutex.Lock()
-	defer fake.readMessageMutex.Unlock()
-	fake.ReadMessageStub = nil
-	if fake.readMessageReturnsOnCall == nil {
-		fake.readMessageReturnsOnCall = make(map[int]struct {
-			result1 kafka.Message
-			result2 error
-		})
-	}
-	fake.readMessageReturnsOnCall[i] = struct {
-		result1 kafka.Message
-		result2 error
-	}{result1, result2}
-}
-
 func (fake *FakeConsumer) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -235,8 +158,6 @@ func (fake *FakeConsumer) Invocations() map[string][][]interface{} {
 	defer fake.closeMutex.RUnlock()
 	fake.commitMessagesMutex.RLock()
 	defer fake.commitMessagesMutex.RUnlock()
-	fake.readMessageMutex.RLock()
-	defer fake.readMessageMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -27,7 +27,7 @@ type TableData struct {
 	primaryKeys     []string
 
 	TopicConfig kafkalib.TopicConfig
-	// Partition to the latest offset(s).
+	// Topic and partition (see artie.ToPartitionKey) to the latest offset(s).
 	// For Kafka, we only need the last message to commit the offset
 	// However, pub/sub requires every single message to be acked
 	PartitionsToLastMessage map[string][]artie.Message
@@ -50,6 +50,42 @@ func (t *TableData) ContainOtherOperations() bool {
 	return t.containOtherOperations
 }
 
+// WithoutExcludedColumns will drop the columns that are excluded by the topic config. Excluded columns may still exist in the destination
+// (e.g. if they were created before being excluded), so this is used to make sure that they are not treated as deleted columns.
+func (t *TableData) WithoutExcludedColumns(ctx context.Context, cols []columns.Column) []columns.Column {
+	var includedCols []columns.Column
+	for _, col := range cols {
+		if t.TopicConfig.WriteColumn(col.Name(ctx, nil), t.primaryKeys) {
+			includedCols = append(includedCols, col)
+		}
+	}
+
+	return includedCols
+}
+
+// ValidateColumnTransforms returns an error if a transform that turns the column into a string applies to a column that already exists
+// in the destination with another type, since the transformed values could not be merged into it.
+func (t *TableData) ValidateColumnTransforms(ctx context.Context, destCols []columns.Column) error {
+	for _, transform := range t.TopicConfig.ColumnTransforms {
+		if !transform.ChangesType() {
+			continue
+		}
+
+		for _, col := range destCols {
+			if !transform.Matches(col.Name(ctx, nil)) {
+				continue
+			}
+
+			if col.KindDetails.Kind != typing.String.Kind && col.KindDetails.Kind != typing.Invalid.Kind {
+				return fmt.Errorf("column: %s already exists in the destination as %s, which cannot store the values from transform: %s",
+					col.Name(ctx, nil), col.KindDetails.Kind, transform.Kind)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (t *TableData) PrimaryKeys(ctx context.Context, args *sql.NameArgs) []columns.Wrapper {
 	var primaryKeysEscaped []columns.Wrapper
 	for _, pk := range t.primaryKeys {
@@ -85,6 +121,29 @@ func (t *TableData) ReadOnlyInMemoryCols() *columns.Columns {
 	return &cols
 }
 
+// Copy returns a copy of the table data that shares the rows, but has its own in-memory columns.
+// Merging will update the in-memory columns from the destination, so every destination needs its own copy.
+// The rows are not copied since merging only reads them, see destination.Baseline.
+func (t *TableData) Copy() *TableData {
+	tableData := *t
+	if t.inMemoryColumns != nil {
+		var cols columns.Columns
+		for _, col := range t.inMemoryColumns.GetColumns() {
+			if col.KindDetails.ExtendedTimeDetails != nil {
+				// UpdateInMemoryColumnsFromDestination will update this in place.
+				extendedTimeDetails := *col.KindDetails.ExtendedTimeDetails
+				col.KindDetails.ExtendedTimeDetails = &extendedTimeDetails
+			}
+
+			cols.AddColumn(col)
+		}
+
+		tableData.inMemoryColumns = &cols
+	}
+
+	return &tableData
+}
+
 func NewTableData(inMemoryColumns *columns.Columns, primaryKeys []string, topicConfig kafkalib.TopicConfig, name string) *TableData {
 	return &TableData{
 		inMemoryColumns: inMemoryColumns,
@@ -177,6 +236,15 @@ func (t *TableData) Rows() uint {
 	return uint(len(t.rowsData))
 }
 
+// ApproxSize returns the approximate size of the rows in bytes.
+func (t *TableData) ApproxSize() int {
+	if t == nil {
+		return 0
+	}
+
+	return t.approxSize
+}
+
 func (t *TableData) DistinctDates(ctx context.Context, colName string) ([]string, error) {
 	retMap := make(map[string]bool)
 	for _, row := range t.rowsData {
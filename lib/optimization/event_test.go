@@ -201,6 +201,87 @@ func (o *OptimizationTestSuite) TestTableData_ReadOnlyInMemoryCols() {
 	assert.Equal(o.T(), 1, len(td.ReadOnlyInMemoryCols().GetColumns()))
 }
 
+func (o *OptimizationTestSuite) TestTableData_Copy() {
+	var cols columns.Columns
+	cols.AddColumn(columns.NewColumn("name", typing.String))
+	cols.AddColumn(columns.NewColumn("created_at", typing.NewKindDetailsFromTemplate(typing.ETime, ext.DateTimeKindType)))
+
+	td := NewTableData(&cols, []string{"id"}, kafkalib.TopicConfig{}, "foo")
+	td.InsertRow("1", map[string]interface{}{"id": 1, "name": "robin"}, false)
+
+	// Updating the copy's columns from the destination should not leak into the original.
+	tdCopy := td.Copy()
+	tdCopy.UpdateInMemoryColumnsFromDestination(o.ctx,
+		columns.NewColumn("name", typing.Integer),
+		columns.NewColumn("created_at", typing.NewKindDetailsFromTemplate(typing.ETime, ext.DateKindType)),
+	)
+
+	col, isOk := tdCopy.ReadOnlyInMemoryCols().GetColumn("name")
+	assert.True(o.T(), isOk)
+	assert.Equal(o.T(), typing.Integer, col.KindDetails)
+
+	col, isOk = td.ReadOnlyInMemoryCols().GetColumn("name")
+	assert.True(o.T(), isOk)
+	assert.Equal(o.T(), typing.String, col.KindDetails)
+
+	col, isOk = td.ReadOnlyInMemoryCols().GetColumn("created_at")
+	assert.True(o.T(), isOk)
+	assert.Equal(o.T(), ext.DateTimeKindType, col.KindDetails.ExtendedTimeDetails.Type)
+
+	// The rows are shared.
+	assert.Equal(o.T(), td.RowsData(), tdCopy.RowsData())
+	assert.Equal(o.T(), td.Rows(), tdCopy.Rows())
+}
+
+func (o *OptimizationTestSuite) TestTableData_WithoutExcludedColumns() {
+	td := NewTableData(&columns.Columns{}, nil, kafkalib.TopicConfig{ExcludeColumns: []string{"*_blob"}}, "foo")
+	cols := []columns.Column{
+		columns.NewColumn("name", typing.String),
+		columns.NewColumn("avatar_blob", typing.String),
+	}
+
+	assert.Equal(o.T(), []columns.Column{columns.NewColumn("name", typing.String)}, td.WithoutExcludedColumns(o.ctx, cols))
+
+	// Primary keys and our own columns are kept even if they match, otherwise they would be treated as deleted columns.
+	td = NewTableData(&columns.Columns{}, []string{"user_id"}, kafkalib.TopicConfig{IncludeColumns: []string{"name"}, ExcludeColumns: []string{"*_id"}}, "foo")
+	cols = []columns.Column{
+		columns.NewColumn("user_id", typing.String),
+		columns.NewColumn("account_id", typing.String),
+		columns.NewColumn("name", typing.String),
+		columns.NewColumn(constants.DeleteColumnMarker, typing.Boolean),
+	}
+
+	assert.Equal(o.T(), []columns.Column{
+		columns.NewColumn("user_id", typing.String),
+		columns.NewColumn("name", typing.String),
+		columns.NewColumn(constants.DeleteColumnMarker, typing.Boolean),
+	}, td.WithoutExcludedColumns(o.ctx, cols))
+}
+
+func (o *OptimizationTestSuite) TestTableData_ValidateColumnTransforms() {
+	td := NewTableData(&columns.Columns{}, nil, kafkalib.TopicConfig{
+		ColumnTransforms: []kafkalib.ColumnTransform{
+			{Column: "email", Kind: kafkalib.TransformHash, Salt: "salt"},
+			{Column: "balance", Kind: kafkalib.TransformNullify},
+			{Column: "zip_code", Kind: kafkalib.TransformTruncate, Length: 3},
+		},
+	}, "foo")
+
+	// New columns and existing string columns are fine, as is nullifying a column of any type.
+	assert.NoError(o.T(), td.ValidateColumnTransforms(o.ctx, nil))
+	assert.NoError(o.T(), td.ValidateColumnTransforms(o.ctx, []columns.Column{
+		columns.NewColumn("email", typing.String),
+		columns.NewColumn("balance", typing.Float),
+		columns.NewColumn("name", typing.Integer),
+	}))
+
+	err := td.ValidateColumnTransforms(o.ctx, []columns.Column{
+		columns.NewColumn("email", typing.String),
+		columns.NewColumn("ZIP_CODE", typing.Integer),
+	})
+	assert.ErrorContains(o.T(), err, "column: ZIP_CODE already exists in the destination as int, which cannot store the values from transform: truncate")
+}
+
 func (o *OptimizationTestSuite) TestTableData_UpdateInMemoryColumns() {
 	var _cols columns.Columns
 	for colName, colKind := range map[string]typing.KindDetails{
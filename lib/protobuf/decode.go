@@ -0,0 +1,144 @@
+package protobuf
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	// Registers the well-known types, so that they can be imported by schemas.
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	Timestamp protoreflect.FullName = "google.protobuf.Timestamp"
+	Duration  protoreflect.FullName = "google.protobuf.Duration"
+	Struct    protoreflect.FullName = "google.protobuf.Struct"
+	Value     protoreflect.FullName = "google.protobuf.Value"
+	ListValue protoreflect.FullName = "google.protobuf.ListValue"
+)
+
+var wrappers = map[protoreflect.FullName]bool{
+	"google.protobuf.DoubleValue": true,
+	"google.protobuf.FloatValue":  true,
+	"google.protobuf.Int64Value":  true,
+	"google.protobuf.UInt64Value": true,
+	"google.protobuf.Int32Value":  true,
+	"google.protobuf.UInt32Value": true,
+	"google.protobuf.BoolValue":   true,
+	"google.protobuf.StringValue": true,
+	"google.protobuf.BytesValue":  true,
+}
+
+// Unwrap returns the underlying field of a wrapper (google.protobuf.Int64Value, etc.), otherwise it'll return itself.
+func Unwrap(field protoreflect.FieldDescriptor) protoreflect.FieldDescriptor {
+	if field.Message() != nil && wrappers[field.Message().FullName()] {
+		return field.Message().Fields().ByName("value")
+	}
+
+	return field
+}
+
+// Decode unmarshals the message into the same shape as Kafka Connect's JsonConverter would've emitted it.
+// Well-known types are converted: timestamps and durations into microseconds and wrappers into their value.
+func Decode(messageDesc protoreflect.MessageDescriptor, bytes []byte) (map[string]interface{}, error) {
+	msg := dynamicpb.NewMessage(messageDesc)
+	if err := proto.Unmarshal(bytes, msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal protobuf, message: %s, err: %v", messageDesc.FullName(), err)
+	}
+
+	return toMap(msg), nil
+}
+
+func toMap(msg protoreflect.Message) map[string]interface{} {
+	fields := msg.Descriptor().Fields()
+	values := make(map[string]interface{}, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.HasPresence() && !msg.Has(field) {
+			values[string(field.Name())] = nil
+			continue
+		}
+
+		values[string(field.Name())] = fieldValue(field, msg.Get(field))
+	}
+
+	return values
+}
+
+func fieldValue(field protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	if field.IsList() {
+		list := value.List()
+		values := make([]interface{}, list.Len())
+		for i := range values {
+			values[i] = singularValue(field, list.Get(i))
+		}
+
+		return values
+	}
+
+	if field.IsMap() {
+		values := make(map[string]interface{})
+		value.Map().Range(func(key protoreflect.MapKey, mapValue protoreflect.Value) bool {
+			values[key.String()] = singularValue(field.MapValue(), mapValue)
+			return true
+		})
+
+		return values
+	}
+
+	return singularValue(field, value)
+}
+
+func singularValue(field protoreflect.FieldDescriptor, value protoreflect.Value) interface{} {
+	switch field.Kind() {
+	case protoreflect.EnumKind:
+		if enumValue := field.Enum().Values().ByNumber(value.Enum()); enumValue != nil {
+			return string(enumValue.Name())
+		}
+
+		return int32(value.Enum())
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(value.Bytes())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageValue(value.Message())
+	}
+
+	return value.Interface()
+}
+
+func messageValue(msg protoreflect.Message) interface{} {
+	fullName := msg.Descriptor().FullName()
+	switch {
+	case fullName == Timestamp || fullName == Duration:
+		fields := msg.Descriptor().Fields()
+		seconds := msg.Get(fields.ByName("seconds")).Int()
+		nanos := msg.Get(fields.ByName("nanos")).Int()
+		return seconds*1_000_000 + nanos/1_000
+	case wrappers[fullName]:
+		valueField := msg.Descriptor().Fields().ByName("value")
+		return singularValue(valueField, msg.Get(valueField))
+	case fullName == Struct || fullName == Value || fullName == ListValue:
+		// These are JSON values, so we'll let protojson do the work.
+		bytes, err := protojson.Marshal(msg.Interface())
+		if err != nil {
+			return nil
+		}
+
+		var value interface{}
+		if err = json.Unmarshal(bytes, &value); err != nil {
+			return nil
+		}
+
+		return value
+	}
+
+	return toMap(msg)
+}
@@ -0,0 +1,213 @@
+package protobuf
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/artie-labs/transfer/lib/schemaregistry"
+)
+
+var (
+	registryFiles   = make(map[int]protoreflect.FileDescriptor)
+	registryFilesMu sync.RWMutex
+)
+
+// FileFromRegistry builds the file descriptor for a schema ID, including any of the schemas that it references.
+// Well-known types (google/protobuf/timestamp.proto, etc.) are not registered as references and are resolved locally.
+func FileFromRegistry(ctx context.Context, client *schemaregistry.Client, schemaID int) (protoreflect.FileDescriptor, error) {
+	registryFilesMu.RLock()
+	file, isOk := registryFiles[schemaID]
+	registryFilesMu.RUnlock()
+	if isOk {
+		return file, nil
+	}
+
+	schema, err := client.GetSerializedSchema(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if schema.SchemaType != schemaregistry.SchemaTypeProtobuf {
+		return nil, fmt.Errorf("schema id: %d is not a protobuf schema, type: %s", schemaID, schema.SchemaType)
+	}
+
+	files := new(protoregistry.Files)
+	if err = registerReferences(ctx, client, files, schema.References); err != nil {
+		return nil, err
+	}
+
+	file, err = buildFile(files, fmt.Sprintf("schema_%d.proto", schemaID), schema.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	registryFilesMu.Lock()
+	registryFiles[schemaID] = file
+	registryFilesMu.Unlock()
+	return file, nil
+}
+
+func registerReferences(ctx context.Context, client *schemaregistry.Client, files *protoregistry.Files, refs []schemaregistry.Reference) error {
+	for _, ref := range refs {
+		if _, err := (resolver{files: files}).FindFileByPath(ref.Name); err == nil {
+			continue
+		}
+
+		schema, err := client.GetSerializedReference(ctx, ref)
+		if err != nil {
+			return err
+		}
+
+		if err = registerReferences(ctx, client, files, schema.References); err != nil {
+			return err
+		}
+
+		file, err := buildFile(files, ref.Name, schema.Schema)
+		if err != nil {
+			return err
+		}
+
+		if err = files.RegisterFile(file); err != nil {
+			return fmt.Errorf("failed to register reference: %s, err: %v", ref.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// buildFile will build the file from a base64 encoded FileDescriptorProto.
+// The path is only used if the descriptor does not have a name, it must match how other files import it.
+func buildFile(files *protoregistry.Files, path string, encoded string) (protoreflect.FileDescriptor, error) {
+	bytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to base64 decode file descriptor, err: %v", err)
+	}
+
+	var fileProto descriptorpb.FileDescriptorProto
+	if err = proto.Unmarshal(bytes, &fileProto); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal file descriptor, err: %v", err)
+	}
+
+	if fileProto.GetName() == "" {
+		fileProto.Name = proto.String(path)
+	}
+
+	file, err := protodesc.NewFile(&fileProto, resolver{files: files})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build file descriptor: %s, err: %v", fileProto.GetName(), err)
+	}
+
+	return file, nil
+}
+
+// resolver looks up files that have been fetched from the schema registry, then falls back to the well-known types.
+type resolver struct {
+	files *protoregistry.Files
+}
+
+func (r resolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if file, err := r.files.FindFileByPath(path); err == nil {
+		return file, nil
+	}
+
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r resolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if desc, err := r.files.FindDescriptorByName(name); err == nil {
+		return desc, nil
+	}
+
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// MessageFromDescriptorSet loads a message from a FileDescriptorSet, which can be generated with `protoc --include_imports --descriptor_set_out`.
+func MessageFromDescriptorSet(path string, messageName string) (protoreflect.MessageDescriptor, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set, err: %v", err)
+	}
+
+	var descriptorSet descriptorpb.FileDescriptorSet
+	if err = proto.Unmarshal(bytes, &descriptorSet); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal descriptor set, err: %v", err)
+	}
+
+	files, err := protodesc.NewFiles(&descriptorSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build descriptor set, err: %v", err)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find message: %s, err: %v", messageName, err)
+	}
+
+	messageDesc, isOk := desc.(protoreflect.MessageDescriptor)
+	if !isOk {
+		return nil, fmt.Errorf("%s is not a message", messageName)
+	}
+
+	return messageDesc, nil
+}
+
+// ParseMessageIndexes parses the path to the message within the schema, this follows the schema ID in the wire format.
+// It is encoded as a zig-zag varint array, an array with just 0 (the first message) is encoded as a single 0.
+func ParseMessageIndexes(bytes []byte) ([]int, []byte, error) {
+	count, n := binary.Varint(bytes)
+	if n <= 0 {
+		return nil, nil, fmt.Errorf("failed to parse message indexes count")
+	}
+
+	bytes = bytes[n:]
+	if count == 0 {
+		return []int{0}, bytes, nil
+	}
+
+	if count < 0 || count > int64(len(bytes)) {
+		return nil, nil, fmt.Errorf("invalid message indexes count: %d", count)
+	}
+
+	indexes := make([]int, count)
+	for i := range indexes {
+		index, n := binary.Varint(bytes)
+		if n <= 0 {
+			return nil, nil, fmt.Errorf("failed to parse message index")
+		}
+
+		indexes[i] = int(index)
+		bytes = bytes[n:]
+	}
+
+	return indexes, bytes, nil
+}
+
+// MessageByIndexes follows the message indexes from the top-level messages of the file into the nested messages.
+func MessageByIndexes(file protoreflect.FileDescriptor, indexes []int) (protoreflect.MessageDescriptor, error) {
+	var messageDesc protoreflect.MessageDescriptor
+	messages := file.Messages()
+	for _, index := range indexes {
+		if index < 0 || index >= messages.Len() {
+			return nil, fmt.Errorf("message index: %d is out of range, file: %s", index, file.Path())
+		}
+
+		messageDesc = messages.Get(index)
+		messages = messageDesc.Messages()
+	}
+
+	if messageDesc == nil {
+		return nil, fmt.Errorf("message indexes are empty")
+	}
+
+	return messageDesc, nil
+}
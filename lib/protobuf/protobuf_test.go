@@ -0,0 +1,257 @@
+package protobuf
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/artie-labs/transfer/lib/schemaregistry"
+)
+
+const commonFile = `{
+	"name": "common.proto",
+	"package": "common",
+	"syntax": "proto3",
+	"enumType": [{"name": "Status", "value": [{"name": "UNKNOWN", "number": 0}, {"name": "ACTIVE", "number": 1}]}]
+}`
+
+const ordersFile = `{
+	"name": "orders.proto",
+	"package": "dbserver1.public.orders",
+	"syntax": "proto3",
+	"dependency": ["common.proto", "google/protobuf/timestamp.proto", "google/protobuf/duration.proto", "google/protobuf/wrappers.proto", "google/protobuf/struct.proto"],
+	"messageType": [
+		{"name": "Other"},
+		{
+			"name": "Envelope",
+			"field": [
+				{"name": "after", "number": 1, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".dbserver1.public.orders.Envelope.Value"},
+				{"name": "op", "number": 2, "label": "LABEL_OPTIONAL", "type": "TYPE_STRING"}
+			],
+			"nestedType": [{
+				"name": "Value",
+				"field": [
+					{"name": "id", "number": 1, "label": "LABEL_OPTIONAL", "type": "TYPE_INT32"},
+					{"name": "name", "number": 2, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".google.protobuf.StringValue"},
+					{"name": "created_at", "number": 3, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".google.protobuf.Timestamp"},
+					{"name": "ttl", "number": 4, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".google.protobuf.Duration"},
+					{"name": "status", "number": 5, "label": "LABEL_OPTIONAL", "type": "TYPE_ENUM", "typeName": ".common.Status"},
+					{"name": "payload", "number": 6, "label": "LABEL_OPTIONAL", "type": "TYPE_BYTES"},
+					{"name": "tags", "number": 7, "label": "LABEL_REPEATED", "type": "TYPE_STRING"},
+					{"name": "attributes", "number": 8, "label": "LABEL_REPEATED", "type": "TYPE_MESSAGE", "typeName": ".dbserver1.public.orders.Envelope.Value.AttributesEntry"},
+					{"name": "metadata", "number": 9, "label": "LABEL_OPTIONAL", "type": "TYPE_MESSAGE", "typeName": ".google.protobuf.Struct"},
+					{"name": "price", "number": 10, "label": "LABEL_OPTIONAL", "type": "TYPE_DOUBLE"}
+				],
+				"nestedType": [{
+					"name": "AttributesEntry",
+					"field": [
+						{"name": "key", "number": 1, "label": "LABEL_OPTIONAL", "type": "TYPE_STRING"},
+						{"name": "value", "number": 2, "label": "LABEL_OPTIONAL", "type": "TYPE_INT64"}
+					],
+					"options": {"mapEntry": true}
+				}]
+			}]
+		}
+	]
+}`
+
+func parseFileProto(t *testing.T, fileJSON string) *descriptorpb.FileDescriptorProto {
+	var fileProto descriptorpb.FileDescriptorProto
+	assert.NoError(t, protojson.Unmarshal([]byte(fileJSON), &fileProto))
+	return &fileProto
+}
+
+func serializeFile(t *testing.T, fileJSON string) string {
+	bytes, err := proto.Marshal(parseFileProto(t, fileJSON))
+	assert.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(bytes)
+}
+
+func envelopeDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	files := new(protoregistry.Files)
+	common, err := protodesc.NewFile(parseFileProto(t, commonFile), files)
+	assert.NoError(t, err)
+	assert.NoError(t, files.RegisterFile(common))
+
+	orders, err := protodesc.NewFile(parseFileProto(t, ordersFile), resolver{files: files})
+	assert.NoError(t, err)
+	return orders.Messages().ByName("Envelope")
+}
+
+func TestDecode(t *testing.T) {
+	messageDesc := envelopeDescriptor(t)
+	msg := dynamicpb.NewMessage(messageDesc)
+	assert.NoError(t, protojson.Unmarshal([]byte(`{
+		"after": {
+			"id": 1,
+			"name": "robin",
+			"created_at": "2023-06-27T19:33:44.123456Z",
+			"ttl": "1.5s",
+			"status": "ACTIVE",
+			"payload": "aGk=",
+			"tags": ["a", "b"],
+			"attributes": {"size": "5"},
+			"metadata": {"foo": "bar", "count": 2}
+		},
+		"op": "c"
+	}`), msg))
+
+	bytes, err := proto.Marshal(msg)
+	assert.NoError(t, err)
+
+	decoded, err := Decode(messageDesc, bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"after": map[string]interface{}{
+			"id":         int32(1),
+			"name":       "robin",
+			"created_at": int64(1687894424123456),
+			"ttl":        int64(1500000),
+			"status":     "ACTIVE",
+			"payload":    "aGk=",
+			"tags":       []interface{}{"a", "b"},
+			"attributes": map[string]interface{}{"size": int64(5)},
+			"metadata":   map[string]interface{}{"foo": "bar", "count": float64(2)},
+			"price":      float64(0),
+		},
+		"op": "c",
+	}, decoded)
+
+	// Fields with presence that are not set will be nil.
+	decoded, err = Decode(messageDesc, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"after": nil, "op": ""}, decoded)
+
+	_, err = Decode(messageDesc, []byte{0xff})
+	assert.Error(t, err)
+}
+
+func TestUnwrap(t *testing.T) {
+	value := envelopeDescriptor(t).Messages().ByName("Value")
+	assert.Equal(t, protoreflect.StringKind, Unwrap(value.Fields().ByName("name")).Kind())
+	assert.Equal(t, value.Fields().ByName("created_at"), Unwrap(value.Fields().ByName("created_at")))
+	assert.Equal(t, value.Fields().ByName("id"), Unwrap(value.Fields().ByName("id")))
+}
+
+func TestParseMessageIndexes(t *testing.T) {
+	indexes, payload, err := ParseMessageIndexes([]byte{0, 'h', 'i'})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0}, indexes)
+	assert.Equal(t, []byte("hi"), payload)
+
+	// Zig-zag encoded: 2 => 4, 1 => 2, 0 => 0
+	indexes, payload, err = ParseMessageIndexes([]byte{4, 2, 0, 'h', 'i'})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 0}, indexes)
+	assert.Equal(t, []byte("hi"), payload)
+
+	_, _, err = ParseMessageIndexes(nil)
+	assert.Error(t, err)
+
+	_, _, err = ParseMessageIndexes([]byte{6, 2})
+	assert.ErrorContains(t, err, "invalid message indexes count")
+}
+
+func TestMessageByIndexes(t *testing.T) {
+	file := envelopeDescriptor(t).ParentFile()
+
+	messageDesc, err := MessageByIndexes(file, []int{1})
+	assert.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("dbserver1.public.orders.Envelope"), messageDesc.FullName())
+
+	messageDesc, err = MessageByIndexes(file, []int{1, 0})
+	assert.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("dbserver1.public.orders.Envelope.Value"), messageDesc.FullName())
+
+	_, err = MessageByIndexes(file, []int{2})
+	assert.ErrorContains(t, err, "out of range")
+
+	_, err = MessageByIndexes(file, nil)
+	assert.Error(t, err)
+}
+
+func TestFileFromRegistry(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.String())
+		var schema schemaregistry.Schema
+		switch r.URL.String() {
+		case "/schemas/ids/10?format=serialized":
+			schema = schemaregistry.Schema{
+				Schema:     serializeFile(t, ordersFile),
+				SchemaType: schemaregistry.SchemaTypeProtobuf,
+				References: []schemaregistry.Reference{{Name: "common.proto", Subject: "common", Version: 1}},
+			}
+		case "/subjects/common/versions/1?format=serialized":
+			schema = schemaregistry.Schema{Schema: serializeFile(t, commonFile), SchemaType: schemaregistry.SchemaTypeProtobuf}
+		case "/schemas/ids/11?format=serialized":
+			schema = schemaregistry.Schema{Schema: `{"type": "string"}`}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		assert.NoError(t, json.NewEncoder(w).Encode(schema))
+	}))
+	defer server.Close()
+
+	client := schemaregistry.NewClient(server.URL, "", "")
+	for i := 0; i < 2; i++ {
+		file, err := FileFromRegistry(context.Background(), client, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, protoreflect.FullName("dbserver1.public.orders"), file.Package())
+	}
+
+	assert.Equal(t, []string{"/schemas/ids/10?format=serialized", "/subjects/common/versions/1?format=serialized"}, requests)
+
+	_, err := FileFromRegistry(context.Background(), client, 11)
+	assert.ErrorContains(t, err, "is not a protobuf schema")
+}
+
+func TestMessageFromDescriptorSet(t *testing.T) {
+	fileSet := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(envelopeDescriptor(t).ParentFile()),
+			parseFileProto(t, commonFile),
+		},
+	}
+
+	for _, wkt := range []string{"google/protobuf/timestamp.proto", "google/protobuf/duration.proto", "google/protobuf/wrappers.proto", "google/protobuf/struct.proto"} {
+		file, err := protoregistry.GlobalFiles.FindFileByPath(wkt)
+		assert.NoError(t, err)
+		fileSet.File = append(fileSet.File, protodesc.ToFileDescriptorProto(file))
+	}
+
+	bytes, err := proto.Marshal(fileSet)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "descriptors.pb")
+	assert.NoError(t, os.WriteFile(path, bytes, 0644))
+
+	messageDesc, err := MessageFromDescriptorSet(path, "dbserver1.public.orders.Envelope")
+	assert.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("dbserver1.public.orders.Envelope"), messageDesc.FullName())
+
+	_, err = MessageFromDescriptorSet(path, "dbserver1.public.orders.Missing")
+	assert.ErrorContains(t, err, "failed to find message")
+
+	_, err = MessageFromDescriptorSet(path, "common.Status")
+	assert.ErrorContains(t, err, "is not a message")
+
+	_, err = MessageFromDescriptorSet(filepath.Join(t.TempDir(), "missing.pb"), "foo")
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/artie-labs/transfer/lib/config"
+)
+
+const (
+	schemaRegistryClientKey = "_src"
+
+	// magicByte is the first byte of every message serialized with the Confluent wire format, it is followed by a 4-byte schema ID.
+	magicByte  = 0
+	headerSize = 5
+)
+
+const SchemaTypeProtobuf = "PROTOBUF"
+
+type Schema struct {
+	Schema string `json:"schema"`
+	// SchemaType is empty for Avro schemas.
+	SchemaType string      `json:"schemaType"`
+	References []Reference `json:"references"`
+}
+
+// Reference is a schema that is imported by another schema, Name is the import path.
+type Reference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// Client fetches schemas from a Confluent compatible schema registry.
+// Schemas are immutable once registered, so we will cache them for the lifetime of the process.
+type Client struct {
+	url        string
+	username   string
+	password   string
+	httpClient *http.Client
+
+	cache map[string]Schema
+	sync.RWMutex
+}
+
+func NewClient(url, username, password string) *Client {
+	return &Client{
+		url:      strings.TrimSuffix(url, "/"),
+		username: username,
+		password: password,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		cache: make(map[string]Schema),
+	}
+}
+
+func (c *Client) GetSchema(ctx context.Context, id int) (Schema, error) {
+	return c.get(ctx, fmt.Sprintf("/schemas/ids/%d", id))
+}
+
+// GetSerializedSchema returns Protobuf schemas as a base64 encoded FileDescriptorProto rather than the .proto source.
+func (c *Client) GetSerializedSchema(ctx context.Context, id int) (Schema, error) {
+	return c.get(ctx, fmt.Sprintf("/schemas/ids/%d?format=serialized", id))
+}
+
+// GetSerializedReference is used to resolve the references of a serialized schema.
+func (c *Client) GetSerializedReference(ctx context.Context, ref Reference) (Schema, error) {
+	return c.get(ctx, fmt.Sprintf("/subjects/%s/versions/%d?format=serialized", url.PathEscape(ref.Subject), ref.Version))
+}
+
+func (c *Client) get(ctx context.Context, path string) (Schema, error) {
+	c.RLock()
+	schema, isOk := c.cache[path]
+	c.RUnlock()
+	if isOk {
+		return schema, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return Schema{}, fmt.Errorf("failed to create request, err: %v", err)
+	}
+
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Schema{}, fmt.Errorf("failed to fetch schema, path: %s, err: %v", path, err)
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Schema{}, fmt.Errorf("failed to read response, path: %s, err: %v", path, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return Schema{}, fmt.Errorf("failed to fetch schema, path: %s, status: %d, body: %s", path, resp.StatusCode, string(body))
+	}
+
+	if err = json.Unmarshal(body, &schema); err != nil {
+		return Schema{}, fmt.Errorf("failed to unmarshal schema, path: %s, err: %v", path, err)
+	}
+
+	c.Lock()
+	c.cache[path] = schema
+	c.Unlock()
+	return schema, nil
+}
+
+// ParseWireFormat splits a message serialized with the Confluent wire format into its schema ID and payload.
+func ParseWireFormat(bytes []byte) (int, []byte, error) {
+	if len(bytes) < headerSize {
+		return 0, nil, fmt.Errorf("message is too short to be in the schema registry wire format, length: %d", len(bytes))
+	}
+
+	if bytes[0] != magicByte {
+		return 0, nil, fmt.Errorf("unknown magic byte: %d", bytes[0])
+	}
+
+	return int(binary.BigEndian.Uint32(bytes[1:headerSize])), bytes[headerSize:], nil
+}
+
+func InjectClientIntoCtx(ctx context.Context, client *Client) context.Context {
+	return context.WithValue(ctx, schemaRegistryClientKey, client)
+}
+
+// FromContext returns nil if the schema registry has not been configured.
+func FromContext(ctx context.Context) *Client {
+	clientVal := ctx.Value(schemaRegistryClientKey)
+	if clientVal == nil {
+		return nil
+	}
+
+	client, isOk := clientVal.(*Client)
+	if !isOk {
+		return nil
+	}
+
+	return client
+}
+
+func LoadClient(ctx context.Context) context.Context {
+	settings := config.FromContext(ctx)
+	if settings.Config.SchemaRegistry == nil {
+		return ctx
+	}
+
+	return InjectClientIntoCtx(ctx, NewClient(settings.Config.SchemaRegistry.URL,
+		settings.Config.SchemaRegistry.Username, settings.Config.SchemaRegistry.Password))
+}
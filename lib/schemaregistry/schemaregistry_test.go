@@ -0,0 +1,71 @@
+package schemaregistry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetSchema(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests += 1
+		username, password, _ := r.BasicAuth()
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+
+		if r.URL.Path != "/schemas/ids/1" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error_code":40403,"message":"Schema not found"}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"schema":"\"string\""}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", "user", "pass")
+	for i := 0; i < 3; i++ {
+		schema, err := client.GetSchema(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, `"string"`, schema.Schema)
+		assert.Equal(t, "", schema.SchemaType)
+	}
+
+	// Subsequent calls should be served from the cache.
+	assert.Equal(t, 1, requests)
+
+	_, err := client.GetSchema(context.Background(), 2)
+	assert.ErrorContains(t, err, "status: 404")
+	assert.Equal(t, 2, requests)
+}
+
+func TestParseWireFormat(t *testing.T) {
+	schemaID, payload, err := ParseWireFormat([]byte{0, 0, 0, 1, 2, 'h', 'i'})
+	assert.NoError(t, err)
+	assert.Equal(t, 258, schemaID)
+	assert.Equal(t, []byte("hi"), payload)
+
+	schemaID, payload, err = ParseWireFormat([]byte{0, 0, 0, 0, 7})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, schemaID)
+	assert.Empty(t, payload)
+
+	_, _, err = ParseWireFormat([]byte{0, 0, 0})
+	assert.ErrorContains(t, err, "too short")
+
+	_, _, err = ParseWireFormat([]byte(`{"id": 1}`))
+	assert.ErrorContains(t, err, "unknown magic byte")
+}
+
+func TestFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, FromContext(ctx))
+
+	client := NewClient("http://localhost:8081", "", "")
+	assert.Equal(t, client, FromContext(InjectClientIntoCtx(ctx, client)))
+}
@@ -0,0 +1,230 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gopkg.in/yaml.v2"
+
+	"github.com/artie-labs/transfer/lib/logger"
+	"github.com/artie-labs/transfer/lib/maputil"
+	"github.com/artie-labs/transfer/lib/telemetry/metrics/base"
+)
+
+const (
+	Namespace        = "namespace"
+	DefaultNamespace = "transfer"
+
+	Addr = "addr"
+	// DefaultAddr is where the /metrics endpoint will be served.
+	DefaultAddr = ":9090"
+
+	// Buckets are in seconds and are used for every timing histogram.
+	Buckets = "buckets"
+
+	// Labels is the allow-list of tags that will be exported as labels, every other tag will be dropped to keep cardinality under control.
+	Labels = "labels"
+)
+
+var DefaultLabels = []string{"database", "groupID", "reason", "schema", "table", "topic", "what"}
+
+var (
+	invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+	validLabelName   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type client struct {
+	namespace string
+	buckets   []float64
+	// labels is sorted, every metric will have all of these labels and tags that are missing will be empty.
+	labels []string
+
+	registry   *prometheus.Registry
+	histograms map[string]*prometheus.HistogramVec
+	counters   map[string]*prometheus.CounterVec
+	gauges     map[string]*prometheus.GaugeVec
+	sync.Mutex
+}
+
+// NewPrometheusClient returns a client that will serve its metrics on `/metrics` of the configured address.
+func NewPrometheusClient(ctx context.Context, settings map[string]interface{}) (base.Client, error) {
+	c, err := newClient(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", c.handler())
+	server := &http.Server{
+		Addr:              fmt.Sprint(maputil.GetKeyFromMap(settings, Addr, DefaultAddr)),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		// This is served for the lifetime of the process, so that metrics are still available during the final flush.
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.FromContext(ctx).WithError(err).Error("failed to serve prometheus metrics")
+		}
+	}()
+
+	return c, nil
+}
+
+func newClient(settings map[string]interface{}) (*client, error) {
+	var buckets []float64
+	if val := maputil.GetKeyFromMap(settings, Buckets, nil); val != nil {
+		if err := unmarshalSetting(val, &buckets); err != nil {
+			return nil, fmt.Errorf("failed to parse buckets: %v, err: %v", val, err)
+		}
+
+		if !sort.Float64sAreSorted(buckets) {
+			return nil, fmt.Errorf("buckets have to be in increasing order, buckets: %v", buckets)
+		}
+	}
+
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	var labels []string
+	if val := maputil.GetKeyFromMap(settings, Labels, nil); val != nil {
+		if err := unmarshalSetting(val, &labels); err != nil {
+			return nil, fmt.Errorf("failed to parse labels: %v, err: %v", val, err)
+		}
+	}
+
+	if len(labels) == 0 {
+		labels = DefaultLabels
+	}
+
+	for _, label := range labels {
+		if !validLabelName.MatchString(label) {
+			return nil, fmt.Errorf("label: %s is not a valid prometheus label name", label)
+		}
+	}
+
+	sortedLabels := make([]string, len(labels))
+	copy(sortedLabels, labels)
+	sort.Strings(sortedLabels)
+
+	return &client{
+		namespace:  fmt.Sprint(maputil.GetKeyFromMap(settings, Namespace, DefaultNamespace)),
+		buckets:    buckets,
+		labels:     sortedLabels,
+		registry:   prometheus.NewRegistry(),
+		histograms: make(map[string]*prometheus.HistogramVec),
+		counters:   make(map[string]*prometheus.CounterVec),
+		gauges:     make(map[string]*prometheus.GaugeVec),
+	}, nil
+}
+
+// unmarshalSetting is used for lists, since YAML will parse them as a sequence, so we'll unpack it again with the same library.
+func unmarshalSetting(val interface{}, out interface{}) error {
+	yamlBytes, err := yaml.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(yamlBytes, out)
+}
+
+func (c *client) handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+// metricName converts our metric names (e.g. `process.message`) into valid Prometheus names.
+func metricName(name, suffix string) string {
+	return invalidNameChars.ReplaceAllString(name, "_") + suffix
+}
+
+// toLabels returns the allowed tags as labels, the rest are dropped.
+func (c *client) toLabels(tags map[string]string) prometheus.Labels {
+	labels := make(prometheus.Labels, len(c.labels))
+	for _, label := range c.labels {
+		labels[label] = tags[label]
+	}
+
+	return labels
+}
+
+func (c *client) histogram(name string) *prometheus.HistogramVec {
+	c.Lock()
+	defer c.Unlock()
+
+	histogram, isOk := c.histograms[name]
+	if !isOk {
+		histogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: c.namespace,
+			Name:      metricName(name, "_seconds"),
+			Buckets:   c.buckets,
+		}, c.labels)
+		c.registry.MustRegister(histogram)
+		c.histograms[name] = histogram
+	}
+
+	return histogram
+}
+
+func (c *client) counter(name string) *prometheus.CounterVec {
+	c.Lock()
+	defer c.Unlock()
+
+	counter, isOk := c.counters[name]
+	if !isOk {
+		counter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: c.namespace,
+			Name:      metricName(name, "_total"),
+		}, c.labels)
+		c.registry.MustRegister(counter)
+		c.counters[name] = counter
+	}
+
+	return counter
+}
+
+func (c *client) gauge(name string) *prometheus.GaugeVec {
+	c.Lock()
+	defer c.Unlock()
+
+	gauge, isOk := c.gauges[name]
+	if !isOk {
+		gauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: c.namespace,
+			Name:      metricName(name, ""),
+		}, c.labels)
+		c.registry.MustRegister(gauge)
+		c.gauges[name] = gauge
+	}
+
+	return gauge
+}
+
+func (c *client) Timing(name string, value time.Duration, tags map[string]string) {
+	c.histogram(name).With(c.toLabels(tags)).Observe(value.Seconds())
+}
+
+func (c *client) Incr(name string, tags map[string]string) {
+	c.counter(name).With(c.toLabels(tags)).Inc()
+}
+
+func (c *client) Count(name string, value int64, tags map[string]string) {
+	if value < 0 {
+		// Counters can only go up.
+		return
+	}
+
+	c.counter(name).With(c.toLabels(tags)).Add(float64(value))
+}
+
+func (c *client) Gauge(name string, value float64, tags map[string]string) {
+	c.gauge(name).With(c.toLabels(tags)).Set(value)
+}
@@ -0,0 +1,63 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClient(t *testing.T) {
+	c, err := newClient(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultNamespace, c.namespace)
+	assert.Equal(t, prometheus.DefBuckets, c.buckets)
+	assert.Equal(t, DefaultLabels, c.labels)
+
+	// YAML will parse lists as []interface{}.
+	c, err = newClient(map[string]interface{}{
+		Namespace: "artie",
+		Buckets:   []interface{}{0.1, 1, 10},
+		Labels:    []interface{}{"topic", "database"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "artie", c.namespace)
+	assert.Equal(t, []float64{0.1, 1, 10}, c.buckets)
+	assert.Equal(t, []string{"database", "topic"}, c.labels)
+
+	_, err = newClient(map[string]interface{}{Buckets: []interface{}{10, 1}})
+	assert.ErrorContains(t, err, "buckets have to be in increasing order")
+
+	_, err = newClient(map[string]interface{}{Labels: []interface{}{"not-valid"}})
+	assert.ErrorContains(t, err, "label: not-valid is not a valid prometheus label name")
+}
+
+func TestClient(t *testing.T) {
+	c, err := newClient(map[string]interface{}{
+		Buckets: []interface{}{0.5, 5},
+		Labels:  []interface{}{"table", "what"},
+	})
+	assert.NoError(t, err)
+
+	// Tags that are not in the allow-list are dropped, and missing ones are empty.
+	c.Timing("process.message", time.Second, map[string]string{"table": "orders", "what": "success", "groupID": "foo"})
+	c.Incr("process.dlq", map[string]string{"table": "orders"})
+	c.Count("process.dlq", 2, map[string]string{"table": "orders"})
+	c.Count("process.dlq", -1, map[string]string{"table": "orders"})
+	c.Gauge("memory.size", 42, nil)
+
+	recorder := httptest.NewRecorder()
+	c.handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	body := recorder.Body.String()
+	assert.Contains(t, body, `transfer_process_message_seconds_bucket{table="orders",what="success",le="0.5"} 0`)
+	assert.Contains(t, body, `transfer_process_message_seconds_bucket{table="orders",what="success",le="5"} 1`)
+	assert.Contains(t, body, `transfer_process_message_seconds_sum{table="orders",what="success"} 1`)
+	assert.Contains(t, body, `transfer_process_dlq_total{table="orders",what=""} 3`)
+	assert.Contains(t, body, `transfer_memory_size{table="",what=""} 42`)
+	assert.NotContains(t, body, "groupID")
+}
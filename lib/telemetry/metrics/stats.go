@@ -5,12 +5,14 @@ import (
 
 	"github.com/artie-labs/transfer/lib/config"
 	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/telemetry/metrics/base"
 	"github.com/artie-labs/transfer/lib/telemetry/metrics/datadog"
+	"github.com/artie-labs/transfer/lib/telemetry/metrics/prometheus"
 
 	"github.com/artie-labs/transfer/lib/logger"
 )
 
-var supportedExporterKinds = []constants.ExporterKind{constants.Datadog}
+var supportedExporterKinds = []constants.ExporterKind{constants.Datadog, constants.Prometheus}
 
 func exporterKindValid(kind constants.ExporterKind) bool {
 	var valid bool
@@ -27,7 +29,7 @@ func exporterKindValid(kind constants.ExporterKind) bool {
 func LoadExporter(ctx context.Context) context.Context {
 	settings := config.FromContext(ctx)
 	kind := settings.Config.Telemetry.Metrics.Provider
-	ddSettings := settings.Config.Telemetry.Metrics.Settings
+	exporterSettings := settings.Config.Telemetry.Metrics.Settings
 
 	if !exporterKindValid(kind) {
 		logger.FromContext(ctx).WithFields(map[string]interface{}{
@@ -35,15 +37,22 @@ func LoadExporter(ctx context.Context) context.Context {
 		}).Info("invalid or no exporter kind passed in, skipping...")
 	}
 
+	var statsClient base.Client
+	var exportErr error
 	switch kind {
 	case constants.Datadog:
-		statsClient, exportErr := datadog.NewDatadogClient(ctx, ddSettings)
-		if exportErr != nil {
-			logger.FromContext(ctx).WithField("provider", kind).Error(exportErr)
-		} else {
-			ctx = InjectMetricsClientIntoCtx(ctx, statsClient)
-			logger.FromContext(ctx).WithField("provider", kind).Info("Metrics client loaded")
-		}
+		statsClient, exportErr = datadog.NewDatadogClient(ctx, exporterSettings)
+	case constants.Prometheus:
+		statsClient, exportErr = prometheus.NewPrometheusClient(ctx, exporterSettings)
+	default:
+		return ctx
+	}
+
+	if exportErr != nil {
+		logger.FromContext(ctx).WithField("provider", kind).Error(exportErr)
+	} else {
+		ctx = InjectMetricsClientIntoCtx(ctx, statsClient)
+		logger.FromContext(ctx).WithField("provider", kind).Info("Metrics client loaded")
 	}
 
 	return ctx
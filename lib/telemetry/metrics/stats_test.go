@@ -11,6 +11,7 @@ import (
 func (m *MetricsTestSuite) TestExporterKindValid() {
 	exporterKindToResultsMap := map[constants.ExporterKind]bool{
 		constants.Datadog:                      true,
+		constants.Prometheus:                   true,
 		constants.ExporterKind("daaaa"):        false,
 		constants.ExporterKind("daaaa231321"):  false,
 		constants.ExporterKind("honeycomb.io"): false,
@@ -39,6 +40,10 @@ func (m *MetricsTestSuite) TestLoadExporter() {
 						Provider constants.ExporterKind `yaml:"provider"`
 						Settings map[string]interface{} `yaml:"settings,omitempty"`
 					}
+					Tracing struct {
+						Provider constants.ExporterKind `yaml:"provider"`
+						Settings map[string]interface{} `yaml:"settings,omitempty"`
+					}
 				}{
 					Metrics: struct {
 						Provider constants.ExporterKind `yaml:"provider"`
@@ -0,0 +1,188 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/logger"
+	"github.com/artie-labs/transfer/lib/maputil"
+)
+
+const (
+	ServiceName        = "serviceName"
+	DefaultServiceName = "transfer"
+
+	// Endpoint is the host:port of the OTLP gRPC collector.
+	// If it's not set, the exporter will fall back to OTEL_EXPORTER_OTLP_ENDPOINT and then localhost:4317.
+	Endpoint = "endpoint"
+	// Insecure will disable TLS when talking to the collector.
+	Insecure = "insecure"
+
+	// File is the path that the stdout exporter will append spans to, spans will be written to stdout if this is not set.
+	File = "file"
+)
+
+const (
+	tracerName        = "github.com/artie-labs/transfer"
+	tracerProviderKey = "_tpk"
+)
+
+var supportedExporterKinds = []constants.ExporterKind{constants.OTLP, constants.Stdout}
+
+func exporterKindValid(kind constants.ExporterKind) bool {
+	for _, supportedExporterKind := range supportedExporterKinds {
+		if kind == supportedExporterKind {
+			return true
+		}
+	}
+
+	return false
+}
+
+func InjectTracerProviderIntoCtx(ctx context.Context, tracerProvider *sdktrace.TracerProvider) context.Context {
+	return context.WithValue(ctx, tracerProviderKey, tracerProvider)
+}
+
+func tracerProviderFromContext(ctx context.Context) *sdktrace.TracerProvider {
+	tracerProvider, isOk := ctx.Value(tracerProviderKey).(*sdktrace.TracerProvider)
+	if !isOk {
+		return nil
+	}
+
+	return tracerProvider
+}
+
+// FromContext will return a no-op tracer if tracing has not been configured.
+func FromContext(ctx context.Context) trace.Tracer {
+	if tracerProvider := tracerProviderFromContext(ctx); tracerProvider != nil {
+		return tracerProvider.Tracer(tracerName)
+	}
+
+	return trace.NewNoopTracerProvider().Tracer(tracerName)
+}
+
+// LoadExporter will set up the tracer provider if a tracing provider has been configured.
+func LoadExporter(ctx context.Context) context.Context {
+	settings := config.FromContext(ctx)
+	kind := settings.Config.Telemetry.Tracing.Provider
+	exporterSettings := settings.Config.Telemetry.Tracing.Settings
+
+	if !exporterKindValid(kind) {
+		logger.FromContext(ctx).WithFields(map[string]interface{}{
+			"exporterKind": kind,
+		}).Info("invalid or no tracing exporter kind passed in, skipping...")
+		return ctx
+	}
+
+	exporter, err := newExporter(ctx, kind, exporterSettings)
+	if err != nil {
+		logger.FromContext(ctx).WithField("provider", kind).Error(err)
+		return ctx
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(fmt.Sprint(maputil.GetKeyFromMap(exporterSettings, ServiceName, DefaultServiceName))),
+		)),
+	)
+
+	logger.FromContext(ctx).WithField("provider", kind).Info("Tracing exporter loaded")
+	return InjectTracerProviderIntoCtx(ctx, tracerProvider)
+}
+
+func newExporter(ctx context.Context, kind constants.ExporterKind, settings map[string]interface{}) (sdktrace.SpanExporter, error) {
+	switch kind {
+	case constants.OTLP:
+		var opts []otlptracegrpc.Option
+		if endpoint := maputil.GetKeyFromMap(settings, Endpoint, nil); endpoint != nil {
+			opts = append(opts, otlptracegrpc.WithEndpoint(fmt.Sprint(endpoint)))
+		}
+
+		if insecure, _ := strconv.ParseBool(fmt.Sprint(maputil.GetKeyFromMap(settings, Insecure, false))); insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create otlp exporter, err: %v", err)
+		}
+
+		return exporter, nil
+	case constants.Stdout:
+		var writer io.Writer = os.Stdout
+		if file := maputil.GetKeyFromMap(settings, File, nil); file != nil {
+			f, err := os.OpenFile(fmt.Sprint(file), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open file: %v, err: %v", file, err)
+			}
+
+			writer = f
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(writer))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter, err: %v", err)
+		}
+
+		return exporter, nil
+	}
+
+	return nil, fmt.Errorf("tracing exporter: %s is not supported", kind)
+}
+
+// Shutdown will export any remaining spans, this should be called once we are done flushing.
+func Shutdown(ctx context.Context) {
+	tracerProvider := tracerProviderFromContext(ctx)
+	if tracerProvider == nil {
+		return
+	}
+
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		logger.FromContext(ctx).WithError(err).Warn("failed to shut down tracer provider")
+	}
+}
+
+// StartSpan is a shorthand to start a span from the tracer within ctx.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return FromContext(ctx).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan will record err onto the span (if any) before ending it, literals within err are redacted like they are in our logs.
+func EndSpan(ctx context.Context, span trace.Span, err error) {
+	if err != nil {
+		redactedErr := logger.RedactQuery(ctx, err.Error())
+		span.RecordError(errors.New(redactedErr))
+		span.SetStatus(codes.Error, redactedErr)
+	}
+
+	span.End()
+}
+
+func Table(name string) attribute.KeyValue {
+	return attribute.String("table", name)
+}
+
+func Rows(rows uint) attribute.KeyValue {
+	return attribute.Int("rows", int(rows))
+}
+
+// Query will have its string literals redacted based on the logging policy, since spans are exported as well.
+func Query(ctx context.Context, query string) attribute.KeyValue {
+	return attribute.String("query", logger.RedactQuery(ctx, query))
+}
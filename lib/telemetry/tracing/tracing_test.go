@@ -0,0 +1,82 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/config/constants"
+)
+
+func TestExporterKindValid(t *testing.T) {
+	assert.True(t, exporterKindValid(constants.OTLP))
+	assert.True(t, exporterKindValid(constants.Stdout))
+	assert.False(t, exporterKindValid(constants.Datadog))
+	assert.False(t, exporterKindValid(""))
+}
+
+func TestSpans(t *testing.T) {
+	// Without a provider, spans are no-ops.
+	_, span := StartSpan(context.Background(), "noop")
+	assert.False(t, span.SpanContext().IsValid())
+	EndSpan(context.Background(), span, nil)
+
+	recorder := tracetest.NewSpanRecorder()
+	ctx := config.InjectSettingsIntoContext(context.Background(), &config.Settings{
+		Config: &config.Config{Logging: config.Logging{Redaction: constants.RedactionTruncate, TruncateLength: 2}},
+	})
+	ctx = InjectTracerProviderIntoCtx(ctx, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	parentCtx, parent := StartSpan(ctx, "parent", Table("orders"), Rows(5))
+	_, child := StartSpan(parentCtx, "child", Query(ctx, "SELECT 'secret'"))
+	EndSpan(ctx, child, fmt.Errorf("query failed, value: 'secret'"))
+	EndSpan(ctx, parent, nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	// Literals are redacted based on the logging policy.
+	assert.Equal(t, "query failed, value: 'se...(6 chars)'", spans[0].Status().Description)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("query", "SELECT 'se...(6 chars)'")}, spans[0].Attributes())
+
+	assert.Equal(t, "parent", spans[1].Name())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+	assert.Equal(t, []attribute.KeyValue{Table("orders"), Rows(5)}, spans[1].Attributes())
+}
+
+func TestLoadExporter_File(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "spans.json")
+	settings := &config.Settings{Config: &config.Config{}}
+	settings.Config.Telemetry.Tracing.Provider = constants.Stdout
+	settings.Config.Telemetry.Tracing.Settings = map[string]interface{}{File: fp}
+
+	ctx := LoadExporter(config.InjectSettingsIntoContext(context.Background(), settings))
+	_, span := StartSpan(ctx, "flush", Table("orders"))
+	EndSpan(ctx, span, nil)
+	Shutdown(ctx)
+
+	bytes, err := os.ReadFile(fp)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bytes), `"Name":"flush"`)
+	assert.Contains(t, string(bytes), `{"Key":"table","Value":{"Type":"STRING","Value":"orders"}}`)
+	assert.Contains(t, string(bytes), DefaultServiceName)
+}
+
+func TestLoadExporter_Invalid(t *testing.T) {
+	settings := &config.Settings{Config: &config.Config{}}
+	settings.Config.Telemetry.Tracing.Provider = "honeycomb.io"
+
+	ctx := LoadExporter(config.InjectSettingsIntoContext(context.Background(), settings))
+	assert.Nil(t, tracerProviderFromContext(ctx))
+}
@@ -3,15 +3,20 @@ package main
 import (
 	"context"
 	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/artie-labs/transfer/lib/config"
 	"github.com/artie-labs/transfer/lib/config/constants"
 	"github.com/artie-labs/transfer/lib/destination/utils"
 	"github.com/artie-labs/transfer/lib/logger"
+	"github.com/artie-labs/transfer/lib/schemaregistry"
 	"github.com/artie-labs/transfer/lib/telemetry/metrics"
+	"github.com/artie-labs/transfer/lib/telemetry/tracing"
 	"github.com/artie-labs/transfer/models"
+	"github.com/artie-labs/transfer/processes/admin"
 	"github.com/artie-labs/transfer/processes/consumer"
 	"github.com/artie-labs/transfer/processes/pool"
 )
@@ -23,7 +28,11 @@ func main() {
 
 	// Loading Telemetry
 	ctx = metrics.LoadExporter(ctx)
-	if utils.IsOutputBaseline(ctx) {
+	ctx = tracing.LoadExporter(ctx)
+	ctx = schemaregistry.LoadClient(ctx)
+	if len(config.FromContext(ctx).Config.Destinations) > 0 {
+		ctx = utils.InjectDestinationsIntoCtx(utils.LoadDestinations(ctx), ctx)
+	} else if utils.IsOutputBaseline(ctx) {
 		ctx = utils.InjectBaselineIntoCtx(utils.Baseline(ctx), ctx)
 	} else {
 		ctx = utils.InjectDwhIntoCtx(utils.DataWarehouse(ctx, nil), ctx)
@@ -38,11 +47,19 @@ func main() {
 		"flush_pool_size (kb)":   settings.Config.FlushSizeKb,
 	}).Info("config is loaded")
 
+	// runCtx will be cancelled on SIGINT / SIGTERM, which will stop the consumers from fetching.
+	runCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if settings.Config.Admin != nil {
+		go admin.StartServer(runCtx)
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		pool.StartPool(ctx, time.Duration(settings.Config.FlushIntervalSeconds)*time.Second)
+		pool.StartPool(runCtx, time.Duration(settings.Config.FlushIntervalSeconds)*time.Second)
 	}()
 
 	wg.Add(1)
@@ -53,10 +70,39 @@ func main() {
 			consumer.StartConsumer(ctx)
 		case constants.PubSub:
 			consumer.StartSubscriber(ctx)
+		case constants.Kinesis:
+			consumer.StartKinesisConsumer(ctx)
+		case constants.FileQueue:
+			consumer.StartFileConsumer(ctx)
+			// Unlike the other queues, files are finite. Once they have been replayed, we will shut down.
+			stop()
 		default:
 			logger.FromContext(ctx).Fatalf("message queue: %s not supported", settings.Config.Queue)
 		}
-	}(ctx)
+	}(runCtx)
+
+	<-runCtx.Done()
+	shutdownTimeout := time.Duration(settings.Config.ShutdownTimeoutSeconds) * time.Second
+	logger.FromContext(ctx).WithField("timeout", shutdownTimeout).Info("Received shutdown signal, flushing...")
+
+	// The final flush cannot use runCtx since it has already been cancelled.
+	shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancel()
 
-	wg.Wait()
+	done := make(chan bool)
+	go func() {
+		wg.Wait()
+		consumer.Shutdown(shutdownCtx)
+		// Spans are batched, so we'll need to export the ones from the final flush.
+		tracing.Shutdown(shutdownCtx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.FromContext(ctx).Info("Shutdown complete")
+	case <-shutdownCtx.Done():
+		logger.FromContext(ctx).Error("Failed to shut down gracefully within the timeout, exiting...")
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,35 @@
+package event
+
+import (
+	"context"
+
+	"github.com/artie-labs/transfer/lib/kafkalib"
+)
+
+// dropExcludedColumns will remove the excluded columns from both the data and the schema, so they're never created in the destination.
+func (e *Event) dropExcludedColumns(ctx context.Context, tc *kafkalib.TopicConfig) {
+	if len(tc.IncludeColumns) == 0 && len(tc.ExcludeColumns) == 0 {
+		return
+	}
+
+	primaryKeys := e.PrimaryKeys()
+	for colName := range e.Data {
+		if !tc.WriteColumn(colName, primaryKeys) {
+			delete(e.Data, colName)
+		}
+	}
+
+	for colName := range e.OptionalSchema {
+		if !tc.WriteColumn(colName, primaryKeys) {
+			delete(e.OptionalSchema, colName)
+		}
+	}
+
+	if e.Columns != nil {
+		for _, col := range e.Columns.GetColumns() {
+			if colName := col.Name(ctx, nil); !tc.WriteColumn(colName, primaryKeys) {
+				e.Columns.DeleteColumn(colName)
+			}
+		}
+	}
+}
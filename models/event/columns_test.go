@@ -0,0 +1,60 @@
+package event
+
+import (
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/typing"
+	"github.com/artie-labs/transfer/lib/typing/columns"
+	"github.com/artie-labs/transfer/models"
+)
+
+func (e *EventsTestSuite) TestSaveEvent_ExcludedColumns() {
+	tc := *topicConfig
+	tc.IncludeColumns = []string{"name", "avatar_*"}
+	tc.ExcludeColumns = []string{"*_blob", "i*"}
+
+	var cols columns.Columns
+	cols.AddColumn(columns.NewColumn("id", typing.String))
+	cols.AddColumn(columns.NewColumn("name", typing.String))
+	cols.AddColumn(columns.NewColumn("avatar_blob", typing.String))
+	cols.AddColumn(columns.NewColumn("internal_notes", typing.String))
+
+	evt := Event{
+		Table:          "excluded",
+		PrimaryKeyMap:  map[string]interface{}{"id": "1"},
+		OptionalSchema: map[string]typing.KindDetails{"avatar_blob": typing.String, "name": typing.String},
+		Columns:        &cols,
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: false,
+			"id":                         "1",
+			"name":                       "robin",
+			"avatar_url":                 "https://artie.so/robin.png",
+			"avatar_blob":                "iVBORw0KGgo=",
+			"internal_notes":             "vip",
+		},
+	}
+
+	kafkaMsg := kafka.Message{}
+	_, _, err := evt.Save(e.ctx, &tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.NoError(e.T(), err)
+
+	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("excluded"))
+	// The primary key and the delete marker are always kept.
+	assert.Equal(e.T(), map[string]interface{}{
+		constants.DeleteColumnMarker: false,
+		"id":                         "1",
+		"name":                       "robin",
+		"avatar_url":                 "https://artie.so/robin.png",
+	}, td.RowsData()["id=1"])
+
+	var colNames []string
+	for _, col := range td.ReadOnlyInMemoryCols().GetColumns() {
+		colNames = append(colNames, col.Name(e.ctx, nil))
+	}
+
+	assert.ElementsMatch(e.T(), []string{"id", "name", "avatar_url", constants.DeleteColumnMarker}, colNames)
+	assert.Equal(e.T(), map[string]typing.KindDetails{"name": typing.String}, evt.OptionalSchema)
+}
@@ -0,0 +1,79 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+)
+
+// computeDerivedColumns evaluates the derived columns against the source row, before any of the source columns are excluded or transformed.
+// Deletes only carry the primary keys, so nothing is computed for them.
+func (e *Event) computeDerivedColumns(derivedColumns []kafkalib.DerivedColumn) (map[string]interface{}, error) {
+	if len(derivedColumns) == 0 || e.Deleted {
+		return nil, nil
+	}
+
+	values := make(map[string]interface{})
+	for idx := range derivedColumns {
+		derivedColumn := &derivedColumns[idx]
+		expression, err := derivedColumn.Compile()
+		if err != nil {
+			return nil, err
+		}
+
+		if e.referencesToastColumn(expression.Columns()) {
+			// The source value was unchanged and not sent over, so the destination should keep the previously computed value.
+			values[derivedColumn.Name] = constants.ToastUnavailableValuePlaceholder
+			continue
+		}
+
+		val, err := expression.Eval(e.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute column: %s, err: %v", derivedColumn.Name, err)
+		}
+
+		values[derivedColumn.Name] = val
+	}
+
+	return values, nil
+}
+
+func (e *Event) referencesToastColumn(colNames []string) bool {
+	for _, colName := range colNames {
+		for key, val := range e.Data {
+			if strings.EqualFold(key, colName) && val == constants.ToastUnavailableValuePlaceholder {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// setDerivedColumns adds the computed values to the row. A derived column replaces a source column with the same name,
+// and its type is inferred from the computed value when the row is saved.
+func (e *Event) setDerivedColumns(values map[string]interface{}) {
+	for colName, val := range values {
+		// Column names are lower cased when the row is saved, so drop the source column regardless of its casing.
+		for key := range e.Data {
+			if strings.EqualFold(key, colName) {
+				delete(e.Data, key)
+			}
+		}
+
+		for key := range e.OptionalSchema {
+			if strings.EqualFold(key, colName) {
+				delete(e.OptionalSchema, key)
+			}
+		}
+
+		e.Data[colName] = val
+		if e.Columns != nil {
+			if _, isOk := e.Columns.GetColumn(colName); isOk {
+				e.Columns.DeleteColumn(colName)
+			}
+		}
+	}
+}
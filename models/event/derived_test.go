@@ -0,0 +1,140 @@
+package event
+
+import (
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/typing"
+	"github.com/artie-labs/transfer/lib/typing/columns"
+	"github.com/artie-labs/transfer/lib/typing/ext"
+	"github.com/artie-labs/transfer/models"
+)
+
+func (e *EventsTestSuite) TestSaveEvent_DerivedColumns() {
+	tc := *topicConfig
+	tc.ExcludeColumns = []string{"payload"}
+	tc.DerivedColumns = []kafkalib.DerivedColumn{
+		{Name: "created_date", Expression: "date_trunc('day', created_at)"},
+		{Name: "zip", Expression: "json_extract(payload, '$.address.zip')"},
+		{Name: "source_region", Expression: "'us-east-1'"},
+		{Name: "order_key", Expression: "concat(tenant_id, '-', id)"},
+		{Name: "notes_upper", Expression: "upper(notes)"},
+	}
+
+	var cols columns.Columns
+	cols.AddColumn(columns.NewColumn("id", typing.Integer))
+	cols.AddColumn(columns.NewColumn("payload", typing.Struct))
+
+	evt := Event{
+		Table:          "derived",
+		PrimaryKeyMap:  map[string]interface{}{"id": 1},
+		OptionalSchema: map[string]typing.KindDetails{"id": typing.Integer, "payload": typing.Struct},
+		Columns:        &cols,
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: false,
+			"id":                         1,
+			"tenant_id":                  "acme",
+			"created_at":                 "2023-03-16T13:45:30Z",
+			"payload":                    `{"address": {"zip": "10001"}}`,
+			"notes":                      constants.ToastUnavailableValuePlaceholder,
+		},
+	}
+
+	kafkaMsg := kafka.Message{}
+	_, _, err := evt.Save(e.ctx, &tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.NoError(e.T(), err)
+
+	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("derived"))
+	// Derived columns can use excluded columns, since they're computed from the source row.
+	assert.Equal(e.T(), map[string]interface{}{
+		constants.DeleteColumnMarker: false,
+		"id":                         1,
+		"tenant_id":                  "acme",
+		"created_at":                 "2023-03-16T13:45:30Z",
+		"notes":                      constants.ToastUnavailableValuePlaceholder,
+		"created_date":               "2023-03-16",
+		"zip":                        "10001",
+		"source_region":              "us-east-1",
+		"order_key":                  "acme-1",
+		"notes_upper":                constants.ToastUnavailableValuePlaceholder,
+	}, td.RowsData()["id=1"])
+
+	// The types are inferred from the computed values.
+	col, isOk := td.ReadOnlyInMemoryCols().GetColumn("created_date")
+	assert.True(e.T(), isOk)
+	assert.Equal(e.T(), typing.ETime.Kind, col.KindDetails.Kind)
+	assert.Equal(e.T(), ext.DateKindType, col.KindDetails.ExtendedTimeDetails.Type)
+
+	col, isOk = td.ReadOnlyInMemoryCols().GetColumn("source_region")
+	assert.True(e.T(), isOk)
+	assert.Equal(e.T(), typing.String, col.KindDetails)
+
+	col, isOk = td.ReadOnlyInMemoryCols().GetColumn("notes_upper")
+	assert.True(e.T(), isOk)
+	assert.True(e.T(), col.ToastColumn)
+
+	_, isOk = td.ReadOnlyInMemoryCols().GetColumn("payload")
+	assert.False(e.T(), isOk)
+}
+
+func (e *EventsTestSuite) TestSaveEvent_DerivedColumnsTransformed() {
+	tc := *topicConfig
+	tc.DerivedColumns = []kafkalib.DerivedColumn{{Name: "email_domain", Expression: "lower(domain)"}}
+	tc.ColumnTransforms = []kafkalib.ColumnTransform{{Column: "email_domain", Kind: kafkalib.TransformMask}}
+
+	evt := Event{
+		Table:         "derived",
+		PrimaryKeyMap: map[string]interface{}{"id": "2"},
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: false,
+			"id":                         "2",
+			"domain":                     "Artie.so",
+		},
+	}
+
+	kafkaMsg := kafka.Message{}
+	_, _, err := evt.Save(e.ctx, &tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.NoError(e.T(), err)
+
+	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("derived"))
+	assert.Equal(e.T(), kafkalib.DefaultMask, td.RowsData()["id=2"]["email_domain"])
+
+	// Deletes only have the primary key, so nothing is computed.
+	evt = Event{
+		Table:         "derived",
+		PrimaryKeyMap: map[string]interface{}{"id": "2"},
+		Deleted:       true,
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: true,
+			"id":                         "2",
+		},
+	}
+
+	_, _, err = evt.Save(e.ctx, &tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.NoError(e.T(), err)
+	_, isOk := td.RowsData()["id=2"]["email_domain"]
+	assert.False(e.T(), isOk)
+}
+
+func (e *EventsTestSuite) TestSaveEvent_DerivedColumnsError() {
+	tc := *topicConfig
+	tc.DerivedColumns = []kafkalib.DerivedColumn{{Name: "total", Expression: "price * quantity"}}
+
+	evt := Event{
+		Table:         "derived",
+		PrimaryKeyMap: map[string]interface{}{"id": "3"},
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: false,
+			"id":                         "3",
+			"price":                      "free",
+			"quantity":                   2,
+		},
+	}
+
+	kafkaMsg := kafka.Message{}
+	_, _, err := evt.Save(e.ctx, &tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.ErrorContains(e.T(), err, "failed to compute column: total")
+}
@@ -109,9 +109,20 @@ func (e *Event) Save(ctx context.Context, topicConfig *kafkalib.TopicConfig, mes
 		return false, "", errors.New("event not valid")
 	}
 
+	derivedValues, err := e.computeDerivedColumns(topicConfig.DerivedColumns)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to compute derived columns, err: %v", err)
+	}
+
+	e.dropExcludedColumns(ctx, topicConfig)
+	e.setDerivedColumns(derivedValues)
+	if err := e.applyColumnTransforms(topicConfig.ColumnTransforms); err != nil {
+		return false, "", fmt.Errorf("failed to transform columns, err: %v", err)
+	}
+
 	inMemDB := models.GetMemoryDB(ctx)
 	// Does the table exist?
-	td := inMemDB.GetOrCreateTableData(e.Table)
+	td := inMemDB.GetOrCreateTableData(models.TableKey(topicConfig.Database, topicConfig.Schema, e.Table))
 	td.Lock()
 	defer td.Unlock()
 	if td.Empty() {
@@ -181,12 +192,13 @@ func (e *Event) Save(ctx context.Context, topicConfig *kafkalib.TopicConfig, mes
 	// Swap out sanitizedData <> data.
 	e.Data = sanitizedData
 	td.InsertRow(e.PrimaryKeyValue(), e.Data, e.Deleted)
-	// If the message is Kafka, then we only need the latest one
-	// If it's pubsub, we will store all of them in memory. This is because GCP pub/sub REQUIRES us to ack every single message
-	if message.Kind() == artie.Kafka {
-		td.PartitionsToLastMessage[message.Partition()] = []artie.Message{message}
+	// If the message is Kafka or Kinesis, then we only need the latest one since committing it will also commit the prior messages.
+	// Replayed files are checkpointed once the whole file has been flushed, so we also only need the latest one.
+	// If it's pubsub, we will store an ack handle for every message. This is because GCP pub/sub REQUIRES us to ack every single message
+	if message.Kind() != artie.PubSub {
+		td.PartitionsToLastMessage[message.PartitionKey()] = []artie.Message{message}
 	} else {
-		td.PartitionsToLastMessage[message.Partition()] = append(td.PartitionsToLastMessage[message.Partition()], message)
+		td.PartitionsToLastMessage[message.PartitionKey()] = append(td.PartitionsToLastMessage[message.PartitionKey()], message.AckHandle())
 	}
 
 	td.LatestCDCTs = e.ExecutionTime
@@ -22,6 +22,11 @@ var topicConfig = &kafkalib.TopicConfig{
 	Schema:    "public",
 }
 
+// tableKey returns the key that the table is buffered under when it's saved with topicConfig.
+func tableKey(tableName string) string {
+	return models.TableKey(topicConfig.Database, topicConfig.Schema, tableName)
+}
+
 func (e *EventsTestSuite) TestSaveEvent() {
 	expectedCol := "rOBiN TaNG"
 	expectedLowerCol := "robin__tang"
@@ -45,7 +50,7 @@ func (e *EventsTestSuite) TestSaveEvent() {
 	_, _, err := event.Save(e.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
 	assert.Nil(e.T(), err)
 
-	optimization := models.GetMemoryDB(e.ctx).GetOrCreateTableData("foo")
+	optimization := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("foo"))
 	// Check the in-memory DB columns.
 	var found int
 	for _, col := range optimization.ReadOnlyInMemoryCols().GetColumns() {
@@ -77,7 +82,7 @@ func (e *EventsTestSuite) TestSaveEvent() {
 	_, _, err = edgeCaseEvent.Save(e.ctx, topicConfig, artie.NewMessage(&newKafkaMsg, nil, newKafkaMsg.Topic))
 	assert.NoError(e.T(), err)
 
-	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData("foo")
+	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("foo"))
 	inMemCol, isOk := td.ReadOnlyInMemoryCols().GetColumn(badColumn)
 	assert.True(e.T(), isOk)
 	assert.Equal(e.T(), typing.Invalid, inMemCol.KindDetails)
@@ -100,7 +105,7 @@ func (e *EventsTestSuite) TestEvent_SaveCasing() {
 	_, _, err := event.Save(e.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
 	assert.Nil(e.T(), err)
 
-	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData("foo")
+	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("foo"))
 	rowData := td.RowsData()[event.PrimaryKeyValue()]
 	expectedColumns := []string{"randomcol", "anothercol"}
 	for _, expectedColumn := range expectedColumns {
@@ -136,7 +141,7 @@ func (e *EventsTestSuite) TestEventSaveOptionalSchema() {
 	_, _, err := event.Save(e.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
 	assert.Nil(e.T(), err)
 
-	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData("foo")
+	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("foo"))
 	column, isOk := td.ReadOnlyInMemoryCols().GetColumn("created_at_date_string")
 	assert.True(e.T(), isOk)
 	assert.Equal(e.T(), typing.String, column.KindDetails)
@@ -175,7 +180,7 @@ func (e *EventsTestSuite) TestEvent_SaveColumnsNoData() {
 	_, _, err := evt.Save(e.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
 	assert.NoError(e.T(), err)
 
-	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData("non_existent")
+	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("non_existent"))
 	var prevKey string
 	for _, col := range td.ReadOnlyInMemoryCols().GetColumns() {
 		if col.Name(e.ctx, nil) == constants.DeleteColumnMarker {
@@ -232,7 +237,7 @@ func (e *EventsTestSuite) TestEventSaveColumns() {
 	_, _, err := event.Save(e.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
 	assert.Nil(e.T(), err)
 
-	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData("foo")
+	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("foo"))
 
 	column, isOk := td.ReadOnlyInMemoryCols().GetColumn("randomcol")
 	assert.True(e.T(), isOk)
@@ -266,10 +271,10 @@ func (e *EventsTestSuite) TestEventSaveTestDeleteFlag() {
 	kafkaMsg := kafka.Message{}
 	_, _, err := event.Save(e.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
 	assert.Nil(e.T(), err)
-	assert.False(e.T(), models.GetMemoryDB(e.ctx).GetOrCreateTableData("foo").ContainOtherOperations())
+	assert.False(e.T(), models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("foo")).ContainOtherOperations())
 
 	event.Deleted = false
 	_, _, err = event.Save(e.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
 	assert.NoError(e.T(), err)
-	assert.True(e.T(), models.GetMemoryDB(e.ctx).GetOrCreateTableData("foo").ContainOtherOperations())
+	assert.True(e.T(), models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("foo")).ContainOtherOperations())
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/artie-labs/transfer/lib/typing/columns"
 
+	"github.com/artie-labs/transfer/lib/cdc"
 	"github.com/artie-labs/transfer/lib/config/constants"
 	"github.com/artie-labs/transfer/lib/kafkalib"
 	"github.com/artie-labs/transfer/lib/typing"
@@ -35,6 +36,10 @@ func (f fakeEvent) GetTableName() string {
 	return "foo"
 }
 
+func (f fakeEvent) GetSourceMetadata() cdc.SourceMetadata {
+	return cdc.SourceMetadata{Table: f.GetTableName()}
+}
+
 func (f fakeEvent) GetOptionalSchema(ctx context.Context) map[string]typing.KindDetails {
 	return nil
 }
@@ -0,0 +1,71 @@
+package event
+
+import (
+	"context"
+	"errors"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/expr"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/optimization"
+	"github.com/artie-labs/transfer/lib/typing/columns"
+	"github.com/artie-labs/transfer/models"
+)
+
+const updateOperation = "u"
+
+// ApplyFilter returns true if the event should be skipped because it does not match the filter.
+// An update that does not match is turned into a delete instead, since the row may have matched before and would otherwise
+// be left behind in the destination. Deletes are never filtered, the before image may not have the filtered columns.
+func (e *Event) ApplyFilter(filter *expr.Expression, operation string) (bool, error) {
+	if filter == nil || e.Deleted {
+		return false, nil
+	}
+
+	// We can't tell whether the row matches if the value is unchanged and was not sent over.
+	if e.referencesToastColumn(filter.Columns()) {
+		return false, nil
+	}
+
+	matches, err := filter.Matches(e.Data)
+	if err != nil || matches {
+		return false, err
+	}
+
+	if operation != updateOperation {
+		return true, nil
+	}
+
+	e.Deleted = true
+	e.Data[constants.DeleteColumnMarker] = true
+	return false, nil
+}
+
+// Skip will keep track of the message without buffering the row, so its offset is still committed (or acked) on the table's next flush.
+func (e *Event) Skip(ctx context.Context, topicConfig *kafkalib.TopicConfig, message artie.Message) error {
+	if topicConfig == nil {
+		return errors.New("topicConfig is missing")
+	}
+
+	inMemDB := models.GetMemoryDB(ctx)
+	td := inMemDB.GetOrCreateTableData(models.TableKey(topicConfig.Database, topicConfig.Schema, e.Table))
+	td.Lock()
+	defer td.Unlock()
+	if td.Empty() {
+		cols := &columns.Columns{}
+		if e.Columns != nil {
+			cols = e.Columns
+		}
+
+		td.SetTableData(optimization.NewTableData(cols, e.PrimaryKeys(), *topicConfig, e.Table))
+	}
+
+	if message.Kind() != artie.PubSub {
+		td.PartitionsToLastMessage[message.PartitionKey()] = []artie.Message{message}
+	} else {
+		td.PartitionsToLastMessage[message.PartitionKey()] = append(td.PartitionsToLastMessage[message.PartitionKey()], message.AckHandle())
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package event
+
+import (
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/ptr"
+	"github.com/artie-labs/transfer/lib/typing"
+	"github.com/artie-labs/transfer/lib/typing/columns"
+)
+
+// AddSourceIdentifier adds the source identifier column to the row and to its primary keys, so it's part of both the buffer key
+// (see PrimaryKeyValue) and the merge's primary keys.
+func (e *Event) AddSourceIdentifier(sourceIdentifier *kafkalib.SourceIdentifier, source kafkalib.SourceNames) {
+	if sourceIdentifier == nil {
+		return
+	}
+
+	colName := sourceIdentifier.ColumnName()
+	value := sourceIdentifier.Identify(source)
+	if e.PrimaryKeyMap == nil {
+		e.PrimaryKeyMap = make(map[string]interface{})
+	}
+
+	e.PrimaryKeyMap[colName] = value
+	if e.Data != nil {
+		e.Data[colName] = value
+	}
+
+	if e.OptionalSchema != nil {
+		e.OptionalSchema[colName] = typing.String
+	}
+
+	if e.Columns != nil {
+		e.Columns.UpsertColumn(colName, columns.UpsertColumnArg{
+			PrimaryKey: ptr.ToBool(true),
+		})
+
+		if col, isOk := e.Columns.GetColumn(colName); isOk {
+			col.KindDetails = typing.String
+			e.Columns.UpdateColumn(col)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package event
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/typing"
+	"github.com/artie-labs/transfer/lib/typing/columns"
+)
+
+func (e *EventsTestSuite) TestEvent_AddSourceIdentifier() {
+	var cols columns.Columns
+	cols.AddColumn(columns.NewColumn("id", typing.Integer))
+
+	evt := Event{
+		Table:          "orders",
+		PrimaryKeyMap:  map[string]interface{}{"id": 1},
+		OptionalSchema: map[string]typing.KindDetails{"id": typing.Integer},
+		Columns:        &cols,
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: false,
+			"id":                         1,
+		},
+	}
+
+	evt.AddSourceIdentifier(nil, kafkalib.SourceNames{Database: "shard_07"})
+	assert.Equal(e.T(), "id=1", evt.PrimaryKeyValue())
+
+	evt.AddSourceIdentifier(&kafkalib.SourceIdentifier{Column: "Shard", Kind: kafkalib.SourceIdentifierDatabase}, kafkalib.SourceNames{Database: "shard_07"})
+	assert.Equal(e.T(), "id=1shard=shard_07", evt.PrimaryKeyValue())
+	assert.Equal(e.T(), []string{"id", "shard"}, evt.PrimaryKeys())
+	assert.Equal(e.T(), "shard_07", evt.Data["shard"])
+	assert.Equal(e.T(), typing.String, evt.OptionalSchema["shard"])
+
+	col, isOk := evt.Columns.GetColumn("shard")
+	assert.True(e.T(), isOk)
+	assert.Equal(e.T(), typing.String, col.KindDetails)
+}
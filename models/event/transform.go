@@ -0,0 +1,65 @@
+package event
+
+import (
+	"fmt"
+
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/typing"
+)
+
+func findTransform(transforms []kafkalib.ColumnTransform, colName string) (kafkalib.ColumnTransform, bool) {
+	for _, transform := range transforms {
+		if transform.Matches(colName) {
+			return transform, true
+		}
+	}
+
+	return kafkalib.ColumnTransform{}, false
+}
+
+// applyColumnTransforms will transform the event's values in place, this has to happen before the row is buffered.
+// Primary keys can only be hashed, that way the transformed value is still unique and will match the row that's already in the destination.
+func (e *Event) applyColumnTransforms(transforms []kafkalib.ColumnTransform) error {
+	if len(transforms) == 0 {
+		return nil
+	}
+
+	for pk, val := range e.PrimaryKeyMap {
+		transform, isOk := findTransform(transforms, pk)
+		if !isOk {
+			continue
+		}
+
+		if transform.Kind != kafkalib.TransformHash {
+			return fmt.Errorf("primary key: %s can only be hashed, transform: %s", pk, transform.Kind)
+		}
+
+		e.PrimaryKeyMap[pk] = transform.Apply(val)
+	}
+
+	for colName, val := range e.Data {
+		transform, isOk := findTransform(transforms, colName)
+		if !isOk {
+			continue
+		}
+
+		e.Data[colName] = transform.Apply(val)
+		if !transform.ChangesType() {
+			continue
+		}
+
+		// The column's type from the source no longer applies.
+		if _, isOk = e.OptionalSchema[colName]; isOk {
+			e.OptionalSchema[colName] = typing.String
+		}
+
+		if e.Columns != nil {
+			if col, isOk := e.Columns.GetColumn(colName); isOk {
+				col.KindDetails = typing.String
+				e.Columns.UpdateColumn(col)
+			}
+		}
+	}
+
+	return nil
+}
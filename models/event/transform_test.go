@@ -0,0 +1,124 @@
+package event
+
+import (
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/typing"
+	"github.com/artie-labs/transfer/lib/typing/columns"
+	"github.com/artie-labs/transfer/models"
+)
+
+func (e *EventsTestSuite) TestSaveEvent_ColumnTransforms() {
+	tc := *topicConfig
+	tc.ColumnTransforms = []kafkalib.ColumnTransform{
+		{Column: "email", Kind: kafkalib.TransformHash, Salt: "pepper"},
+		{Column: "ssn", Kind: kafkalib.TransformMask},
+		{Column: "token", Kind: kafkalib.TransformNullify},
+		{Column: "Name", Kind: kafkalib.TransformTruncate, Length: 2},
+	}
+
+	var cols columns.Columns
+	cols.AddColumn(columns.NewColumn("email", typing.String))
+	cols.AddColumn(columns.NewColumn("ssn", typing.Integer))
+
+	evt := Event{
+		Table:          "transforms",
+		PrimaryKeyMap:  map[string]interface{}{"email": "robin@artie.so"},
+		OptionalSchema: map[string]typing.KindDetails{"ssn": typing.Integer},
+		Columns:        &cols,
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: false,
+			"email":                      "robin@artie.so",
+			"ssn":                        123456789,
+			"token":                      "secret",
+			"name":                       "robin",
+			"age":                        30,
+		},
+	}
+
+	kafkaMsg := kafka.Message{}
+	_, _, err := evt.Save(e.ctx, &tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.NoError(e.T(), err)
+
+	hashedEmail := tc.ColumnTransforms[0].Apply("robin@artie.so")
+	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("transforms"))
+	// The primary key is hashed the same way as the column, so the row will still match within MERGE.
+	row, isOk := td.RowsData()["email="+hashedEmail.(string)]
+	assert.True(e.T(), isOk)
+	assert.Equal(e.T(), hashedEmail, row["email"])
+	assert.Equal(e.T(), kafkalib.DefaultMask, row["ssn"])
+	assert.Nil(e.T(), row["token"])
+	assert.Equal(e.T(), "ro", row["name"])
+	assert.Equal(e.T(), 30, row["age"])
+
+	// Masked columns are no longer integers.
+	col, isOk := td.ReadOnlyInMemoryCols().GetColumn("ssn")
+	assert.True(e.T(), isOk)
+	assert.Equal(e.T(), typing.String, col.KindDetails)
+}
+
+func (e *EventsTestSuite) TestSaveEvent_ColumnTransformsPrimaryKey() {
+	tc := *topicConfig
+	tc.ColumnTransforms = []kafkalib.ColumnTransform{{Column: "id", Kind: kafkalib.TransformMask}}
+
+	evt := Event{
+		Table:         "transforms",
+		PrimaryKeyMap: map[string]interface{}{"id": "123"},
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: false,
+			"id":                         "123",
+		},
+	}
+
+	kafkaMsg := kafka.Message{}
+	_, _, err := evt.Save(e.ctx, &tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.ErrorContains(e.T(), err, "primary key: id can only be hashed, transform: mask")
+}
+
+func (e *EventsTestSuite) TestSaveEvent_ColumnTransformsNumericPrimaryKey() {
+	tc := *topicConfig
+	tc.ColumnTransforms = []kafkalib.ColumnTransform{{Column: "id", Kind: kafkalib.TransformHash, Salt: "pepper"}}
+
+	// Keys are decoded from JSON, so the id is a float64. The delete's row is built from the primary keys.
+	deleteEvt := Event{
+		Table:         "transforms_numeric",
+		PrimaryKeyMap: map[string]interface{}{"id": 1000000.0},
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: true,
+			"id":                         1000000.0,
+		},
+	}
+
+	kafkaMsg := kafka.Message{}
+	_, _, err := deleteEvt.Save(e.ctx, &tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.NoError(e.T(), err)
+
+	hashedID := tc.ColumnTransforms[0].Apply(1000000)
+	td := models.GetMemoryDB(e.ctx).GetOrCreateTableData(tableKey("transforms_numeric"))
+	row, isOk := td.RowsData()["id="+hashedID.(string)]
+	assert.True(e.T(), isOk)
+	// This has to match the id that the row was inserted with, otherwise the delete won't match within MERGE.
+	assert.Equal(e.T(), hashedID, row["id"])
+
+	// Whereas the row's id is cast to an int when the value is parsed.
+	insertEvt := Event{
+		Table:         "transforms_numeric",
+		PrimaryKeyMap: map[string]interface{}{"id": 1000000.0},
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: false,
+			"id":                         1000000,
+		},
+	}
+
+	_, _, err = insertEvt.Save(e.ctx, &tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.NoError(e.T(), err)
+	assert.Len(e.T(), td.RowsData(), 1)
+	row, isOk = td.RowsData()["id="+hashedID.(string)]
+	assert.True(e.T(), isOk)
+	assert.Equal(e.T(), hashedID, row["id"])
+	assert.Equal(e.T(), false, row[constants.DeleteColumnMarker])
+}
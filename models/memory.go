@@ -2,9 +2,13 @@ package models
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/artie-labs/transfer/lib/artie"
 	"github.com/artie-labs/transfer/lib/logger"
 	"github.com/artie-labs/transfer/lib/optimization"
 )
@@ -16,15 +20,102 @@ const dbKey = "__db"
 // We did this because certain operations require different locking patterns
 type TableData struct {
 	*optimization.TableData
+	// partialBatch is set aside when it has only been merged into some of its destinations, see SetPartialBatch.
+	partialBatch  *Batch
 	lastMergeTime time.Time
+	// lastError is the error from the last flush, it's cleared once a flush succeeds.
+	lastError error
+	// approxSize mirrors TableData.ApproxSize(), so it can be read without waiting for the lock during a merge.
+	approxSize atomic.Int64
+	// status is refreshed whenever the lock is released, so it can also be read without waiting for the lock, see CachedStatus.
+	status atomic.Pointer[statusSnapshot]
+	db     *DatabaseData
 	sync.Mutex
 }
 
+// Unlock refreshes the cached status before releasing the lock, since the table is only ever changed while holding it.
+func (t *TableData) Unlock() {
+	t.status.Store(t.snapshotStatus())
+	t.Mutex.Unlock()
+}
+
+// InsertRow wraps optimization.TableData.InsertRow, so that the size of the rows is also accounted for within the database.
+func (t *TableData) InsertRow(pk string, rowData map[string]interface{}, delete bool) {
+	prevSize := t.TableData.ApproxSize()
+	t.TableData.InsertRow(pk, rowData, delete)
+	t.addSize(int64(t.TableData.ApproxSize() - prevSize))
+}
+
+func (t *TableData) addSize(delta int64) {
+	t.approxSize.Add(delta)
+	if t.db != nil {
+		t.db.approxSize.Add(delta)
+	}
+}
+
 func (t *TableData) Wipe() {
+	t.addSize(-t.approxSize.Load())
 	t.TableData = nil
+	t.partialBatch = nil
+	t.lastMergeTime = time.Now()
+}
+
+// Batch is the rows that are merged together, along with the destinations that they have been merged into.
+type Batch struct {
+	*optimization.TableData
+	Merged map[string]bool
+}
+
+// PartialBatch returns the batch that has only been merged into some of its destinations, if there is one.
+func (t *TableData) PartialBatch() *Batch {
+	return t.partialBatch
+}
+
+// SetPartialBatch sets the batch aside, so that the retry will only merge it into the destinations that it has not been merged into.
+// If it's the batch that is being buffered into, new rows will be buffered into a new batch, since the destinations that have succeeded
+// would otherwise never receive them.
+func (t *TableData) SetPartialBatch(batch *Batch) {
+	if batch.TableData == t.TableData {
+		t.TableData = nil
+	}
+
+	t.partialBatch = batch
+}
+
+// ClearPartialBatch is called once the partial batch has been merged into every destination and its offsets have been committed.
+func (t *TableData) ClearPartialBatch() {
+	if t.partialBatch == nil {
+		return
+	}
+
+	t.addSize(-int64(t.partialBatch.ApproxSize()))
+	t.partialBatch = nil
 	t.lastMergeTime = time.Now()
 }
 
+// Buffered returns whether there are any rows in memory, including the partial batch.
+func (t *TableData) Buffered() bool {
+	return !t.Empty() || t.partialBatch != nil
+}
+
+// batches returns the partial batch followed by the batch that is being buffered into, skipping the ones that do not exist.
+func (t *TableData) batches() []*optimization.TableData {
+	var batches []*optimization.TableData
+	if t.partialBatch != nil {
+		batches = append(batches, t.partialBatch.TableData)
+	}
+
+	if !t.Empty() {
+		batches = append(batches, t.TableData)
+	}
+
+	return batches
+}
+
+func (t *TableData) SetLastError(err error) {
+	t.lastError = err
+}
+
 // ShouldSkipMerge - this function is only used when the flush reason was time-based.
 // We want to add this in so that it can strike a balance between the Flush and Consumer go-routines on when to merge.
 // Say our flush interval is 5 mins and it flushed 4 mins ago based on size or rows - we don't want to flush right after since the buffer would be mostly empty.
@@ -50,6 +141,8 @@ func (t *TableData) SetTableData(td *optimization.TableData) {
 
 type DatabaseData struct {
 	tableData map[string]*TableData
+	// approxSize is the approximate size in bytes of every row that is buffered across all the tables.
+	approxSize atomic.Int64
 	sync.RWMutex
 }
 
@@ -74,27 +167,204 @@ func GetMemoryDB(ctx context.Context) *DatabaseData {
 	return db
 }
 
-func (d *DatabaseData) GetOrCreateTableData(tableName string) *TableData {
+// TableKey returns the key that a table is buffered under. Tables are identified by their database, schema and name,
+// so that tables sharing a name across databases or schemas are kept apart.
+func TableKey(database, schema, table string) string {
+	return fmt.Sprintf("%s.%s.%s", database, schema, table)
+}
+
+func (d *DatabaseData) GetOrCreateTableData(tableKey string) *TableData {
 	d.Lock()
 	defer d.Unlock()
 
-	table, exists := d.tableData[tableName]
+	table, exists := d.tableData[tableKey]
 	if !exists {
 		table = &TableData{
 			Mutex: sync.Mutex{},
+			db:    d,
 		}
-		d.tableData[tableName] = table
+		d.tableData[tableKey] = table
 	}
 
 	return table
 }
 
-func (d *DatabaseData) ClearTableConfig(tableName string) {
+func (d *DatabaseData) ClearTableConfig(tableKey string) {
 	d.Lock()
 	defer d.Unlock()
-	d.tableData[tableName].Wipe()
+	d.tableData[tableKey].Wipe()
 }
 
 func (d *DatabaseData) TableData() map[string]*TableData {
 	return d.tableData
 }
+
+// ApproxSize returns the approximate size in bytes of every row that is buffered in memory.
+func (d *DatabaseData) ApproxSize() int {
+	return int(d.approxSize.Load())
+}
+
+// TablesBySize returns the keys of the tables that have rows buffered, largest first.
+func (d *DatabaseData) TablesBySize() []string {
+	d.RLock()
+	defer d.RUnlock()
+
+	sizes := make(map[string]int64)
+	var tableKeys []string
+	for tableKey, table := range d.tableData {
+		if size := table.approxSize.Load(); size > 0 {
+			sizes[tableKey] = size
+			tableKeys = append(tableKeys, tableKey)
+		}
+	}
+
+	sort.Slice(tableKeys, func(i, j int) bool {
+		if sizes[tableKeys[i]] == sizes[tableKeys[j]] {
+			return tableKeys[i] < tableKeys[j]
+		}
+
+		return sizes[tableKeys[i]] > sizes[tableKeys[j]]
+	})
+
+	return tableKeys
+}
+
+// AttachMessage attaches a message that was not buffered (e.g. it was dead-lettered) to a table that is buffering messages from the
+// same partition, so that it's committed (or acked) along with the rows that came before it. This returns false if no table is
+// buffering messages from the partition, in which case there is nothing for the message to wait on.
+func (d *DatabaseData) AttachMessage(message artie.Message) bool {
+	d.RLock()
+	tables := make([]*TableData, 0, len(d.tableData))
+	for _, table := range d.tableData {
+		tables = append(tables, table)
+	}
+	d.RUnlock()
+
+	for _, table := range tables {
+		table.Lock()
+		attached := table.attachMessage(message)
+		table.Unlock()
+		if attached {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *TableData) attachMessage(message artie.Message) bool {
+	batches := t.batches()
+	// The latest batch is committed last, so the message is attached to it.
+	for idx := len(batches) - 1; idx >= 0; idx-- {
+		partitionsToLastMessage := batches[idx].PartitionsToLastMessage
+		if _, isOk := partitionsToLastMessage[message.PartitionKey()]; !isOk {
+			continue
+		}
+
+		if message.Kind() != artie.PubSub {
+			partitionsToLastMessage[message.PartitionKey()] = []artie.Message{message}
+		} else {
+			partitionsToLastMessage[message.PartitionKey()] = append(partitionsToLastMessage[message.PartitionKey()], message.AckHandle())
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// TableStatus is a point-in-time view of a table that we are buffering.
+type TableStatus struct {
+	Key             string     `json:"key"`
+	Rows            uint       `json:"rows"`
+	ApproxSizeBytes int        `json:"approxSizeBytes"`
+	LastMergeTime   *time.Time `json:"lastMergeTime,omitempty"`
+	LastError       string     `json:"lastError,omitempty"`
+	// LagMs is how far behind the furthest behind partition is, based on the publish time of its latest buffered message.
+	LagMs int64 `json:"lagMs"`
+}
+
+// statusSnapshot is the part of the table's status that has to be read while holding the lock.
+type statusSnapshot struct {
+	rows          uint
+	lastMergeTime time.Time
+	lastError     string
+	// oldestPublishTime is the publish time of the latest buffered message from the partition that is furthest behind.
+	oldestPublishTime time.Time
+}
+
+func (t *TableData) snapshotStatus() *statusSnapshot {
+	snapshot := &statusSnapshot{lastMergeTime: t.lastMergeTime}
+	if t.lastError != nil {
+		snapshot.lastError = t.lastError.Error()
+	}
+
+	for _, batch := range t.batches() {
+		snapshot.rows += batch.Rows()
+		for _, msgs := range batch.PartitionsToLastMessage {
+			if len(msgs) == 0 {
+				continue
+			}
+
+			publishTime := msgs[len(msgs)-1].PublishTime()
+			if snapshot.oldestPublishTime.IsZero() || publishTime.Before(snapshot.oldestPublishTime) {
+				snapshot.oldestPublishTime = publishTime
+			}
+		}
+	}
+
+	return snapshot
+}
+
+func (s *statusSnapshot) toStatus(tableKey string, approxSize int64) TableStatus {
+	status := TableStatus{
+		Key:             tableKey,
+		Rows:            s.rows,
+		ApproxSizeBytes: int(approxSize),
+		LastError:       s.lastError,
+	}
+
+	if !s.lastMergeTime.IsZero() {
+		lastMergeTime := s.lastMergeTime
+		status.LastMergeTime = &lastMergeTime
+	}
+
+	if !s.oldestPublishTime.IsZero() {
+		status.LagMs = time.Since(s.oldestPublishTime).Milliseconds()
+	}
+
+	return status
+}
+
+// Status returns the status of the table, callers are expected to hold the lock.
+func (t *TableData) Status(tableKey string) TableStatus {
+	return t.snapshotStatus().toStatus(tableKey, t.approxSize.Load())
+}
+
+// CachedStatus returns the status of the table as of the last time that its lock was released, this does not wait for the lock.
+// The size and the lag are always current, whereas the rows and the last error are not updated until an ongoing merge is done.
+func (t *TableData) CachedStatus(tableKey string) TableStatus {
+	snapshot := t.status.Load()
+	if snapshot == nil {
+		snapshot = &statusSnapshot{}
+	}
+
+	return snapshot.toStatus(tableKey, t.approxSize.Load())
+}
+
+// TableStatuses returns the cached status of every table sorted by key, so it does not wait on tables that are being merged.
+func (d *DatabaseData) TableStatuses() []TableStatus {
+	d.RLock()
+	defer d.RUnlock()
+
+	statuses := make([]TableStatus, 0, len(d.tableData))
+	for tableKey, table := range d.tableData {
+		statuses = append(statuses, table.CachedStatus(tableKey))
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Key < statuses[j].Key
+	})
+
+	return statuses
+}
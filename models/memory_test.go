@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 
+	"github.com/artie-labs/transfer/lib/kafkalib"
 	"github.com/artie-labs/transfer/lib/optimization"
 
 	"github.com/stretchr/testify/assert"
@@ -39,3 +40,62 @@ func (m *ModelsTestSuite) TestTableData_Complete() {
 	db.ClearTableConfig(tableName)
 	assert.True(m.T(), td.Empty())
 }
+
+func (m *ModelsTestSuite) TestDatabaseData_ApproxSize() {
+	db := GetMemoryDB(m.ctx)
+	assert.Equal(m.T(), 0, db.ApproxSize())
+	assert.Empty(m.T(), db.TablesBySize())
+
+	small := db.GetOrCreateTableData("small")
+	small.SetTableData(optimization.NewTableData(nil, []string{"id"}, kafkalib.TopicConfig{}, "small"))
+	small.InsertRow("1", map[string]interface{}{"id": "1"}, false)
+
+	large := db.GetOrCreateTableData("large")
+	large.SetTableData(optimization.NewTableData(nil, []string{"id"}, kafkalib.TopicConfig{}, "large"))
+	large.InsertRow("1", map[string]interface{}{"id": "1", "name": "the quick brown fox jumps over the lazy dog"}, false)
+
+	assert.Equal(m.T(), small.ApproxSize()+large.ApproxSize(), db.ApproxSize())
+	assert.Equal(m.T(), []string{"large", "small"}, db.TablesBySize())
+
+	// Updating a row should replace its size rather than adding to it.
+	large.InsertRow("1", map[string]interface{}{"id": "1"}, false)
+	assert.Equal(m.T(), 2*small.ApproxSize(), db.ApproxSize())
+
+	db.ClearTableConfig("large")
+	assert.Equal(m.T(), small.ApproxSize(), db.ApproxSize())
+	assert.Equal(m.T(), []string{"small"}, db.TablesBySize())
+
+	small.Wipe()
+	assert.Equal(m.T(), 0, db.ApproxSize())
+}
+
+func (m *ModelsTestSuite) TestTableData_PartialBatch() {
+	db := GetMemoryDB(m.ctx)
+	td := db.GetOrCreateTableData("partial")
+	td.SetTableData(optimization.NewTableData(nil, []string{"id"}, kafkalib.TopicConfig{}, "partial"))
+	td.InsertRow("1", map[string]interface{}{"id": "1"}, false)
+	rowSize := td.ApproxSize()
+
+	// Setting the batch aside means that new rows will be buffered into a new batch.
+	td.SetPartialBatch(&Batch{TableData: td.TableData, Merged: map[string]bool{"analytics": true}})
+	assert.True(m.T(), td.Empty())
+	assert.True(m.T(), td.Buffered())
+	assert.Equal(m.T(), uint(1), td.Status("partial").Rows)
+
+	td.SetTableData(optimization.NewTableData(nil, []string{"id"}, kafkalib.TopicConfig{}, "partial"))
+	td.InsertRow("1", map[string]interface{}{"id": "1"}, false)
+	assert.Equal(m.T(), uint(2), td.Status("partial").Rows)
+	assert.Equal(m.T(), 2*rowSize, td.Status("partial").ApproxSizeBytes)
+	assert.Equal(m.T(), 2*rowSize, db.ApproxSize())
+
+	td.ClearPartialBatch()
+	assert.Nil(m.T(), td.PartialBatch())
+	assert.False(m.T(), td.Empty())
+	assert.Equal(m.T(), rowSize, db.ApproxSize())
+
+	// Wiping the table also discards the partial batch.
+	td.SetPartialBatch(&Batch{TableData: td.TableData})
+	db.ClearTableConfig("partial")
+	assert.False(m.T(), td.Buffered())
+	assert.Equal(m.T(), 0, db.ApproxSize())
+}
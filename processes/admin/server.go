@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/destination"
+	"github.com/artie-labs/transfer/lib/destination/utils"
+	"github.com/artie-labs/transfer/lib/logger"
+	"github.com/artie-labs/transfer/models"
+	"github.com/artie-labs/transfer/processes/consumer"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+// StartServer will serve the admin endpoints until ctx is done.
+func StartServer(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	settings := config.FromContext(ctx)
+	log.Info("Starting admin server...", settings.Config.Admin)
+
+	server := &http.Server{
+		Addr:              settings.Config.Admin.Address,
+		Handler:           newHandler(ctx),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.WithError(err).Warn("failed to shut down admin server")
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.WithError(err).Fatal("failed to start admin server")
+	}
+}
+
+// newHandler returns the admin endpoints. Flushes are run with ctx rather than the request's context,
+// since ctx carries the settings, destination and the in-memory database.
+func newHandler(ctx context.Context) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := ready(ctx); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not_ready", "error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	})
+
+	mux.HandleFunc("/tables", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, models.GetMemoryDB(ctx).TableStatuses())
+	})
+
+	if token := config.FromContext(ctx).Config.Admin.Token; token != "" {
+		mux.Handle("/flush", authenticate(token, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			flush(ctx, w, r)
+		})))
+	}
+
+	return mux
+}
+
+// ready returns an error if we cannot reach the destination or if the consumer has not been assigned anything to read.
+func ready(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("shutting down")
+	}
+
+	for _, dest := range utils.DestinationsFromContext(ctx) {
+		if dwh, isOk := dest.Baseline.(destination.DataWarehouse); isOk {
+			if _, err := dwh.Exec("SELECT 1"); err != nil {
+				if dest.Name != "" {
+					return fmt.Errorf("failed to reach destination: %s, err: %v", dest.Name, err)
+				}
+
+				return fmt.Errorf("failed to reach destination, err: %v", err)
+			}
+		}
+	}
+
+	if !consumer.Assigned() {
+		return fmt.Errorf("consumer has not been assigned")
+	}
+
+	return nil
+}
+
+func authenticate(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// flush will flush the table passed in via `?table=<key>` or every table if it's not set, and return the tables' status afterwards.
+// This responds with a 500 if any of the tables failed to flush, their status has the error.
+func flush(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	tableKey := r.URL.Query().Get("table")
+	if tableKey != "" && !hasTable(ctx, tableKey) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("table: %s does not exist", tableKey)})
+		return
+	}
+
+	if err := consumer.Flush(consumer.Args{Context: ctx, SpecificTable: tableKey, Reason: "admin"}); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	statusCode := http.StatusOK
+	var statuses []models.TableStatus
+	for _, status := range models.GetMemoryDB(ctx).TableStatuses() {
+		if tableKey == "" || status.Key == tableKey {
+			statuses = append(statuses, status)
+			if status.LastError != "" {
+				statusCode = http.StatusInternalServerError
+			}
+		}
+	}
+
+	writeJSON(w, statusCode, statuses)
+}
+
+func hasTable(ctx context.Context, tableKey string) bool {
+	db := models.GetMemoryDB(ctx)
+	db.RLock()
+	defer db.RUnlock()
+	_, isOk := db.TableData()[tableKey]
+	return isOk
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/db"
+	"github.com/artie-labs/transfer/lib/destination/utils"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/mocks"
+	"github.com/artie-labs/transfer/models"
+	"github.com/artie-labs/transfer/models/event"
+	"github.com/artie-labs/transfer/processes/consumer"
+)
+
+var topicConfig = &kafkalib.TopicConfig{
+	Database:  "customer",
+	Schema:    "public",
+	Topic:     "foo",
+	CDCFormat: constants.DBZPostgresFormat,
+}
+
+func newTestContext(t *testing.T, fakeStore *mocks.FakeStore, token string) context.Context {
+	ctx := config.InjectSettingsIntoContext(context.Background(), &config.Settings{
+		Config: &config.Config{
+			Queue: constants.Kafka,
+			Kafka: &config.Kafka{
+				BootstrapServer: "foo",
+				GroupID:         "bar",
+				TopicConfigs:    []*kafkalib.TopicConfig{topicConfig},
+			},
+			Output:               constants.Snowflake,
+			BufferRows:           500,
+			FlushIntervalSeconds: 60,
+			FlushSizeKb:          500,
+			Admin:                &config.Admin{Address: ":0", Token: token},
+		},
+	})
+
+	store := db.Store(fakeStore)
+	ctx = utils.InjectDwhIntoCtx(utils.DataWarehouse(ctx, &store), ctx)
+	ctx = models.LoadMemoryDB(ctx)
+	consumer.SetKafkaConsumer(map[string]kafkalib.Consumer{topicConfig.Topic: &mocks.FakeConsumer{}})
+
+	for i := 0; i < 3; i++ {
+		evt := event.Event{
+			Table:         "orders",
+			PrimaryKeyMap: map[string]interface{}{"id": fmt.Sprint(i)},
+			Data: map[string]interface{}{
+				constants.DeleteColumnMarker: false,
+				"id":                         fmt.Sprint(i),
+			},
+		}
+
+		kafkaMsg := kafka.Message{Topic: topicConfig.Topic, Partition: 1, Offset: int64(i)}
+		_, _, err := evt.Save(ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+		assert.NoError(t, err)
+	}
+
+	return ctx
+}
+
+func serve(ctx context.Context, method, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	recorder := httptest.NewRecorder()
+	newHandler(ctx).ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	fakeStore := &mocks.FakeStore{}
+	ctx := newTestContext(t, fakeStore, "")
+
+	assert.Equal(t, http.StatusOK, serve(ctx, http.MethodGet, "/healthz", "").Code)
+
+	// The consumer has not been started.
+	resp := serve(ctx, http.MethodGet, "/readyz", "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Contains(t, resp.Body.String(), "consumer has not been assigned")
+
+	fakeStore.ExecReturns(nil, fmt.Errorf("connection refused"))
+	resp = serve(ctx, http.MethodGet, "/readyz", "")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Contains(t, resp.Body.String(), "failed to reach destination")
+}
+
+func TestTables(t *testing.T) {
+	ctx := newTestContext(t, &mocks.FakeStore{}, "")
+
+	resp := serve(ctx, http.MethodGet, "/tables", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var statuses []models.TableStatus
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &statuses))
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, "customer.public.orders", statuses[0].Key)
+	assert.Equal(t, uint(3), statuses[0].Rows)
+	assert.Greater(t, statuses[0].ApproxSizeBytes, 0)
+	assert.Nil(t, statuses[0].LastMergeTime)
+	assert.Empty(t, statuses[0].LastError)
+
+	// The status is read without waiting for tables that are being merged.
+	td := models.GetMemoryDB(ctx).GetOrCreateTableData("customer.public.orders")
+	td.Lock()
+	resp = serve(ctx, http.MethodGet, "/tables", "")
+	td.Unlock()
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"rows":3`)
+
+	// Flush endpoints are disabled without a token.
+	assert.Equal(t, http.StatusNotFound, serve(ctx, http.MethodPost, "/flush", "").Code)
+}
+
+func TestFlush(t *testing.T) {
+	fakeStore := &mocks.FakeStore{}
+	ctx := newTestContext(t, fakeStore, "secret")
+
+	assert.Equal(t, http.StatusUnauthorized, serve(ctx, http.MethodPost, "/flush", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(ctx, http.MethodPost, "/flush", "wrong").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(ctx, http.MethodGet, "/flush", "secret").Code)
+	assert.Equal(t, http.StatusNotFound, serve(ctx, http.MethodPost, "/flush?table=customer.public.missing", "secret").Code)
+
+	// A failed merge fails the request, and is reported on the table.
+	fakeStore.ExecReturns(nil, fmt.Errorf("merge failed"))
+	resp := serve(ctx, http.MethodPost, "/flush?table=customer.public.orders", "secret")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	var statuses []models.TableStatus
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &statuses))
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, uint(3), statuses[0].Rows)
+	assert.Contains(t, statuses[0].LastError, "failed to merge")
+
+	fakeStore.ExecReturns(nil, nil)
+	resp = serve(ctx, http.MethodPost, "/flush", "secret")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	statuses = nil
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &statuses))
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, uint(0), statuses[0].Rows)
+	assert.NotNil(t, statuses[0].LastMergeTime)
+	assert.Empty(t, statuses[0].LastError)
+}
@@ -2,11 +2,20 @@ package consumer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
 
 	"github.com/artie-labs/transfer/lib/artie"
 	"github.com/artie-labs/transfer/lib/cdc"
+	"github.com/artie-labs/transfer/lib/dlq"
+	"github.com/artie-labs/transfer/lib/jitter"
 	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/logger"
+	"github.com/artie-labs/transfer/models"
 )
 
 type TcFmtMap struct {
@@ -36,14 +45,73 @@ func (t *TcFmtMap) GetTopicFmt(topic string) (TopicConfigFormatter, bool) {
 type TopicConfigFormatter struct {
 	tc *kafkalib.TopicConfig
 	cdc.Format
+	// dlq is optional, and is only set if the topic config has a dead-letter queue.
+	dlq dlq.Writer
 }
 
-func commitOffset(ctx context.Context, topic string, partitionsToOffset map[string][]artie.Message) error {
+const (
+	deadLetterRetryIntervalMs  = 500
+	deadLetterMaxRetryInterval = 30 * time.Second
+)
+
+// errDeadLetterFailed is returned when the message could not be written to the dead-letter queue before ctx was done.
+// The message has neither been processed nor dead-lettered, so the consumer must not move past it.
+var errDeadLetterFailed = errors.New("failed to write to dead letter queue")
+
+// deadLetter will write the message into the dead-letter queue (if there is one) and return the original error back.
+// Once written, the message is attached to the table that is buffering rows from the same partition, so that it's committed or acked
+// along with them. If there isn't one, nothing before it is waiting to be flushed and it's committed right away.
+func (t TopicConfigFormatter) deadLetter(ctx context.Context, msg artie.Message, tags map[string]string, err error) error {
+	if t.dlq == nil {
+		return err
+	}
+
+	if dlqErr := t.writeDeadLetter(ctx, dlq.NewRecord(msg, err)); dlqErr != nil {
+		tags["dlq"] = "write_fail"
+		return fmt.Errorf("%v, %w, err: %v", err, errDeadLetterFailed, dlqErr)
+	}
+
+	tags["dlq"] = "written"
+	if !models.GetMemoryDB(ctx).AttachMessage(msg) {
+		// Failing to commit is fine, since the offset will be committed along with the messages that come after it.
+		if commitErr := commitOffset(ctx, map[string][]artie.Message{msg.PartitionKey(): {msg.AckHandle()}}); commitErr != nil {
+			logger.FromContext(ctx).WithError(commitErr).Warn("failed to commit dead lettered message")
+		}
+	}
+
+	return fmt.Errorf("%v, message was written to the dead letter queue", err)
+}
+
+// writeDeadLetter will keep retrying until the record has been written or ctx is done.
+// Moving on would let the offsets of the following messages be committed, which would drop this message.
+func (t TopicConfigFormatter) writeDeadLetter(ctx context.Context, record dlq.Record) error {
+	for attempts := 0; ; attempts++ {
+		err := t.dlq.Write(ctx, record)
+		if err == nil {
+			return nil
+		}
+
+		sleepDuration := time.Duration(jitter.JitterMs(deadLetterRetryIntervalMs, attempts)) * time.Millisecond
+		logger.FromContext(ctx).WithError(err).WithFields(map[string]interface{}{
+			"topic":           record.Topic,
+			"partition":       record.Partition,
+			"offset":          record.Offset,
+			"attempts":        attempts,
+			"sleepDurationMs": sleepDuration.Milliseconds(),
+		}).Warn("failed to write to dead letter queue, retrying...")
+
+		if !sleep(ctx, sleepDuration) {
+			return err
+		}
+	}
+}
+
+func commitOffset(ctx context.Context, partitionsToOffset map[string][]artie.Message) error {
 	var err error
 	for _, msgs := range partitionsToOffset {
 		for _, msg := range msgs {
 			if msg.KafkaMsg != nil {
-				err = topicToConsumer.Get(topic).CommitMessages(ctx, *msg.KafkaMsg)
+				err = topicToConsumer.Get(msg.KafkaMsg.Topic).CommitMessages(ctx, *msg.KafkaMsg)
 				if err != nil {
 					return err
 				}
@@ -52,8 +120,32 @@ func commitOffset(ctx context.Context, topic string, partitionsToOffset map[stri
 			if msg.PubSub != nil {
 				msg.PubSub.Ack()
 			}
+
+			if msg.Kinesis != nil {
+				err = kinesisCheckpoints.Set(msg.Kinesis.Stream, msg.Kinesis.ShardID, aws.ToString(msg.Kinesis.SequenceNumber))
+				if err != nil {
+					return err
+				}
+			}
 		}
 	}
 
 	return err
 }
+
+// nackMessages is called when the merge has failed. Pub/Sub messages are nacked so that they are redelivered right away, rather than
+// waiting for the ack deadline. It returns true if the messages were nacked, in which case the buffered rows should be dropped.
+// Other kinds are not nacked, their rows are kept in memory and retried on the next flush.
+func nackMessages(partitionsToMessages map[string][]artie.Message) bool {
+	var nacked bool
+	for _, msgs := range partitionsToMessages {
+		for _, msg := range msgs {
+			if msg.PubSub != nil {
+				msg.PubSub.Nack()
+				nacked = true
+			}
+		}
+	}
+
+	return nacked
+}
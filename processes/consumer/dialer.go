@@ -0,0 +1,60 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	awsCfg "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/aws_msk_iam_v2"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"github.com/artie-labs/transfer/lib/config"
+)
+
+// newDialer returns the dialer that is shared by the consumer group, the partition readers and the dead-letter queue writers.
+func newDialer(ctx context.Context, cfg *config.Kafka) (*kafka.Dialer, error) {
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+		TLS:       tlsConfig,
+	}
+
+	switch cfg.Mechanism() {
+	case config.SASLPlain:
+		dialer.SASLMechanism = plain.Mechanism{
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}
+	case config.SASLScramSHA256, config.SASLScramSHA512:
+		algorithm := scram.SHA256
+		if cfg.Mechanism() == config.SASLScramSHA512 {
+			algorithm = scram.SHA512
+		}
+
+		mechanism, err := scram.Mechanism(algorithm, cfg.Username, cfg.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create scram mechanism, err: %v", err)
+		}
+
+		dialer.SASLMechanism = mechanism
+	case config.SASLAWSMSKIAM:
+		// If using AWS MSK IAM, we expect this to be set in the ENV VAR
+		// (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, or the AWS Profile should be called default.)
+		awsConfig, err := awsCfg.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load aws configuration, err: %v", err)
+		}
+
+		dialer.SASLMechanism = aws_msk_iam_v2.NewMechanism(awsConfig)
+	}
+
+	return dialer, nil
+}
@@ -0,0 +1,45 @@
+package consumer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/config"
+)
+
+func TestNewDialer(t *testing.T) {
+	dialer, err := newDialer(context.Background(), &config.Kafka{})
+	assert.NoError(t, err)
+	assert.Nil(t, dialer.SASLMechanism)
+	assert.Nil(t, dialer.TLS)
+
+	dialer, err = newDialer(context.Background(), &config.Kafka{Username: "user", Password: "pass"})
+	assert.NoError(t, err)
+	assert.Equal(t, "PLAIN", dialer.SASLMechanism.Name())
+	assert.NotNil(t, dialer.TLS)
+
+	dialer, err = newDialer(context.Background(), &config.Kafka{
+		SASLMechanism: config.SASLScramSHA256,
+		Username:      "user",
+		Password:      "pass",
+		DisableTLS:    true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "SCRAM-SHA-256", dialer.SASLMechanism.Name())
+	assert.Nil(t, dialer.TLS)
+
+	dialer, err = newDialer(context.Background(), &config.Kafka{
+		SASLMechanism: config.SASLScramSHA512,
+		Username:      "user",
+		Password:      "pass",
+		TLS:           &config.KafkaTLS{ServerName: "kafka.internal"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "SCRAM-SHA-512", dialer.SASLMechanism.Name())
+	assert.Equal(t, "kafka.internal", dialer.TLS.ServerName)
+
+	_, err = newDialer(context.Background(), &config.Kafka{TLS: &config.KafkaTLS{CAFile: "/does/not/exist.pem"}})
+	assert.ErrorContains(t, err, "failed to read ca file")
+}
@@ -0,0 +1,84 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+
+	gcp_pubsub "cloud.google.com/go/pubsub"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/artie-labs/transfer/lib/dlq"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/logger"
+)
+
+type deadLetterArgs struct {
+	// Kafka
+	Brokers []string
+	Dialer  *kafka.Dialer
+
+	// Pub/Sub
+	PubSubClient *gcp_pubsub.Client
+}
+
+// deadLetterWriters are closed by Shutdown, once the final flush is done.
+var deadLetterWriters struct {
+	writers []dlq.Writer
+	sync.Mutex
+}
+
+func addDeadLetterWriter(writer dlq.Writer) dlq.Writer {
+	deadLetterWriters.Lock()
+	defer deadLetterWriters.Unlock()
+	deadLetterWriters.writers = append(deadLetterWriters.writers, writer)
+	return writer
+}
+
+// closeDeadLetterWriters flushes and closes every dead-letter queue writer that has been loaded.
+func closeDeadLetterWriters() error {
+	deadLetterWriters.Lock()
+	defer deadLetterWriters.Unlock()
+
+	var err error
+	for _, writer := range deadLetterWriters.writers {
+		if closeErr := writer.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+
+	deadLetterWriters.writers = nil
+	return err
+}
+
+// loadDeadLetterWriter will return nil if the topic config does not have a dead-letter queue configured.
+func loadDeadLetterWriter(ctx context.Context, tc *kafkalib.TopicConfig, args deadLetterArgs) dlq.Writer {
+	if tc.DeadLetterQueue == nil {
+		return nil
+	}
+
+	log := logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"topic": tc.Topic,
+		"kind":  tc.DeadLetterQueue.Kind,
+	})
+
+	switch tc.DeadLetterQueue.Kind {
+	case kafkalib.DeadLetterQueueKafka:
+		if len(args.Brokers) > 0 {
+			return addDeadLetterWriter(dlq.NewKafkaWriter(args.Brokers, tc.DeadLetterQueue.Topic, args.Dialer))
+		}
+	case kafkalib.DeadLetterQueuePubSub:
+		if args.PubSubClient != nil {
+			return addDeadLetterWriter(dlq.NewPubSubWriter(args.PubSubClient, tc.DeadLetterQueue.Topic))
+		}
+	case kafkalib.DeadLetterQueueFile:
+		writer, err := dlq.NewFileWriter(tc.DeadLetterQueue.Path)
+		if err != nil {
+			log.WithError(err).Fatal("failed to load dead letter queue")
+		}
+
+		return addDeadLetterWriter(writer)
+	}
+
+	log.Fatal("dead letter queue kind is not supported for this message queue")
+	return nil
+}
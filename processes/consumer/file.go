@@ -0,0 +1,306 @@
+package consumer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/cdc/format"
+	"github.com/artie-labs/transfer/lib/checkpoint"
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/dlq"
+	"github.com/artie-labs/transfer/lib/logger"
+	"github.com/artie-labs/transfer/models"
+)
+
+const (
+	fileGroupID = "transfer_file"
+	// Files are replayed serially, so the checkpoint is the number of lines that have been flushed.
+	fileCheckpointPartition = "lines"
+)
+
+// StartFileConsumer will replay every file and return once they have all been flushed, or when ctx is done.
+func StartFileConsumer(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	settings := config.FromContext(ctx)
+	log.Info("Starting file consumer...", settings.Config.File)
+
+	store, err := checkpoint.NewFileStore(settings.Config.File.CheckpointPath)
+	if err != nil {
+		log.WithError(err).Fatal("failed to load file checkpoints")
+	}
+
+	tcFmtMap := NewTcFmtMap()
+	for _, topicConfig := range settings.Config.File.TopicConfigs {
+		tcFmtMap.Add(topicConfig.Topic, TopicConfigFormatter{
+			tc:     topicConfig,
+			Format: format.GetFormatParser(ctx, topicConfig),
+			dlq:    loadDeadLetterWriter(ctx, topicConfig, deadLetterArgs{}),
+		})
+	}
+
+	paths, err := listFiles(settings.Config.File.Paths)
+	if err != nil {
+		log.WithError(err).Fatal("failed to list files")
+	}
+
+	assigned.Store(true)
+	r := &replayer{checkpoints: store, tcFmtMap: tcFmtMap}
+	for _, path := range paths {
+		if err = r.replayFile(ctx, path); err != nil {
+			log.WithError(err).WithField("path", path).Fatal("failed to replay file")
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+
+	log.WithField("files", len(paths)).Info("Finished replaying files")
+}
+
+// listFiles expands the directories within paths, the files will be returned as absolute paths.
+func listFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var dirFiles []string
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+				dirFiles = append(dirFiles, filepath.Join(path, entry.Name()))
+			}
+		}
+
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+
+	for i, file := range files {
+		absPath, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+
+		files[i] = absPath
+	}
+
+	return files, nil
+}
+
+type replayer struct {
+	checkpoints *checkpoint.FileStore
+	tcFmtMap    *TcFmtMap
+}
+
+// replayFile processes the file from its checkpoint. The file is checkpointed whenever a flush leaves nothing in memory, and once we
+// reach the end of the file, everything is flushed and the file is checkpointed. If ctx is done before then, the file will be
+// replayed again from its last checkpoint.
+func (r *replayer) replayFile(ctx context.Context, path string) error {
+	log := logger.FromContext(ctx)
+	var skip int
+	if position, isOk := r.checkpoints.Get(path, fileCheckpointPartition); isOk {
+		var err error
+		if skip, err = strconv.Atoi(position); err != nil {
+			return fmt.Errorf("failed to parse checkpoint: %s, err: %v", position, err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var line int
+	lastFlushCount := flushCount.Load()
+	for ctx.Err() == nil {
+		lineBytes, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("failed to read line: %d, err: %v", line+1, readErr)
+		}
+
+		if len(lineBytes) > 0 {
+			line += 1
+			lineBytes = bytes.TrimSpace(lineBytes)
+			if line > skip && len(lineBytes) > 0 {
+				fileRecord, parseErr := parseFileRecord(path, line, lineBytes)
+				if parseErr != nil {
+					return fmt.Errorf("failed to parse line: %d, err: %v", line, parseErr)
+				}
+
+				msg := artie.NewFileMessage(fileRecord)
+
+				// We are not emitting ingestion lag here since the records may be arbitrarily old.
+				_, processErr := processMessage(ctx, ProcessArgs{
+					Msg:                    msg,
+					GroupID:                fileGroupID,
+					TopicToConfigFormatMap: r.tcFmtMap,
+				})
+
+				if processErr != nil {
+					if errors.Is(processErr, errDeadLetterFailed) {
+						// The line is not checkpointed, so the file will be replayed from its last checkpoint.
+						return nil
+					}
+
+					log.WithError(processErr).WithFields(map[string]interface{}{
+						"path":  path,
+						"line":  line,
+						"topic": msg.Topic(),
+					}).Warn("skipping message...")
+				}
+
+				// Once everything has been flushed, the lines so far are safe to checkpoint. This way, we don't start over if we crash midway.
+				if flushes := flushCount.Load(); flushes != lastFlushCount {
+					lastFlushCount = flushes
+					if len(bufferedTables(ctx)) == 0 {
+						if err = r.checkpoints.Set(path, fileCheckpointPartition, fmt.Sprint(line)); err != nil {
+							return fmt.Errorf("failed to checkpoint line: %d, err: %v", line, err)
+						}
+					}
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if ctx.Err() != nil || line <= skip {
+		return nil
+	}
+
+	if err = Flush(Args{Context: ctx, Reason: "file_replayed"}); err != nil {
+		return fmt.Errorf("failed to flush, err: %v", err)
+	}
+
+	// Flush will keep the rows in memory if the merge has failed, we cannot checkpoint the file then.
+	if tableKeys := bufferedTables(ctx); len(tableKeys) > 0 {
+		return fmt.Errorf("failed to flush tables: %v", tableKeys)
+	}
+
+	return r.checkpoints.Set(path, fileCheckpointPartition, fmt.Sprint(line))
+}
+
+// rawFileRecord is a line within the file. It can either be a record from our dead-letter queue, where the encoding is base64 and
+// the key and value are base64 encoded strings, or a plain JSON CDC record without an encoding, where the key and value are used as is.
+type rawFileRecord struct {
+	Key       json.RawMessage   `json:"key"`
+	Value     json.RawMessage   `json:"value"`
+	Headers   map[string]string `json:"headers"`
+	Topic     string            `json:"topic"`
+	Partition string            `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+	Encoding  string            `json:"encoding"`
+}
+
+func parseFileRecord(path string, line int, lineBytes []byte) (artie.FileRecord, error) {
+	var record rawFileRecord
+	if err := json.Unmarshal(lineBytes, &record); err != nil {
+		return artie.FileRecord{}, err
+	}
+
+	switch record.Encoding {
+	case "", dlq.EncodingBase64:
+	default:
+		return artie.FileRecord{}, fmt.Errorf("encoding: %s is not supported", record.Encoding)
+	}
+
+	key, err := decodeFileRecordField(record.Key, record.Encoding)
+	if err != nil {
+		return artie.FileRecord{}, fmt.Errorf("failed to decode key, err: %v", err)
+	}
+
+	value, err := decodeFileRecordField(record.Value, record.Encoding)
+	if err != nil {
+		return artie.FileRecord{}, fmt.Errorf("failed to decode value, err: %v", err)
+	}
+
+	return artie.FileRecord{
+		Path:      path,
+		Line:      line,
+		Topic:     record.Topic,
+		Partition: record.Partition,
+		Offset:    record.Offset,
+		Key:       key,
+		Value:     value,
+		Headers:   record.Headers,
+		Timestamp: record.Timestamp,
+	}, nil
+}
+
+// decodeFileRecordField returns the bytes of the key or value. If the record is base64 encoded, the field has to be a base64 encoded string.
+// Otherwise, strings are used as is (e.g. a `Struct{id=1}` key) and anything else is raw JSON.
+func decodeFileRecordField(raw json.RawMessage, encoding string) ([]byte, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	if raw[0] != '"' {
+		if encoding == dlq.EncodingBase64 {
+			return nil, fmt.Errorf("expected a base64 encoded string")
+		}
+
+		return raw, nil
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return nil, err
+	}
+
+	if encoding == dlq.EncodingBase64 {
+		return base64.StdEncoding.DecodeString(str)
+	}
+
+	return []byte(str), nil
+}
+
+// bufferedTables returns the tables that still have rows in memory.
+func bufferedTables(ctx context.Context) []string {
+	models.GetMemoryDB(ctx).RLock()
+	allTables := models.GetMemoryDB(ctx).TableData()
+	models.GetMemoryDB(ctx).RUnlock()
+
+	var tableKeys []string
+	for tableKey, tableData := range allTables {
+		tableData.Lock()
+		if tableData.Buffered() {
+			tableKeys = append(tableKeys, tableKey)
+		}
+		tableData.Unlock()
+	}
+
+	sort.Strings(tableKeys)
+	return tableKeys
+}
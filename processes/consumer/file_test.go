@@ -0,0 +1,209 @@
+package consumer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/cdc/mongo"
+	"github.com/artie-labs/transfer/lib/checkpoint"
+	"github.com/artie-labs/transfer/lib/dlq"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/models"
+)
+
+func TestListFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.jsonl", "a.jsonl", ".checkpoint.tmp"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0755))
+
+	other := filepath.Join(t.TempDir(), "other.jsonl")
+	assert.NoError(t, os.WriteFile(other, nil, 0644))
+
+	files, err := listFiles([]string{other, dir})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{other, filepath.Join(dir, "a.jsonl"), filepath.Join(dir, "b.jsonl")}, files)
+
+	_, err = listFiles([]string{filepath.Join(dir, "does_not_exist")})
+	assert.Error(t, err)
+}
+
+func fileRecordLine(t assert.TestingT, id int, name string) string {
+	bytes, err := json.Marshal(dlq.Record{
+		Encoding:  dlq.EncodingBase64,
+		Topic:     "replay",
+		Partition: "0",
+		Offset:    int64(id),
+		Key:       []byte(fmt.Sprintf("Struct{id=%d}", id)),
+		Value: []byte(fmt.Sprintf(`{
+	"schema": {},
+	"payload": {
+		"after": "{\"_id\": {\"$numberLong\": \"%d\"},\"name\": \"%s\"}",
+		"source": {"connector": "mongodb", "ts_ms": 1668753321000, "db": "inventory", "collection": "customers"},
+		"op": "c"
+	}
+}`, id, name)),
+	})
+	assert.NoError(t, err)
+	return string(bytes)
+}
+
+func (f *FlushTestSuite) TestReplayFile() {
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	tcFmtMap.Add("replay", TopicConfigFormatter{
+		tc: &kafkalib.TopicConfig{
+			Database:     "db",
+			Schema:       "public",
+			Topic:        "replay",
+			CDCKeyFormat: "org.apache.kafka.connect.storage.StringConverter",
+		},
+		Format: &mgo,
+	})
+
+	dir := f.T().TempDir()
+	store, err := checkpoint.NewFileStore(filepath.Join(dir, "checkpoints.json"))
+	assert.NoError(f.T(), err)
+	r := &replayer{checkpoints: store, tcFmtMap: tcFmtMap}
+
+	path := filepath.Join(dir, "replay.jsonl")
+	lines := []string{
+		fileRecordLine(f.T(), 1, "foo"),
+		"",
+		fileRecordLine(f.T(), 2, "bar"),
+	}
+	assert.NoError(f.T(), os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644))
+
+	assert.NoError(f.T(), r.replayFile(f.ctx, path))
+	assert.Empty(f.T(), bufferedTables(f.ctx))
+	assert.NotZero(f.T(), f.fakeStore.ExecCallCount(), "rows should be merged at the end of the file")
+
+	position, isOk := store.Get(path, fileCheckpointPartition)
+	assert.True(f.T(), isOk)
+	assert.Equal(f.T(), "3", position)
+
+	// Lines prior to the checkpoint are skipped, so a replay will only pick up what has been appended.
+	lines[0] = "not json"
+	lines = append(lines, fileRecordLine(f.T(), 3, "qux"))
+	assert.NoError(f.T(), os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+	execCount := f.fakeStore.ExecCallCount()
+	assert.NoError(f.T(), r.replayFile(f.ctx, path))
+	assert.Greater(f.T(), f.fakeStore.ExecCallCount(), execCount)
+
+	position, _ = store.Get(path, fileCheckpointPartition)
+	assert.Equal(f.T(), "4", position)
+
+	// Nothing new, so there's nothing to flush.
+	execCount = f.fakeStore.ExecCallCount()
+	assert.NoError(f.T(), r.replayFile(f.ctx, path))
+	assert.Equal(f.T(), execCount, f.fakeStore.ExecCallCount())
+
+	// Malformed lines will fail the replay and the file will not be checkpointed.
+	badPath := filepath.Join(dir, "bad.jsonl")
+	assert.NoError(f.T(), os.WriteFile(badPath, []byte(fileRecordLine(f.T(), 4, "quux")+"\nnot json\n"), 0644))
+	assert.ErrorContains(f.T(), r.replayFile(f.ctx, badPath), "failed to parse line: 2")
+	_, isOk = store.Get(badPath, fileCheckpointPartition)
+	assert.False(f.T(), isOk)
+	assert.Equal(f.T(), []string{"db.public.customers"}, bufferedTables(f.ctx))
+	models.GetMemoryDB(f.ctx).ClearTableConfig("db.public.customers")
+}
+
+func TestParseFileRecord(t *testing.T) {
+	// Dead-letter queue records say that their key and value are base64 encoded.
+	record, err := parseFileRecord("/tmp/replay.jsonl", 1, []byte(fileRecordLine(t, 1, "foo")))
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/replay.jsonl", record.Path)
+	assert.Equal(t, 1, record.Line)
+	assert.Equal(t, "replay", record.Topic)
+	assert.Equal(t, "0", record.Partition)
+	assert.Equal(t, "Struct{id=1}", string(record.Key))
+	assert.Contains(t, string(record.Value), `"connector": "mongodb"`)
+
+	// Plain JSON CDC records can have their key and value as is.
+	record, err = parseFileRecord("/tmp/replay.jsonl", 2, []byte(`{"topic": "replay", "key": {"id": 1}, "value": {"payload": {"op": "c"}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "replay", record.Topic)
+	assert.Equal(t, `{"id": 1}`, string(record.Key))
+	assert.Equal(t, `{"payload": {"op": "c"}}`, string(record.Value))
+
+	record, err = parseFileRecord("/tmp/replay.jsonl", 3, []byte(`{"topic": "replay", "key": "Struct{id=1}", "value": null}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "Struct{id=1}", string(record.Key))
+	assert.Nil(t, record.Value)
+
+	// Strings are only decoded if the record says that it's base64 encoded, even if they happen to be valid base64.
+	record, err = parseFileRecord("/tmp/replay.jsonl", 4, []byte(`{"topic": "replay", "key": "test", "value": "abcd"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "test", string(record.Key))
+	assert.Equal(t, "abcd", string(record.Value))
+
+	record, err = parseFileRecord("/tmp/replay.jsonl", 5, []byte(`{"topic": "replay", "encoding": "base64", "key": "dGVzdA==", "value": null}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "test", string(record.Key))
+	assert.Nil(t, record.Value)
+
+	_, err = parseFileRecord("/tmp/replay.jsonl", 6, []byte(`{"topic": "replay", "encoding": "base64", "key": "Struct{id=1}"}`))
+	assert.ErrorContains(t, err, "failed to decode key")
+
+	_, err = parseFileRecord("/tmp/replay.jsonl", 7, []byte(`{"topic": "replay", "encoding": "base64", "key": {"id": 1}}`))
+	assert.ErrorContains(t, err, "expected a base64 encoded string")
+
+	_, err = parseFileRecord("/tmp/replay.jsonl", 8, []byte(`{"topic": "replay", "encoding": "hex", "key": "74657374"}`))
+	assert.ErrorContains(t, err, "encoding: hex is not supported")
+
+	_, err = parseFileRecord("/tmp/replay.jsonl", 9, []byte("not json"))
+	assert.Error(t, err)
+}
+
+func rawFileRecordLine(id int, name string) string {
+	return fmt.Sprintf(`{"topic": "replay", "partition": "0", "offset": %d, "key": "Struct{id=%d}", "value": {"schema": {}, "payload": {"after": "{\"_id\": {\"$numberLong\": \"%d\"},\"name\": \"%s\"}", "source": {"connector": "mongodb", "ts_ms": 1668753321000, "db": "inventory", "collection": "customers"}, "op": "c"}}}`,
+		id, id, id, name)
+}
+
+func (f *FlushTestSuite) TestReplayFile_CheckpointAfterFlush() {
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	tcFmtMap.Add("replay", TopicConfigFormatter{
+		tc: &kafkalib.TopicConfig{
+			Database:     "db",
+			Schema:       "public",
+			Topic:        "replay",
+			CDCKeyFormat: "org.apache.kafka.connect.storage.StringConverter",
+		},
+		Format: &mgo,
+	})
+
+	dir := f.T().TempDir()
+	store, err := checkpoint.NewFileStore(filepath.Join(dir, "checkpoints.json"))
+	assert.NoError(f.T(), err)
+	r := &replayer{checkpoints: store, tcFmtMap: tcFmtMap}
+
+	// BufferRows is 500, so the table is flushed after line 501 and the last line fails the replay.
+	var lines []string
+	for i := 1; i <= 503; i++ {
+		lines = append(lines, rawFileRecordLine(i, "foo"))
+	}
+
+	path := filepath.Join(dir, "replay.jsonl")
+	assert.NoError(f.T(), os.WriteFile(path, []byte(strings.Join(append(lines, "not json"), "\n")), 0644))
+	assert.ErrorContains(f.T(), r.replayFile(f.ctx, path), "failed to parse line: 504")
+
+	// The lines that have been flushed are checkpointed, so we don't have to start over.
+	position, isOk := store.Get(path, fileCheckpointPartition)
+	assert.True(f.T(), isOk)
+	assert.Equal(f.T(), "501", position)
+	models.GetMemoryDB(f.ctx).ClearTableConfig("db.public.customers")
+
+	assert.NoError(f.T(), os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644))
+	assert.NoError(f.T(), r.replayFile(f.ctx, path))
+	position, _ = store.Get(path, fileCheckpointPartition)
+	assert.Equal(f.T(), "503", position)
+	assert.Empty(f.T(), bufferedTables(f.ctx))
+}
@@ -2,20 +2,30 @@ package consumer
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/artie-labs/transfer/lib/destination/utils"
 	"github.com/artie-labs/transfer/lib/logger"
 	"github.com/artie-labs/transfer/lib/telemetry/metrics"
+	"github.com/artie-labs/transfer/lib/telemetry/tracing"
 	"github.com/artie-labs/transfer/models"
 )
 
+// flushCount is incremented after every flush, so the file consumer can tell when to check whether it's safe to checkpoint.
+var flushCount atomic.Int64
+
 type Args struct {
 	Context context.Context
 	// If cooldown is passed in, we'll skip the merge if the table has been recently merged
 	CoolDown *time.Duration
 	// If specificTable is not passed in, we'll just flush everything.
+	// Tables are buffered by their database, schema and name, so this is the key from models.TableKey.
 	SpecificTable string
 
 	// Reason (reason for the flush)
@@ -36,20 +46,21 @@ func Flush(args Args) error {
 	models.GetMemoryDB(args.Context).RLock()
 	allTables := models.GetMemoryDB(args.Context).TableData()
 	models.GetMemoryDB(args.Context).RUnlock()
+	destinations := utils.DestinationsFromContext(args.Context)
 
 	// Flush will take everything in memory and call Snowflake to create temp tables.
-	for tableName, tableData := range allTables {
-		if args.SpecificTable != "" && tableName != args.SpecificTable {
+	for tableKey, tableData := range allTables {
+		if args.SpecificTable != "" && tableKey != args.SpecificTable {
 			// If the table is specified within args and the table does not match the database, skip this flush.
 			continue
 		}
 
 		wg.Add(1)
-		go func(_tableName string, _tableData *models.TableData) {
+		go func(_tableKey string, _tableData *models.TableData) {
 			defer wg.Done()
 
 			logFields := map[string]interface{}{
-				"tableName": _tableName,
+				"tableName": _tableKey,
 			}
 
 			if args.CoolDown != nil && _tableData.ShouldSkipMerge(*args.CoolDown) {
@@ -60,40 +71,138 @@ func Flush(args Args) error {
 			// Lock the tables when executing merge.
 			_tableData.Lock()
 			defer _tableData.Unlock()
+
+			// The partial batch's offsets come before the ones that have been buffered since, so it has to be finished first.
+			if partialBatch := _tableData.PartialBatch(); partialBatch != nil {
+				if err := flushBatch(args, destinations, _tableKey, _tableData, partialBatch); err != nil {
+					return
+				}
+
+				_tableData.ClearPartialBatch()
+			}
+
 			if _tableData.Empty() {
 				return
 			}
 
-			// This is added so that we have a new temporary table suffix for each merge.
-			_tableData.ResetTempTableSuffix()
-
-			start := time.Now()
-			tags := map[string]string{
-				"what":     "success",
-				"table":    _tableName,
-				"database": _tableData.TopicConfig.Database,
-				"schema":   _tableData.TopicConfig.Schema,
-				"reason":   args.Reason,
+			if err := flushBatch(args, destinations, _tableKey, _tableData, &models.Batch{TableData: _tableData.TableData}); err == nil {
+				models.GetMemoryDB(args.Context).ClearTableConfig(_tableKey)
 			}
+		}(tableKey, tableData)
+	}
+	wg.Wait()
+	flushCount.Add(1)
 
-			err := utils.FromContext(args.Context).Merge(args.Context, _tableData.TableData)
-			if err != nil {
-				tags["what"] = "merge_fail"
-				log.WithError(err).WithFields(logFields).Warn("Failed to execute merge...not going to flush memory")
-			} else {
-				log.WithFields(logFields).Info("Merge success, clearing memory...")
-				commitErr := commitOffset(args.Context, _tableData.TopicConfig.Topic, _tableData.PartitionsToLastMessage)
-				if commitErr == nil {
-					models.GetMemoryDB(args.Context).ClearTableConfig(_tableName)
-				} else {
-					tags["what"] = "commit_fail"
-					log.WithError(commitErr).Warn("commit error...")
-				}
-			}
-			metrics.FromContext(args.Context).Timing("flush", time.Since(start), tags)
-		}(tableName, tableData)
+	return nil
+}
+
+// flushBatch will merge the batch and commit its offsets, callers are expected to hold the lock and clear the batch if this succeeds.
+// If the batch has been merged into some of the destinations, but not all of them (or its offsets could not be committed), it's set aside
+// so that the retry will only merge it into the destinations that it has not been merged into.
+func flushBatch(args Args, destinations []utils.Destination, tableKey string, tableData *models.TableData, batch *models.Batch) error {
+	log := logger.FromContext(args.Context)
+	logFields := map[string]interface{}{
+		"tableName": tableKey,
+	}
+
+	// This is added so that we have a new temporary table suffix for each merge.
+	batch.ResetTempTableSuffix()
+
+	start := time.Now()
+	tags := map[string]string{
+		"what":     "success",
+		"table":    batch.Name(args.Context, nil),
+		"database": batch.TopicConfig.Database,
+		"schema":   batch.TopicConfig.Schema,
+		"reason":   args.Reason,
+	}
+
+	// The destination's steps will be child spans of this span.
+	spanCtx, span := tracing.StartSpan(args.Context, "flush", tracing.Table(tableKey),
+		tracing.Rows(batch.Rows()), attribute.String("reason", args.Reason))
+	var flushErr error
+	err := merge(spanCtx, destinations, batch)
+	if err != nil {
+		tags["what"] = "merge_fail"
+		flushErr = fmt.Errorf("failed to merge, err: %v", err)
+		if len(batch.Merged) > 0 {
+			// Redelivering the messages would merge them into the destinations that have succeeded again.
+			log.WithError(err).WithFields(logFields).Warn("Failed to execute merge into some of the destinations...not going to flush memory")
+		} else if nackMessages(batch.PartitionsToLastMessage) {
+			log.WithError(err).WithFields(logFields).Warn("Failed to execute merge...messages have been nacked for redelivery, clearing memory")
+			models.GetMemoryDB(args.Context).ClearTableConfig(tableKey)
+		} else {
+			log.WithError(err).WithFields(logFields).Warn("Failed to execute merge...not going to flush memory")
+		}
+	} else {
+		log.WithFields(logFields).Info("Merge success, clearing memory...")
+		if commitErr := commitOffset(args.Context, batch.PartitionsToLastMessage); commitErr != nil {
+			tags["what"] = "commit_fail"
+			flushErr = fmt.Errorf("failed to commit, err: %v", commitErr)
+			log.WithError(commitErr).WithFields(logFields).Warn("commit error...")
+		}
+	}
+
+	if flushErr != nil && len(batch.Merged) > 0 {
+		tableData.SetPartialBatch(batch)
+	}
+
+	tableData.SetLastError(flushErr)
+	tracing.EndSpan(args.Context, span, flushErr)
+	metrics.FromContext(args.Context).Timing("flush", time.Since(start), tags)
+	return flushErr
+}
+
+// merge will merge the batch into every destination that its topic config writes to, and returns an error if any of them failed.
+// The destinations are independent, so they're merged into concurrently and one failing will not stop the rest.
+// Every destination gets its own copy of the table data, since merging will update the in-memory columns from the destination.
+// The rows are shared, merges only read them.
+// Destinations that the batch has already been merged into are skipped, and the ones that succeed are added to batch.Merged.
+func merge(ctx context.Context, destinations []utils.Destination, batch *models.Batch) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(destinations))
+	for idx, dest := range destinations {
+		// Unnamed means that it's the only destination.
+		if dest.Name == "" {
+			return dest.Baseline.Merge(ctx, batch.TableData.Copy())
+		}
+
+		if !batch.TopicConfig.WritesTo(dest.Name) || batch.Merged[dest.Name] {
+			continue
+		}
+
+		wg.Add(1)
+		go func(idx int, dest utils.Destination) {
+			defer wg.Done()
+			spanCtx, span := tracing.StartSpan(ctx, "flush.destination", attribute.String("destination", dest.Name))
+			errs[idx] = dest.Baseline.Merge(dest.Context(spanCtx), batch.TableData.Copy())
+			tracing.EndSpan(ctx, span, errs[idx])
+		}(idx, dest)
 	}
 	wg.Wait()
 
+	var errMsgs []string
+	for idx, dest := range destinations {
+		if !batch.TopicConfig.WritesTo(dest.Name) || batch.Merged[dest.Name] {
+			continue
+		}
+
+		if errs[idx] != nil {
+			logger.FromContext(ctx).WithError(errs[idx]).WithField("destination", dest.Name).Warn("Failed to merge into destination")
+			errMsgs = append(errMsgs, fmt.Sprintf("destination: %s, err: %v", dest.Name, errs[idx]))
+			continue
+		}
+
+		if batch.Merged == nil {
+			batch.Merged = make(map[string]bool)
+		}
+
+		batch.Merged[dest.Name] = true
+	}
+
+	if len(errMsgs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errMsgs, "; "))
+	}
+
 	return nil
 }
@@ -1,18 +1,30 @@
 package consumer
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
 
 	"github.com/artie-labs/transfer/models/event"
 
 	"github.com/artie-labs/transfer/lib/artie"
 	"github.com/artie-labs/transfer/lib/config"
 	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/destination/utils"
+	"github.com/artie-labs/transfer/lib/optimization"
+	"github.com/artie-labs/transfer/lib/typing"
+	"github.com/artie-labs/transfer/lib/typing/columns"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
 	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/mocks"
+	"github.com/artie-labs/transfer/lib/telemetry/tracing"
 	"github.com/artie-labs/transfer/models"
 )
 
@@ -23,6 +35,11 @@ var topicConfig = &kafkalib.TopicConfig{
 	Topic:     "foo",
 }
 
+// tableKey returns the key that the table is buffered under when it's saved with topicConfig.
+func tableKey(tableName string) string {
+	return models.TableKey(topicConfig.Database, topicConfig.Schema, tableName)
+}
+
 func (f *FlushTestSuite) TestMemoryBasic() {
 	for i := 0; i < 5; i++ {
 		evt := event.Event{
@@ -37,11 +54,11 @@ func (f *FlushTestSuite) TestMemoryBasic() {
 			},
 		}
 
-		kafkaMsg := kafka.Message{Partition: 1, Offset: 1}
+		kafkaMsg := kafka.Message{Topic: topicConfig.Topic, Partition: 1, Offset: 1}
 		_, _, err := evt.Save(f.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
 		assert.Nil(f.T(), err)
 
-		td := models.GetMemoryDB(f.ctx).GetOrCreateTableData("foo")
+		td := models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("foo"))
 		assert.Equal(f.T(), int(td.Rows()), i+1)
 	}
 }
@@ -66,7 +83,7 @@ func (f *FlushTestSuite) TestShouldFlush() {
 		}
 
 		var err error
-		kafkaMsg := kafka.Message{Partition: 1, Offset: int64(i)}
+		kafkaMsg := kafka.Message{Topic: topicConfig.Topic, Partition: 1, Offset: int64(i)}
 		flush, flushReason, err = evt.Save(f.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
 		assert.Nil(f.T(), err)
 
@@ -103,7 +120,7 @@ func (f *FlushTestSuite) TestMemoryConcurrency() {
 					},
 				}
 
-				kafkaMsg := kafka.Message{Partition: 1, Offset: int64(i)}
+				kafkaMsg := kafka.Message{Topic: topicConfig.Topic, Partition: 1, Offset: int64(i)}
 				_, _, err := evt.Save(f.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
 				assert.Nil(f.T(), err)
 			}
@@ -114,7 +131,7 @@ func (f *FlushTestSuite) TestMemoryConcurrency() {
 
 	// Verify all the tables exist.
 	for idx := range tableNames {
-		td := models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableNames[idx])
+		td := models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey(tableNames[idx]))
 		tableConfig := td.RowsData()
 		assert.Equal(f.T(), len(tableConfig), 5)
 	}
@@ -130,3 +147,299 @@ func (f *FlushTestSuite) TestMemoryConcurrency() {
 		assert.Equal(f.T(), kafkaMessages[0].Offset, int64(4))
 	}
 }
+
+func (f *FlushTestSuite) TestShutdown() {
+	for i := 0; i < 5; i++ {
+		evt := event.Event{
+			Table: "shutdown",
+			PrimaryKeyMap: map[string]interface{}{
+				"id": fmt.Sprintf("pk-%d", i),
+			},
+			Data: map[string]interface{}{
+				constants.DeleteColumnMarker: false,
+				"id":                         fmt.Sprintf("pk-%d", i),
+			},
+		}
+
+		kafkaMsg := kafka.Message{Topic: topicConfig.Topic, Partition: 1, Offset: int64(i)}
+		_, _, err := evt.Save(f.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+		assert.Nil(f.T(), err)
+	}
+
+	Shutdown(f.ctx)
+	// Buffered rows should be merged and the offset committed before the consumer is closed.
+	assert.Equal(f.T(), 1, f.fakeConsumer.CommitMessagesCallCount())
+	_, kafkaMessages := f.fakeConsumer.CommitMessagesArgsForCall(0)
+	assert.Equal(f.T(), int64(4), kafkaMessages[0].Offset)
+	assert.Equal(f.T(), 1, f.fakeConsumer.CloseCallCount())
+	assert.True(f.T(), models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("shutdown")).Empty())
+}
+
+func (f *FlushTestSuite) TestFlushMergeFailure() {
+	for i := 0; i < 5; i++ {
+		evt := event.Event{
+			Table: "pubsub",
+			PrimaryKeyMap: map[string]interface{}{
+				"id": fmt.Sprintf("pk-%d", i),
+			},
+			Data: map[string]interface{}{
+				constants.DeleteColumnMarker: false,
+				"id":                         fmt.Sprintf("pk-%d", i),
+			},
+		}
+
+		pubsubMsg := &pubsub.Message{ID: fmt.Sprint(i), Data: []byte("payload")}
+		_, _, err := evt.Save(f.ctx, topicConfig, artie.NewMessage(nil, pubsubMsg, topicConfig.Topic))
+		assert.Nil(f.T(), err)
+	}
+	f.saveToPartition("kafka", 1, 3)
+
+	// Every Pub/Sub message has an ack handle, without holding onto the payload.
+	handles := models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("pubsub")).PartitionsToLastMessage[artie.ToPartitionKey(topicConfig.Topic, "no_partition")]
+	assert.Len(f.T(), handles, 5)
+	for _, handle := range handles {
+		assert.Nil(f.T(), handle.Value())
+	}
+
+	f.fakeStore.ExecReturns(nil, fmt.Errorf("merge failed"))
+	assert.Nil(f.T(), Flush(Args{Context: f.ctx}))
+
+	// Pub/Sub messages are nacked and will be redelivered, whereas Kafka rows are kept for the next flush.
+	assert.True(f.T(), models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("pubsub")).Empty())
+	assert.False(f.T(), models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("kafka")).Empty())
+	assert.Equal(f.T(), 0, f.fakeConsumer.CommitMessagesCallCount())
+}
+
+func (f *FlushTestSuite) saveOrder(tc *kafkalib.TopicConfig, offset int64) {
+	evt := event.Event{
+		Table: "orders",
+		PrimaryKeyMap: map[string]interface{}{
+			"id": "1",
+		},
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: false,
+			"id":                         "1",
+		},
+	}
+
+	kafkaMsg := kafka.Message{Topic: tc.Topic, Partition: 1, Offset: offset}
+	_, _, err := evt.Save(f.ctx, tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.Nil(f.T(), err)
+}
+
+func (f *FlushTestSuite) TestFlushTablesAcrossSchemas() {
+	barConsumer := &mocks.FakeConsumer{}
+	topicToConsumer.Add("bar", barConsumer)
+
+	// Tables that share a name in different schemas are buffered separately.
+	otherSchema := &kafkalib.TopicConfig{Database: topicConfig.Database, Schema: "other", Topic: "bar"}
+	f.saveOrder(topicConfig, 1)
+	f.saveOrder(otherSchema, 7)
+
+	publicOrders := models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("orders"))
+	otherOrders := models.GetMemoryDB(f.ctx).GetOrCreateTableData(models.TableKey(topicConfig.Database, "other", "orders"))
+	assert.Equal(f.T(), uint(1), publicOrders.Rows())
+	assert.Equal(f.T(), "public", publicOrders.TopicConfig.Schema)
+	assert.Equal(f.T(), uint(1), otherOrders.Rows())
+	assert.Equal(f.T(), "other", otherOrders.TopicConfig.Schema)
+
+	assert.Nil(f.T(), Flush(Args{Context: f.ctx, SpecificTable: tableKey("orders")}))
+	assert.True(f.T(), publicOrders.Empty())
+	assert.False(f.T(), otherOrders.Empty())
+	assert.Equal(f.T(), 1, f.fakeConsumer.CommitMessagesCallCount())
+	assert.Equal(f.T(), 0, barConsumer.CommitMessagesCallCount())
+
+	// A table that is fed by more than one topic will commit each topic's offset.
+	f.saveOrder(topicConfig, 2)
+	f.saveOrder(&kafkalib.TopicConfig{Database: topicConfig.Database, Schema: topicConfig.Schema, Topic: "bar"}, 9)
+	assert.Len(f.T(), publicOrders.PartitionsToLastMessage, 2)
+
+	assert.Nil(f.T(), Flush(Args{Context: f.ctx}))
+	assert.True(f.T(), publicOrders.Empty())
+	assert.True(f.T(), otherOrders.Empty())
+	assert.Equal(f.T(), 2, f.fakeConsumer.CommitMessagesCallCount())
+	_, kafkaMessages := f.fakeConsumer.CommitMessagesArgsForCall(1)
+	assert.Equal(f.T(), int64(2), kafkaMessages[0].Offset)
+
+	var barOffsets []int64
+	for i := 0; i < barConsumer.CommitMessagesCallCount(); i++ {
+		_, kafkaMessages = barConsumer.CommitMessagesArgsForCall(i)
+		barOffsets = append(barOffsets, kafkaMessages[0].Offset)
+	}
+	assert.ElementsMatch(f.T(), []int64{7, 9}, barOffsets)
+}
+
+func (f *FlushTestSuite) TestFlushSpans() {
+	recorder := tracetest.NewSpanRecorder()
+	ctx := tracing.InjectTracerProviderIntoCtx(f.ctx, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	f.saveOrder(topicConfig, 1)
+	f.saveOrder(topicConfig, 2)
+	assert.Nil(f.T(), Flush(Args{Context: ctx, Reason: "test"}))
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	flushSpan, isOk := spans["flush"]
+	assert.True(f.T(), isOk)
+	assert.Contains(f.T(), flushSpan.Attributes(), tracing.Table(tableKey("orders")))
+	assert.Contains(f.T(), flushSpan.Attributes(), tracing.Rows(1))
+
+	// Every destination step is a child of the flush span.
+	for _, name := range []string{"snowflake.get_table_config", "snowflake.load_temp_table", "snowflake.merge", "snowflake.drop_temp_table"} {
+		span, isOk := spans[name]
+		assert.True(f.T(), isOk, name)
+		assert.Equal(f.T(), flushSpan.SpanContext().SpanID(), span.Parent().SpanID(), name)
+	}
+
+	var query string
+	for _, attr := range spans["snowflake.merge"].Attributes() {
+		if attr.Key == "query" {
+			query = attr.Value.AsString()
+		}
+	}
+	assert.Contains(f.T(), query, "MERGE INTO")
+}
+
+type fakeDestination struct {
+	err        error
+	outputs    []constants.DestinationKind
+	tableDatas []*optimization.TableData
+	deadlines  []time.Time
+}
+
+func (f *fakeDestination) Label() constants.DestinationKind {
+	return "fake"
+}
+
+func (f *fakeDestination) Merge(ctx context.Context, tableData *optimization.TableData) error {
+	// The destination's own settings should be injected.
+	f.outputs = append(f.outputs, config.FromContext(ctx).Config.Output)
+	f.tableDatas = append(f.tableDatas, tableData)
+	if deadline, isOk := ctx.Deadline(); isOk {
+		f.deadlines = append(f.deadlines, deadline)
+	}
+
+	return f.err
+}
+
+func (f *FlushTestSuite) TestFlushDestinations() {
+	analytics := &fakeDestination{}
+	lake := &fakeDestination{err: fmt.Errorf("failed to upload")}
+	warehouse := &fakeDestination{}
+	ctx := utils.InjectDestinationsIntoCtx([]utils.Destination{
+		{Name: "analytics", Settings: &config.Settings{Config: &config.Config{Output: constants.Snowflake}}, Baseline: analytics},
+		{Name: "lake", Settings: &config.Settings{Config: &config.Config{Output: constants.S3}}, Baseline: lake},
+		{Name: "warehouse", Settings: &config.Settings{Config: &config.Config{Output: constants.Redshift}}, Baseline: warehouse},
+	}, f.ctx)
+
+	tc := &kafkalib.TopicConfig{
+		Database:     topicConfig.Database,
+		Schema:       topicConfig.Schema,
+		Topic:        topicConfig.Topic,
+		Destinations: []string{"analytics", "lake"},
+	}
+	f.saveOrder(tc, 1)
+
+	// The offset is not committed until every destination that the topic config writes to has succeeded.
+	assert.Nil(f.T(), Flush(Args{Context: ctx}))
+	orders := models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("orders"))
+	assert.True(f.T(), orders.Buffered())
+	assert.Equal(f.T(), map[string]bool{"analytics": true}, orders.PartialBatch().Merged)
+	assert.Contains(f.T(), orders.Status(tableKey("orders")).LastError, "destination: lake, err: failed to upload")
+	assert.Equal(f.T(), []constants.DestinationKind{constants.Snowflake}, analytics.outputs)
+	assert.Equal(f.T(), []constants.DestinationKind{constants.S3}, lake.outputs)
+	assert.Empty(f.T(), warehouse.outputs)
+	assert.Equal(f.T(), 0, f.fakeConsumer.CommitMessagesCallCount())
+
+	// Every destination merges its own copy of the table data, so their in-memory columns are kept apart.
+	assert.NotSame(f.T(), analytics.tableDatas[0], lake.tableDatas[0])
+
+	// Rows that are buffered in the meantime go into a new batch, and the retry only merges the partial batch into the lake.
+	f.saveOrder(tc, 2)
+	assert.Nil(f.T(), Flush(Args{Context: ctx}))
+	assert.Equal(f.T(), uint(2), orders.Status(tableKey("orders")).Rows)
+	assert.Len(f.T(), analytics.outputs, 1)
+	assert.Len(f.T(), lake.outputs, 2)
+	assert.Equal(f.T(), 0, f.fakeConsumer.CommitMessagesCallCount())
+
+	// Once the partial batch succeeds, its offset is committed before the new batch is merged into both destinations.
+	lake.err = nil
+	assert.Nil(f.T(), Flush(Args{Context: ctx}))
+	assert.False(f.T(), orders.Buffered())
+	assert.Empty(f.T(), orders.Status(tableKey("orders")).LastError)
+	assert.Len(f.T(), analytics.outputs, 2)
+	assert.Len(f.T(), lake.outputs, 4)
+	assert.Empty(f.T(), warehouse.outputs)
+	assert.Equal(f.T(), 2, f.fakeConsumer.CommitMessagesCallCount())
+	_, msgs := f.fakeConsumer.CommitMessagesArgsForCall(0)
+	assert.Equal(f.T(), int64(1), msgs[0].Offset)
+	_, msgs = f.fakeConsumer.CommitMessagesArgsForCall(1)
+	assert.Equal(f.T(), int64(2), msgs[0].Offset)
+}
+
+// readingDestination reads every row and updates the in-memory columns from the destination, the same way that the clients do.
+type readingDestination struct {
+	kind      constants.DestinationKind
+	idKind    typing.KindDetails
+	rows      int
+	tableData *optimization.TableData
+}
+
+func (r *readingDestination) Label() constants.DestinationKind {
+	return r.kind
+}
+
+func (r *readingDestination) Merge(ctx context.Context, tableData *optimization.TableData) error {
+	tableData.UpdateInMemoryColumnsFromDestination(ctx, columns.NewColumn("id", r.idKind))
+	for _, row := range tableData.RowsData() {
+		for _, col := range tableData.ReadOnlyInMemoryCols().GetColumnsToUpdate(ctx, nil) {
+			_ = fmt.Sprint(row[col])
+		}
+
+		r.rows += 1
+	}
+
+	r.tableData = tableData
+	return nil
+}
+
+func (f *FlushTestSuite) TestFlushDestinationsConcurrently() {
+	// This is meant to be run with -race, both destinations merge the same batch at the same time.
+	analytics := &readingDestination{kind: constants.Snowflake, idKind: typing.Integer}
+	lake := &readingDestination{kind: constants.S3, idKind: typing.Float}
+	ctx := utils.InjectDestinationsIntoCtx([]utils.Destination{
+		{Name: "analytics", Settings: &config.Settings{Config: &config.Config{Output: constants.Snowflake}}, Baseline: analytics},
+		{Name: "lake", Settings: &config.Settings{Config: &config.Config{Output: constants.S3}}, Baseline: lake},
+	}, f.ctx)
+
+	tc := &kafkalib.TopicConfig{
+		Database:     topicConfig.Database,
+		Schema:       topicConfig.Schema,
+		Topic:        topicConfig.Topic,
+		Destinations: []string{"analytics", "lake"},
+	}
+
+	for offset := int64(1); offset <= 50; offset++ {
+		f.saveToPartitionWithConfig(tc, "orders", 1, offset)
+	}
+
+	orders := models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("orders"))
+	buffered := orders.TableData
+
+	assert.Nil(f.T(), Flush(Args{Context: ctx}))
+	assert.Equal(f.T(), 50, analytics.rows)
+	assert.Equal(f.T(), 50, lake.rows)
+	assert.False(f.T(), orders.Buffered())
+	assert.Equal(f.T(), 1, f.fakeConsumer.CommitMessagesCallCount())
+
+	// Every destination updated the column types on its own copy, which leaves the buffered batch alone.
+	col, _ := analytics.tableData.ReadOnlyInMemoryCols().GetColumn("id")
+	assert.Equal(f.T(), typing.Integer.Kind, col.KindDetails.Kind)
+	col, _ = lake.tableData.ReadOnlyInMemoryCols().GetColumn("id")
+	assert.Equal(f.T(), typing.Float.Kind, col.KindDetails.Kind)
+	col, _ = buffered.ReadOnlyInMemoryCols().GetColumn("id")
+	assert.Equal(f.T(), typing.String.Kind, col.KindDetails.Kind)
+}
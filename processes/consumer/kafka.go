@@ -2,15 +2,13 @@ package consumer
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/artie-labs/transfer/lib/artie"
-	awsCfg "github.com/aws/aws-sdk-go-v2/config"
-	"github.com/segmentio/kafka-go/sasl/aws_msk_iam_v2"
-	"github.com/segmentio/kafka-go/sasl/plain"
 
 	"github.com/artie-labs/transfer/lib/cdc/format"
 	"github.com/artie-labs/transfer/lib/config"
@@ -44,6 +42,21 @@ func (t *TopicToConsumer) Get(topic string) kafkalib.Consumer {
 	return t.topicToConsumer[topic]
 }
 
+// Close will close all the consumers and return the last error, if any.
+func (t *TopicToConsumer) Close() error {
+	t.Lock()
+	defer t.Unlock()
+
+	var err error
+	for topic, consumer := range t.topicToConsumer {
+		if closeErr := consumer.Close(); closeErr != nil {
+			err = fmt.Errorf("failed to close consumer for topic: %s, err: %v", topic, closeErr)
+		}
+	}
+
+	return err
+}
+
 // SetKafkaConsumer - This is used for tests.
 func SetKafkaConsumer(_topicToConsumer map[string]kafkalib.Consumer) {
 	topicToConsumer = &TopicToConsumer{
@@ -56,92 +69,323 @@ func StartConsumer(ctx context.Context) {
 	settings := config.FromContext(ctx)
 	log.Info("Starting Kafka consumer...", settings.Config.Kafka)
 
-	dialer := &kafka.Dialer{
-		Timeout:   10 * time.Second,
-		DualStack: true,
+	dialer, err := newDialer(ctx, settings.Config.Kafka)
+	if err != nil {
+		log.WithError(err).Fatal("failed to create kafka dialer")
 	}
 
-	// If using AWS MSK IAM, we expect this to be set in the ENV VAR
-	// (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, or the AWS Profile should be called default.)
-	if settings.Config.Kafka.EnableAWSMSKIAM {
-		cfg, err := awsCfg.LoadDefaultConfig(ctx)
+	var brokers []string
+	for _, bootstrapServer := range strings.Split(settings.Config.Kafka.BootstrapServer, ",") {
+		brokers = append(brokers, bootstrapServer)
+	}
+
+	kafkaCfg := settings.Config.Kafka
+	tcFmtMap := NewTcFmtMap()
+	topicToConsumer = NewTopicToConsumer()
+	tcs := kafkaCfg.TopicConfigs
+	if len(kafkaCfg.TopicPatterns) > 0 {
+		topics, err := discoverTopics(ctx, dialer, brokers)
 		if err != nil {
-			log.WithError(err).Fatal("failed to load aws configuration")
+			log.WithError(err).Fatal("failed to discover kafka topics")
 		}
 
-		dialer.SASLMechanism = aws_msk_iam_v2.NewMechanism(cfg)
-		dialer.TLS = &tls.Config{}
+		tcs = resolveTopicConfigs(kafkaCfg.TopicConfigs, kafkaCfg.TopicPatterns, topics)
+	}
+
+	// Tracks every reader and processor across generations, so we only return once all of them have stopped.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	args := groupArgs{
+		Brokers:           brokers,
+		Dialer:            dialer,
+		GroupID:           kafkaCfg.GroupID,
+		TcFmtMap:          tcFmtMap,
+		DiscoveryInterval: time.Duration(kafkaCfg.TopicDiscoveryIntervalSeconds) * time.Second,
 	}
 
-	// If username or password is set, then let's enable PLAIN.
-	// By default, we will support no auth (local testing) and PLAIN SASL.
-	if settings.Config.Kafka.Username != "" {
-		dialer.SASLMechanism = plain.Mechanism{
-			Username: settings.Config.Kafka.Username,
-			Password: settings.Config.Kafka.Password,
+	for {
+		for _, topicConfig := range tcs {
+			if _, isOk := tcFmtMap.GetTopicFmt(topicConfig.Topic); isOk {
+				continue
+			}
+
+			tcFmtMap.Add(topicConfig.Topic, TopicConfigFormatter{
+				tc:     topicConfig,
+				Format: format.GetFormatParser(ctx, topicConfig),
+				dlq: loadDeadLetterWriter(ctx, topicConfig, deadLetterArgs{
+					Brokers: brokers,
+					Dialer:  dialer,
+				}),
+			})
 		}
 
-		dialer.TLS = &tls.Config{}
+		tcs = consumeGroup(ctx, args, tcs, &wg)
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.WithField("topics", len(tcs)).Info("Rejoining the kafka consumer group...")
+	}
+}
+
+type groupArgs struct {
+	Brokers           []string
+	Dialer            *kafka.Dialer
+	GroupID           string
+	TcFmtMap          *TcFmtMap
+	DiscoveryInterval time.Duration
+}
+
+// consumeGroup joins the consumer group for the topics and consumes until we are shutting down, until topic discovery finds a different
+// set of topics or until a partition cannot be consumed. In the latter cases, the group is closed (which flushes the revoked partitions)
+// and the topics to rejoin with are returned.
+func consumeGroup(ctx context.Context, args groupArgs, tcs []*kafkalib.TopicConfig, wg *sync.WaitGroup) []*kafkalib.TopicConfig {
+	log := logger.FromContext(ctx)
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	defer cancelWatch()
+
+	var next []*kafkalib.TopicConfig
+	changed := make(chan struct{})
+	if len(config.FromContext(ctx).Config.Kafka.TopicPatterns) > 0 {
+		go func() {
+			var isOk bool
+			if next, isOk = watchTopics(watchCtx, args, tcs); isOk {
+				close(changed)
+			}
+		}()
+	}
+
+	if len(tcs) == 0 {
+		log.Warn("No kafka topics matched, waiting for topic discovery...")
+		select {
+		case <-changed:
+			return next
+		case <-ctx.Done():
+			return nil
+		}
 	}
 
-	tcFmtMap := NewTcFmtMap()
-	topicToConsumer = NewTopicToConsumer()
 	var topics []string
-	for _, topicConfig := range settings.Config.Kafka.TopicConfigs {
-		tcFmtMap.Add(topicConfig.Topic, TopicConfigFormatter{
-			tc:     topicConfig,
-			Format: format.GetFormatParser(ctx, topicConfig.CDCFormat, topicConfig.Topic),
-		})
-		topics = append(topics, topicConfig.Topic)
+	for _, tc := range tcs {
+		topics = append(topics, tc.Topic)
 	}
 
-	var wg sync.WaitGroup
-	for _, topic := range topics {
-		wg.Add(1)
-		go func(topic string) {
-			defer wg.Done()
-
-			kafkaCfg := kafka.ReaderConfig{
-				GroupID: settings.Config.Kafka.GroupID,
-				Dialer:  dialer,
-				Topic:   topic,
+	kafkaCfg := config.FromContext(ctx).Config.Kafka
+	group, err := kafka.NewConsumerGroup(kafka.ConsumerGroupConfig{
+		ID:               args.GroupID,
+		Brokers:          args.Brokers,
+		Dialer:           args.Dialer,
+		Topics:           topics,
+		SessionTimeout:   kafkaCfg.SessionTimeout(),
+		RebalanceTimeout: kafkaCfg.RebalanceTimeout(),
+	})
+	if err != nil {
+		log.WithError(err).Fatal("failed to create kafka consumer group")
+	}
+
+	failed := make(chan struct{})
+	var failedOnce sync.Once
+	endGeneration := func() {
+		failedOnce.Do(func() { close(failed) })
+	}
+
+	closed := make(chan []*kafkalib.TopicConfig, 1)
+	go func() {
+		select {
+		case <-changed:
+			// Close waits for the current generation to end, so the revoked partitions have been flushed once this returns.
+			group.Close()
+			closed <- next
+		case <-failed:
+			// Rejoining will start every partition over from its committed offset.
+			group.Close()
+			closed <- tcs
+		case <-watchCtx.Done():
+		}
+	}()
+
+	for {
+		gen, err := group.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				// We are shutting down, the group will be closed within Shutdown after the final flush.
+				return nil
 			}
-			var brokers []string
-			for _, bootstrapServer := range strings.Split(settings.Config.Kafka.BootstrapServer, ",") {
-				brokers = append(brokers, bootstrapServer)
+
+			if errors.Is(err, kafka.ErrGroupClosed) {
+				return <-closed
 			}
 
-			kafkaCfg.Brokers = brokers
-			kafkaConsumer := kafka.NewReader(kafkaCfg)
-			topicToConsumer.Add(topic, kafkaConsumer)
-			for {
-				kafkaMsg, err := kafkaConsumer.FetchMessage(ctx)
-				msg := artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic)
-				logFields := map[string]interface{}{
-					"topic":  msg.Topic(),
-					"offset": kafkaMsg.Offset,
-					"key":    string(msg.Key()),
-					"value":  string(msg.Value()),
-				}
+			log.WithError(err).Warn("failed to join kafka consumer group, retrying...")
+			continue
+		}
 
-				if err != nil {
-					log.WithError(err).WithFields(logFields).Warn("failed to read kafka message")
-					continue
-				}
+		log.WithField("generationID", gen.ID).WithField("assignments", gen.Assignments).Info("Joined kafka consumer group generation")
+		consumer := &generationConsumer{group: group, gen: gen}
+		for _, topic := range topics {
+			topicToConsumer.Add(topic, consumer)
+		}
 
-				tableName, processErr := processMessage(ctx, ProcessArgs{
-					Msg:                    msg,
-					GroupID:                kafkaConsumer.Config().GroupID,
-					TopicToConfigFormatMap: tcFmtMap,
+		consumeGeneration(ctx, generationArgs{
+			Gen:           gen,
+			Assignments:   gen.Assignments,
+			GroupID:       args.GroupID,
+			TcFmtMap:      args.TcFmtMap,
+			EndGeneration: endGeneration,
+			NewReader: func(topic string, partition int) partitionReader {
+				return kafka.NewReader(kafka.ReaderConfig{
+					Brokers:   args.Brokers,
+					Dialer:    args.Dialer,
+					Topic:     topic,
+					Partition: partition,
 				})
+			},
+		}, wg)
+	}
+}
+
+// watchTopics periodically discovers topics from the brokers and returns the new topic configs once they differ from the current ones.
+func watchTopics(ctx context.Context, args groupArgs, current []*kafkalib.TopicConfig) ([]*kafkalib.TopicConfig, bool) {
+	log := logger.FromContext(ctx)
+	kafkaCfg := config.FromContext(ctx).Config.Kafka
+	ticker := time.NewTicker(args.DiscoveryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-ticker.C:
+		}
+
+		topics, err := discoverTopics(ctx, args.Dialer, args.Brokers)
+		if err != nil {
+			log.WithError(err).Warn("failed to discover kafka topics")
+			continue
+		}
+
+		tcs := resolveTopicConfigs(kafkaCfg.TopicConfigs, kafkaCfg.TopicPatterns, topics)
+		if !sameTopics(current, tcs) {
+			return tcs, true
+		}
+	}
+}
 
-				msg.EmitIngestionLag(ctx, kafkaConsumer.Config().GroupID, tableName)
-				if processErr != nil {
-					log.WithError(processErr).WithFields(logFields).Warn("skipping message...")
+// generation is implemented by *kafka.Generation, it's an interface so the partition workers can be driven within tests.
+type generation interface {
+	Start(fn func(ctx context.Context))
+}
+
+// partitionReader is implemented by *kafka.Reader.
+type partitionReader interface {
+	SetOffset(offset int64) error
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	Close() error
+}
+
+type generationArgs struct {
+	Gen         generation
+	Assignments map[string][]kafka.PartitionAssignment
+	GroupID     string
+	TcFmtMap    *TcFmtMap
+	// NewReader returns the reader for the topic's partition.
+	NewReader func(topic string, partition int) partitionReader
+	// EndGeneration is called when a partition cannot be consumed, so that the group is rejoined.
+	EndGeneration func()
+}
+
+// consumeGeneration starts a worker for every partition that has been assigned to us in this generation.
+// Each worker reads and processes its partition serially, so partitions are processed concurrently while keeping the order within a partition.
+// When the generation ends, the workers are stopped and the buffered rows of the revoked partitions are flushed before the partitions move.
+func consumeGeneration(ctx context.Context, args generationArgs, wg *sync.WaitGroup) {
+	// Workers stop fetching when we are shutting down or when the generation ends, whichever comes first.
+	readCtx, cancelRead := context.WithCancel(ctx)
+	var genWg sync.WaitGroup
+	for topic, assignments := range args.Assignments {
+		for _, assignment := range assignments {
+			wg.Add(1)
+			genWg.Add(1)
+			go func(topic string, assignment kafka.PartitionAssignment) {
+				defer wg.Done()
+				defer genWg.Done()
+				if err := consumePartition(ctx, readCtx, args, topic, assignment); err != nil {
+					logger.FromContext(ctx).WithError(err).WithFields(map[string]interface{}{
+						"topic":     topic,
+						"partition": assignment.ID,
+					}).Warn("failed to consume kafka partition, ending the generation...")
+					args.EndGeneration()
 				}
-			}
-		}(topic)
+			}(topic, assignment)
+		}
 	}
 
-	wg.Wait()
+	assigned.Store(true)
+	args.Gen.Start(func(genCtx context.Context) {
+		defer cancelRead()
+		// We are not assigned until the group has rebalanced.
+		defer assigned.Store(false)
+		// The generation must stay alive while we are shutting down, otherwise the group would rejoin and the
+		// final flush within Shutdown would not be able to commit. Closing the group will end the generation.
+		<-genCtx.Done()
+		cancelRead()
+		genWg.Wait()
+		if ctx.Err() != nil {
+			// We are shutting down, Shutdown has already flushed every table.
+			return
+		}
+
+		revokePartitions(ctx, args.Assignments)
+	})
+}
+
+// consumePartition fetches with readCtx and processes with ctx, so the message that is being processed when the generation ends is not interrupted.
+// An error is returned if the partition cannot be consumed at all.
+func consumePartition(ctx, readCtx context.Context, args generationArgs, topic string, assignment kafka.PartitionAssignment) error {
+	log := logger.FromContext(ctx)
+	reader := args.NewReader(topic, assignment.ID)
+	defer reader.Close()
+
+	// Start clean from the group's committed offset, anything we buffered prior has already been flushed or discarded.
+	if err := reader.SetOffset(assignment.Offset); err != nil {
+		return fmt.Errorf("failed to set kafka reader offset: %d, err: %v", assignment.Offset, err)
+	}
+
+	for {
+		kafkaMsg, err := reader.FetchMessage(readCtx)
+		if err != nil {
+			if readCtx.Err() != nil {
+				return nil
+			}
+
+			log.WithError(err).WithFields(map[string]interface{}{
+				"topic":     topic,
+				"partition": assignment.ID,
+			}).Warn("failed to read kafka message")
+			continue
+		}
+
+		msg := artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic)
+		tableName, processErr := processMessage(ctx, ProcessArgs{
+			Msg:                    msg,
+			GroupID:                args.GroupID,
+			TopicToConfigFormatMap: args.TcFmtMap,
+		})
+
+		msg.EmitIngestionLag(ctx, args.GroupID, tableName)
+		if processErr != nil {
+			logFields := map[string]interface{}{
+				"topic":  msg.Topic(),
+				"offset": kafkaMsg.Offset,
+				"key":    string(msg.Key()),
+				"value":  string(msg.Value()),
+			}
+
+			if errors.Is(processErr, errDeadLetterFailed) {
+				// Stop reading the partition, so nothing past this message is committed. It'll be read again after the rebalance or restart.
+				log.WithError(processErr).WithFields(logFields).Warn("failed to dead letter message, stopping partition...")
+				return nil
+			}
+
+			log.WithError(processErr).WithFields(logFields).Warn("skipping message...")
+		}
+	}
 }
@@ -0,0 +1,359 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsCfg "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/cdc/format"
+	"github.com/artie-labs/transfer/lib/checkpoint"
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/logger"
+)
+
+// Kinesis allows 5 GetRecords calls per second per shard.
+const minGetRecordsInterval = 200 * time.Millisecond
+
+// kinesisAPI is the subset of the Kinesis client that we use, so it can be faked within tests.
+type kinesisAPI interface {
+	ListShards(ctx context.Context, params *kinesis.ListShardsInput, optFns ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error)
+	GetShardIterator(ctx context.Context, params *kinesis.GetShardIteratorInput, optFns ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *kinesis.GetRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error)
+}
+
+// kinesisCheckpoints is where commitOffset will write the sequence numbers to after a successful flush.
+var kinesisCheckpoints *checkpoint.FileStore
+
+func newKinesisClient(ctx context.Context, cfg *config.Kinesis) (*kinesis.Client, error) {
+	opts := []func(*awsCfg.LoadOptions) error{awsCfg.WithRegion(cfg.Region)}
+	if cfg.AwsAccessKeyID != "" {
+		opts = append(opts, awsCfg.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AwsAccessKeyID, cfg.AwsSecretAccessKey, "")))
+	}
+
+	awsConfig, err := awsCfg.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws configuration, err: %v", err)
+	}
+
+	return kinesis.NewFromConfig(awsConfig, func(o *kinesis.Options) {
+		if cfg.Endpoint != "" {
+			o.EndpointResolver = kinesis.EndpointResolverFromURL(cfg.Endpoint)
+		}
+	}), nil
+}
+
+// StartKinesisConsumer reads every shard of the configured streams and will return once ctx is done and all the readers have stopped.
+// Sequence numbers are checkpointed by commitOffset after a successful flush, so we resume from there on restart.
+func StartKinesisConsumer(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	settings := config.FromContext(ctx)
+	log.Info("Starting Kinesis consumer...", settings.Config.Kinesis)
+
+	client, err := newKinesisClient(ctx, settings.Config.Kinesis)
+	if err != nil {
+		log.WithError(err).Fatal("failed to create kinesis client")
+	}
+
+	store, err := checkpoint.NewFileStore(settings.Config.Kinesis.CheckpointPath)
+	if err != nil {
+		log.WithError(err).Fatal("failed to load kinesis checkpoints")
+	}
+
+	kinesisCheckpoints = store
+	tcFmtMap := NewTcFmtMap()
+	for _, topicConfig := range settings.Config.Kinesis.TopicConfigs {
+		tcFmtMap.Add(topicConfig.Topic, TopicConfigFormatter{
+			tc:     topicConfig,
+			Format: format.GetFormatParser(ctx, topicConfig),
+			dlq:    loadDeadLetterWriter(ctx, topicConfig, deadLetterArgs{}),
+		})
+	}
+
+	var wg sync.WaitGroup
+	for _, topicConfig := range settings.Config.Kinesis.TopicConfigs {
+		consumer := newStreamConsumer(streamConsumerArgs{
+			Client:       client,
+			Checkpoints:  store,
+			Stream:       topicConfig.Topic,
+			TcFmtMap:     tcFmtMap,
+			PollInterval: time.Duration(settings.Config.Kinesis.PollIntervalMs) * time.Millisecond,
+		})
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := consumer.consume(ctx); err != nil {
+				log.WithError(err).WithField("stream", consumer.Stream).Fatal("failed to consume kinesis stream")
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+type streamConsumerArgs struct {
+	Client       kinesisAPI
+	Checkpoints  *checkpoint.FileStore
+	Stream       string
+	TcFmtMap     *TcFmtMap
+	PollInterval time.Duration
+}
+
+// streamConsumer starts a reader for every shard of the stream, readers feed into a single processor so the stream is processed serially.
+type streamConsumer struct {
+	streamConsumerArgs
+
+	// shards is keyed by shard ID, the channel is closed once the shard has been fully read.
+	// Child shards (after a reshard) will wait for their parents so that the records stay in order.
+	shards map[string]chan struct{}
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+}
+
+func newStreamConsumer(args streamConsumerArgs) *streamConsumer {
+	return &streamConsumer{
+		streamConsumerArgs: args,
+		shards:             make(map[string]chan struct{}),
+	}
+}
+
+func (s *streamConsumer) groupID() string {
+	return fmt.Sprintf("transfer_%s", s.Stream)
+}
+
+// consume returns once ctx is done and every shard reader has stopped.
+func (s *streamConsumer) consume(ctx context.Context) error {
+	msgs := make(chan artie.Message)
+	if err := s.startShards(ctx, msgs); err != nil {
+		return err
+	}
+
+	assigned.Store(true)
+
+	processed := make(chan struct{})
+	go func() {
+		defer close(processed)
+		s.processMessages(ctx, msgs)
+	}()
+
+	s.wg.Wait()
+	close(msgs)
+	<-processed
+	return nil
+}
+
+func (s *streamConsumer) listShards(ctx context.Context) ([]types.Shard, error) {
+	var shards []types.Shard
+	input := &kinesis.ListShardsInput{StreamName: aws.String(s.Stream)}
+	for {
+		out, err := s.Client.ListShards(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list shards, stream: %s, err: %v", s.Stream, err)
+		}
+
+		shards = append(shards, out.Shards...)
+		if out.NextToken == nil {
+			return shards, nil
+		}
+
+		// StreamName cannot be set alongside NextToken.
+		input = &kinesis.ListShardsInput{NextToken: out.NextToken}
+	}
+}
+
+// startShards will start a reader for every shard that we are not reading yet.
+func (s *streamConsumer) startShards(ctx context.Context, msgs chan<- artie.Message) error {
+	shards, err := s.listShards(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var newShards []types.Shard
+	for _, shard := range shards {
+		shardID := aws.ToString(shard.ShardId)
+		if _, isOk := s.shards[shardID]; !isOk {
+			s.shards[shardID] = make(chan struct{})
+			newShards = append(newShards, shard)
+		}
+	}
+
+	for _, shard := range newShards {
+		var parents []chan struct{}
+		for _, parentID := range []*string{shard.ParentShardId, shard.AdjacentParentShardId} {
+			// The parent may have already expired, in which case there is nothing to wait for.
+			if parent, isOk := s.shards[aws.ToString(parentID)]; isOk {
+				parents = append(parents, parent)
+			}
+		}
+
+		s.wg.Add(1)
+		go func(shardID string, done chan struct{}, parents []chan struct{}) {
+			defer s.wg.Done()
+			for _, parent := range parents {
+				select {
+				case <-parent:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if s.readShard(ctx, shardID, msgs) {
+				close(done)
+				// The shard has been closed by a reshard, pick up its children.
+				if err := s.startShards(ctx, msgs); err != nil {
+					logger.FromContext(ctx).WithError(err).Warn("failed to start child shards")
+				}
+			}
+		}(aws.ToString(shard.ShardId), s.shards[aws.ToString(shard.ShardId)], parents)
+	}
+
+	return nil
+}
+
+func (s *streamConsumer) shardIterator(ctx context.Context, shardID, afterSequenceNumber string) (*string, error) {
+	input := &kinesis.GetShardIteratorInput{
+		StreamName:        aws.String(s.Stream),
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: types.ShardIteratorTypeTrimHorizon,
+	}
+
+	if afterSequenceNumber != "" {
+		input.ShardIteratorType = types.ShardIteratorTypeAfterSequenceNumber
+		input.StartingSequenceNumber = aws.String(afterSequenceNumber)
+	}
+
+	out, err := s.Client.GetShardIterator(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shard iterator, stream: %s, shard: %s, err: %v", s.Stream, shardID, err)
+	}
+
+	return out.ShardIterator, nil
+}
+
+// readShard reads the shard from its last checkpoint and returns true once the shard has been closed and fully read.
+func (s *streamConsumer) readShard(ctx context.Context, shardID string, msgs chan<- artie.Message) bool {
+	log := logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"stream": s.Stream,
+		"shard":  shardID,
+	})
+
+	// lastSequenceNumber is what we have read, which may be ahead of the checkpoint. If the iterator expires, we'll continue from here.
+	lastSequenceNumber, _ := s.Checkpoints.Get(s.Stream, shardID)
+	var iterator *string
+	for {
+		if iterator == nil {
+			var err error
+			iterator, err = s.shardIterator(ctx, shardID, lastSequenceNumber)
+			if err != nil {
+				if ctx.Err() != nil {
+					return false
+				}
+
+				log.WithError(err).Warn("failed to get shard iterator, retrying...")
+				if !sleep(ctx, s.PollInterval) {
+					return false
+				}
+				continue
+			}
+		}
+
+		out, err := s.Client.GetRecords(ctx, &kinesis.GetRecordsInput{ShardIterator: iterator})
+		if err != nil {
+			if ctx.Err() != nil {
+				return false
+			}
+
+			var expiredErr *types.ExpiredIteratorException
+			if errors.As(err, &expiredErr) {
+				iterator = nil
+				continue
+			}
+
+			log.WithError(err).Warn("failed to get kinesis records, retrying...")
+			if !sleep(ctx, s.PollInterval) {
+				return false
+			}
+			continue
+		}
+
+		for _, record := range out.Records {
+			select {
+			case msgs <- artie.NewKinesisMessage(record, s.Stream, shardID):
+				lastSequenceNumber = aws.ToString(record.SequenceNumber)
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if out.NextShardIterator == nil {
+			log.Info("Kinesis shard has been closed")
+			return true
+		}
+
+		iterator = out.NextShardIterator
+		wait := minGetRecordsInterval
+		if len(out.Records) == 0 {
+			wait = s.PollInterval
+		}
+
+		if !sleep(ctx, wait) {
+			return false
+		}
+	}
+}
+
+func (s *streamConsumer) processMessages(ctx context.Context, msgs <-chan artie.Message) {
+	log := logger.FromContext(ctx)
+	for msg := range msgs {
+		msg := msg
+		tableName, processErr := processMessage(ctx, ProcessArgs{
+			Msg:                    msg,
+			GroupID:                s.groupID(),
+			TopicToConfigFormatMap: s.TcFmtMap,
+		})
+
+		msg.EmitIngestionLag(ctx, s.groupID(), tableName)
+		if processErr != nil {
+			logFields := map[string]interface{}{
+				"stream":         msg.Topic(),
+				"shard":          msg.Partition(),
+				"sequenceNumber": aws.ToString(msg.Kinesis.SequenceNumber),
+				"key":            string(msg.Key()),
+				"value":          string(msg.Value()),
+			}
+
+			if errors.Is(processErr, errDeadLetterFailed) {
+				// Stop processing, so nothing past this record is checkpointed. The shard readers will stop since ctx is done.
+				log.WithError(processErr).WithFields(logFields).Warn("failed to dead letter record, stopping...")
+				return
+			}
+
+			log.WithError(processErr).WithFields(logFields).Warn("skipping message...")
+		}
+	}
+}
+
+// sleep returns false if ctx is done before d has elapsed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
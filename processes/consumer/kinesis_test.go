@@ -0,0 +1,207 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/cdc/mongo"
+	"github.com/artie-labs/transfer/lib/checkpoint"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/models"
+)
+
+type fakeShard struct {
+	types.Shard
+	records []types.Record
+	// closed shards will return a nil NextShardIterator once every record has been read.
+	closed bool
+}
+
+// fakeKinesis serves the shards from memory, iterators are `shardID:index`.
+type fakeKinesis struct {
+	shards []fakeShard
+
+	iteratorInputs []*kinesis.GetShardIteratorInput
+	sync.Mutex
+}
+
+func (f *fakeKinesis) ListShards(_ context.Context, _ *kinesis.ListShardsInput, _ ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error) {
+	var shards []types.Shard
+	for _, shard := range f.shards {
+		shards = append(shards, shard.Shard)
+	}
+
+	return &kinesis.ListShardsOutput{Shards: shards}, nil
+}
+
+func (f *fakeKinesis) shard(shardID string) fakeShard {
+	for _, shard := range f.shards {
+		if aws.ToString(shard.ShardId) == shardID {
+			return shard
+		}
+	}
+
+	panic(fmt.Sprintf("shard: %s does not exist", shardID))
+}
+
+func (f *fakeKinesis) GetShardIterator(_ context.Context, params *kinesis.GetShardIteratorInput, _ ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error) {
+	f.Lock()
+	f.iteratorInputs = append(f.iteratorInputs, params)
+	f.Unlock()
+
+	var idx int
+	if params.ShardIteratorType == types.ShardIteratorTypeAfterSequenceNumber {
+		for i, record := range f.shard(aws.ToString(params.ShardId)).records {
+			if aws.ToString(record.SequenceNumber) == aws.ToString(params.StartingSequenceNumber) {
+				idx = i + 1
+			}
+		}
+	}
+
+	return &kinesis.GetShardIteratorOutput{ShardIterator: aws.String(fmt.Sprintf("%s:%d", aws.ToString(params.ShardId), idx))}, nil
+}
+
+func (f *fakeKinesis) GetRecords(_ context.Context, params *kinesis.GetRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error) {
+	parts := strings.Split(aws.ToString(params.ShardIterator), ":")
+	idx, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	shard := f.shard(parts[0])
+	out := &kinesis.GetRecordsOutput{
+		Records:           shard.records[idx:],
+		NextShardIterator: aws.String(fmt.Sprintf("%s:%d", parts[0], len(shard.records))),
+	}
+
+	if shard.closed {
+		out.NextShardIterator = nil
+	}
+
+	return out, nil
+}
+
+func kinesisRecord(id int, sequenceNumber string) types.Record {
+	now := time.Now()
+	return types.Record{
+		PartitionKey:                aws.String(fmt.Sprintf("Struct{id=%d}", id)),
+		SequenceNumber:              aws.String(sequenceNumber),
+		ApproximateArrivalTimestamp: &now,
+		Data: []byte(fmt.Sprintf(`{
+	"schema": {},
+	"payload": {
+		"before": null,
+		"after": "{\"_id\": {\"$numberLong\": \"%d\"},\"name\": \"name_%s\"}",
+		"source": {"connector": "mongodb", "ts_ms": 1668753321000, "db": "inventory", "collection": "customers"},
+		"op": "c"
+	}
+}`, id, sequenceNumber)),
+	}
+}
+
+func (f *FlushTestSuite) TestKinesisStreamConsumer() {
+	client := &fakeKinesis{
+		shards: []fakeShard{
+			{
+				Shard:   types.Shard{ShardId: aws.String("shardId-0")},
+				records: []types.Record{kinesisRecord(1, "1"), kinesisRecord(2, "2")},
+				closed:  true,
+			},
+			{
+				// This is a child of shardId-0, so it will only be read once the parent has been fully read.
+				Shard:   types.Shard{ShardId: aws.String("shardId-1"), ParentShardId: aws.String("shardId-0")},
+				records: []types.Record{kinesisRecord(1, "3")},
+			},
+		},
+	}
+
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	tcFmtMap.Add("stream", TopicConfigFormatter{
+		tc: &kafkalib.TopicConfig{
+			Database:     "db",
+			Schema:       "public",
+			Topic:        "stream",
+			CDCKeyFormat: "org.apache.kafka.connect.storage.StringConverter",
+		},
+		Format: &mgo,
+	})
+
+	store, err := checkpoint.NewFileStore(filepath.Join(f.T().TempDir(), "checkpoints.json"))
+	assert.NoError(f.T(), err)
+	kinesisCheckpoints = store
+
+	args := streamConsumerArgs{
+		Client:       client,
+		Checkpoints:  store,
+		Stream:       "stream",
+		TcFmtMap:     tcFmtMap,
+		PollInterval: 10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(f.ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(f.T(), newStreamConsumer(args).consume(ctx))
+	}()
+
+	td := models.GetMemoryDB(f.ctx).GetOrCreateTableData(models.TableKey("db", "public", "customers"))
+	assert.Eventually(f.T(), func() bool {
+		td.Lock()
+		defer td.Unlock()
+		return td.Rows() == 2 && len(td.PartitionsToLastMessage) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	// The child shard has the latest version of id=1.
+	td.Lock()
+	assert.Equal(f.T(), "name_3", td.RowsData()["_id=1"]["name"])
+	td.Unlock()
+
+	assert.NoError(f.T(), Flush(Args{Context: f.ctx, Reason: "test"}))
+	assert.True(f.T(), td.Empty())
+
+	sequenceNumber, isOk := store.Get("stream", "shardId-0")
+	assert.True(f.T(), isOk)
+	assert.Equal(f.T(), "2", sequenceNumber)
+
+	sequenceNumber, isOk = store.Get("stream", "shardId-1")
+	assert.True(f.T(), isOk)
+	assert.Equal(f.T(), "3", sequenceNumber)
+
+	// On restart, we should resume after the checkpoints.
+	client.iteratorInputs = nil
+	ctx, cancel = context.WithCancel(f.ctx)
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(f.T(), newStreamConsumer(args).consume(ctx))
+	}()
+
+	assert.Eventually(f.T(), func() bool {
+		client.Lock()
+		defer client.Unlock()
+		return len(client.iteratorInputs) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	for _, input := range client.iteratorInputs {
+		assert.Equal(f.T(), types.ShardIteratorTypeAfterSequenceNumber, input.ShardIteratorType)
+	}
+	assert.True(f.T(), td.Empty())
+}
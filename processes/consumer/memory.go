@@ -0,0 +1,71 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/logger"
+	"github.com/artie-labs/transfer/lib/telemetry/metrics"
+	"github.com/artie-labs/transfer/models"
+)
+
+// backpressureRetryInterval is how long we wait before flushing again when the merges could not bring us back under budget.
+const backpressureRetryInterval = 5 * time.Second
+
+// budgetMu ensures that only one worker is flushing for the memory budget at a time, the rest will wait behind it.
+var budgetMu sync.Mutex
+
+// waitForMemory blocks while the rows buffered across every table exceed the memory budget.
+// The first worker over budget flushes the largest tables and every other worker that is over budget waits behind it, so we stop fetching rather
+// than buffering without bound. The check is not atomic with buffering the message, since the size of the message is not known until it has been
+// parsed. Workers that are under budget run concurrently, so the budget can be exceeded by up to one message per worker (partition or shard).
+func waitForMemory(ctx context.Context) {
+	budget := config.FromContext(ctx).Config.MemoryBudgetKb * 1024
+	if budget <= 0 {
+		return
+	}
+
+	db := models.GetMemoryDB(ctx)
+	if db.ApproxSize() <= budget {
+		return
+	}
+
+	budgetMu.Lock()
+	defer budgetMu.Unlock()
+
+	start := time.Now()
+	defer func() {
+		metrics.FromContext(ctx).Timing("memory.backpressure", time.Since(start), nil)
+	}()
+
+	for db.ApproxSize() > budget {
+		flushLargestTables(ctx, budget)
+		if db.ApproxSize() <= budget {
+			return
+		}
+
+		logger.FromContext(ctx).WithFields(map[string]interface{}{
+			"approxSizeKb": db.ApproxSize() / 1024,
+			"budgetKb":     budget / 1024,
+		}).Warn("still over the memory budget after flushing, pausing consumption...")
+		if !sleep(ctx, backpressureRetryInterval) {
+			return
+		}
+	}
+}
+
+// flushLargestTables flushes one table at a time, largest first, until we are under budget.
+func flushLargestTables(ctx context.Context, budget int) {
+	db := models.GetMemoryDB(ctx)
+	for _, tableKey := range db.TablesBySize() {
+		if db.ApproxSize() <= budget {
+			return
+		}
+
+		if err := Flush(Args{Context: ctx, Reason: "memory_budget", SpecificTable: tableKey}); err != nil {
+			logger.FromContext(ctx).WithError(err).WithField("tableName", tableKey).Warn("failed to flush table")
+		}
+	}
+}
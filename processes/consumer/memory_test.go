@@ -0,0 +1,58 @@
+package consumer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/models"
+	"github.com/artie-labs/transfer/models/event"
+)
+
+func (f *FlushTestSuite) saveRows(tableName string, rows int, value string) {
+	for i := 0; i < rows; i++ {
+		evt := event.Event{
+			Table: tableName,
+			PrimaryKeyMap: map[string]interface{}{
+				"id": fmt.Sprintf("pk-%d", i),
+			},
+			Data: map[string]interface{}{
+				constants.DeleteColumnMarker: false,
+				"id":                         fmt.Sprintf("pk-%d", i),
+				"value":                      value,
+			},
+		}
+
+		kafkaMsg := kafka.Message{Topic: topicConfig.Topic, Partition: 1, Offset: int64(i)}
+		_, _, err := evt.Save(f.ctx, topicConfig, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+		assert.NoError(f.T(), err)
+	}
+}
+
+func (f *FlushTestSuite) TestWaitForMemory() {
+	f.saveRows("large", 10, strings.Repeat("a", 500))
+	f.saveRows("medium", 2, strings.Repeat("b", 100))
+	f.saveRows("small", 1, "c")
+
+	db := models.GetMemoryDB(f.ctx)
+	assert.Equal(f.T(), []string{tableKey("large"), tableKey("medium"), tableKey("small")}, db.TablesBySize())
+
+	// Without a budget, nothing is flushed.
+	waitForMemory(f.ctx)
+	assert.Equal(f.T(), 0, f.fakeStore.ExecCallCount())
+
+	// Flushing the largest table is enough to get back under budget.
+	config.FromContext(f.ctx).Config.MemoryBudgetKb = 1
+
+	waitForMemory(f.ctx)
+	assert.True(f.T(), db.GetOrCreateTableData(tableKey("large")).Empty())
+	assert.False(f.T(), db.GetOrCreateTableData(tableKey("medium")).Empty())
+	assert.False(f.T(), db.GetOrCreateTableData(tableKey("small")).Empty())
+	assert.LessOrEqual(f.T(), db.ApproxSize(), 1024)
+	assert.Equal(f.T(), 1, f.fakeConsumer.CommitMessagesCallCount())
+}
@@ -6,7 +6,10 @@ import (
 	"time"
 
 	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/logger"
 	"github.com/artie-labs/transfer/lib/telemetry/metrics"
+	"github.com/artie-labs/transfer/models"
 	"github.com/artie-labs/transfer/models/event"
 )
 
@@ -25,6 +28,9 @@ func processMessage(ctx context.Context, processArgs ProcessArgs) (string, error
 		return "", fmt.Errorf("failed to process, topicConfig is nil")
 	}
 
+	// Every partition (or shard) is processed serially by its own worker, so holding the message here also pauses fetching it until there is room in memory.
+	waitForMemory(ctx)
+
 	tags := map[string]string{
 		"groupID": processArgs.GroupID,
 		"topic":   processArgs.Msg.Topic(),
@@ -47,38 +53,76 @@ func processMessage(ctx context.Context, processArgs ProcessArgs) (string, error
 	pkMap, err := topicConfig.GetPrimaryKey(ctx, processArgs.Msg.Key(), topicConfig.tc)
 	if err != nil {
 		tags["what"] = "marshall_pk_err"
-		return "", fmt.Errorf("cannot unmarshall key, key: %s, err: %v", string(processArgs.Msg.Key()), err)
+		return "", topicConfig.deadLetter(ctx, processArgs.Msg, tags,
+			fmt.Errorf("cannot unmarshall key, key: %s, err: %v", logger.RedactValue(ctx, string(processArgs.Msg.Key())), err))
 	}
 
 	_event, err := topicConfig.GetEventFromBytes(ctx, processArgs.Msg.Value())
 	if err != nil {
 		tags["what"] = "marshall_value_err"
-		return "", fmt.Errorf("cannot unmarshall event, err: %v", err)
+		return "", topicConfig.deadLetter(ctx, processArgs.Msg, tags, fmt.Errorf("cannot unmarshall event, err: %v", err))
 	}
 
 	tags["op"] = _event.Operation()
-	evt := event.ToMemoryEvent(ctx, _event, pkMap, topicConfig.tc)
+	// Topics with more than one table may have per-table overrides, and the destination's names may be templated from the source.
+	source := _event.GetSourceMetadata()
+	sourceNames := kafkalib.SourceNames{
+		Database: source.Database,
+		Schema:   source.Schema,
+		Table:    source.Table,
+		Topic:    processArgs.Msg.Topic(),
+	}
+
+	tc := topicConfig.tc.ForSource(source.Database, source.Schema, source.Table).ForDestination(sourceNames)
+	tags["database"] = tc.Database
+	tags["schema"] = tc.Schema
+	evt := event.ToMemoryEvent(ctx, _event, pkMap, tc)
+	// Sources that fan-in to the same table are told apart by the source identifier, which is part of the primary key.
+	evt.AddSourceIdentifier(tc.SourceIdentifier, sourceNames)
 	// Table name is only available after event has been casted
 	tags["table"] = evt.Table
 
+	filter, err := tc.RowFilter()
+	if err != nil {
+		tags["what"] = "filter_err"
+		return "", topicConfig.deadLetter(ctx, processArgs.Msg, tags, err)
+	}
+
+	filtered, err := evt.ApplyFilter(filter, _event.Operation())
+	if err != nil {
+		tags["what"] = "filter_err"
+		return "", topicConfig.deadLetter(ctx, processArgs.Msg, tags, fmt.Errorf("failed to evaluate filter, err: %v", err))
+	}
+
+	if filtered {
+		// The row is not buffered, but the message still needs to be committed along with the table.
+		tags["filtered"] = "yes"
+		if err = evt.Skip(ctx, tc, processArgs.Msg); err != nil {
+			tags["what"] = "skip_fail"
+			return "", fmt.Errorf("failed to skip event, err: %v", err)
+		}
+
+		return evt.Table, nil
+	}
+
 	// Check to see if we should skip first
 	// This way, we can emit a specific tag to be more clear
-	if evt.ShouldSkip(topicConfig.tc.SkipDelete) {
+	if evt.ShouldSkip(tc.SkipDelete) {
 		tags["skipped"] = "yes"
 		return evt.Table, nil
 	}
 
-	shouldFlush, flushReason, err := evt.Save(ctx, topicConfig.tc, processArgs.Msg)
+	shouldFlush, flushReason, err := evt.Save(ctx, tc, processArgs.Msg)
 	if err != nil {
 		tags["what"] = "save_fail"
-		return "", fmt.Errorf("event failed to save, err: %v", err)
+		return "", topicConfig.deadLetter(ctx, processArgs.Msg, tags, fmt.Errorf("event failed to save, err: %v", err))
 	}
 
 	if shouldFlush {
 		return evt.Table, Flush(Args{
 			Context:       ctx,
 			Reason:        flushReason,
-			SpecificTable: evt.Table,
+			SpecificTable: models.TableKey(tc.Database, tc.Schema, evt.Table),
 		})
 	}
 
@@ -2,7 +2,10 @@ package consumer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -11,7 +14,11 @@ import (
 	"github.com/artie-labs/transfer/lib/cdc/mongo"
 	"github.com/artie-labs/transfer/lib/config"
 	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/dlq"
 	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/lib/mocks"
+	"github.com/artie-labs/transfer/lib/ptr"
+	"github.com/artie-labs/transfer/lib/typing"
 	"github.com/artie-labs/transfer/models"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
@@ -183,12 +190,12 @@ func TestProcessMessageFailures(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, table, tableName)
 
-		td := memoryDB.GetOrCreateTableData(table)
+		td := memoryDB.GetOrCreateTableData(models.TableKey(db, schema, table))
 		// Check that there are corresponding row(s) in the memory DB
 		assert.Equal(t, len(td.RowsData()), idx)
 	}
 
-	td := memoryDB.GetOrCreateTableData(table)
+	td := memoryDB.GetOrCreateTableData(models.TableKey(db, schema, table))
 
 	// Tombstone means deletion
 	val, isOk := td.RowsData()["_id=1"][constants.DeleteColumnMarker]
@@ -345,7 +352,7 @@ func TestProcessMessageSkip(t *testing.T) {
 			TopicToConfigFormatMap: tcFmtMap,
 		}
 
-		td := memoryDB.GetOrCreateTableData(table)
+		td := memoryDB.GetOrCreateTableData(models.TableKey(db, schema, table))
 		assert.Equal(t, 0, int(td.Rows()))
 
 		tableName, err := processMessage(ctx, processArgs)
@@ -355,3 +362,546 @@ func TestProcessMessageSkip(t *testing.T) {
 		assert.Equal(t, 0, int(td.Rows()))
 	}
 }
+
+func TestProcessMessageDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	ctx = config.InjectSettingsIntoContext(ctx, &config.Settings{
+		Config: &config.Config{
+			FlushIntervalSeconds: 10,
+			BufferRows:           10,
+			FlushSizeKb:          900,
+		},
+		VerboseLogging: false,
+	})
+
+	ctx = models.LoadMemoryDB(ctx)
+	kafkaMsg := kafka.Message{
+		Topic:     "foo",
+		Partition: 2,
+		Offset:    99,
+		Key:       []byte("Struct{id=1}"),
+		Value:     []byte("not a json object"),
+	}
+
+	fakeConsumer := &mocks.FakeConsumer{}
+	SetKafkaConsumer(map[string]kafkalib.Consumer{"foo": fakeConsumer})
+
+	msg := artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic)
+	path := filepath.Join(t.TempDir(), "dlq.jsonl")
+	writer, err := dlq.NewFileWriter(path)
+	assert.NoError(t, err)
+
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	tcFmtMap.Add(msg.Topic(), TopicConfigFormatter{
+		tc: &kafkalib.TopicConfig{
+			Database:     "lemonade",
+			TableName:    "orders",
+			Schema:       "public",
+			Topic:        msg.Topic(),
+			CDCKeyFormat: "org.apache.kafka.connect.storage.StringConverter",
+		},
+		Format: &mgo,
+		dlq:    writer,
+	})
+
+	tableName, err := processMessage(ctx, ProcessArgs{
+		Msg:                    msg,
+		GroupID:                "foo",
+		TopicToConfigFormatMap: tcFmtMap,
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot unmarshall event")
+	assert.Contains(t, err.Error(), "written to the dead letter queue")
+	assert.Empty(t, tableName)
+	assert.NoError(t, writer.Close())
+
+	// Nothing is buffered from the partition, so the message is committed right away rather than creating a table without primary keys.
+	assert.Empty(t, models.GetMemoryDB(ctx).TableData())
+	assert.Equal(t, 1, fakeConsumer.CommitMessagesCallCount())
+	_, committed := fakeConsumer.CommitMessagesArgsForCall(0)
+	assert.Equal(t, []kafka.Message{kafkaMsg}, committed)
+
+	bytes, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	var record dlq.Record
+	assert.NoError(t, json.Unmarshal(bytes, &record))
+	assert.Equal(t, kafkaMsg.Key, record.Key)
+	assert.Equal(t, kafkaMsg.Value, record.Value)
+	assert.Equal(t, "foo", record.Topic)
+	assert.Equal(t, "2", record.Partition)
+	assert.Equal(t, int64(99), record.Offset)
+	assert.Contains(t, record.Reason, "cannot unmarshall event")
+}
+
+func TestProcessMessageTableOverrides(t *testing.T) {
+	ctx := context.Background()
+	ctx = config.InjectSettingsIntoContext(ctx, &config.Settings{
+		Config: &config.Config{
+			FlushIntervalSeconds: 10,
+			BufferRows:           10,
+			FlushSizeKb:          900,
+		},
+		VerboseLogging: false,
+	})
+
+	ctx = models.LoadMemoryDB(ctx)
+	kafkaMsg := kafka.Message{
+		Topic: "routed",
+		Key:   []byte("Struct{id=1}"),
+		Value: []byte(`{
+	"schema": {},
+	"payload": {
+		"before": "{\"_id\": {\"$numberLong\": \"1004\"},\"first_name\": \"Anne\"}",
+		"after": null,
+		"source": {
+			"connector": "mongodb",
+			"ts_ms": 1668753321000,
+			"db": "inventory",
+			"collection": "customers"
+		},
+		"op": "d"
+	}
+}`),
+	}
+
+	msg := artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic)
+
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	tcFmtMap.Add(msg.Topic(), TopicConfigFormatter{
+		tc: &kafkalib.TopicConfig{
+			Database:     "lemonade",
+			Schema:       "public",
+			Topic:        msg.Topic(),
+			CDCKeyFormat: "org.apache.kafka.connect.storage.StringConverter",
+			SkipDelete:   true,
+			TableOverrides: []*kafkalib.TableOverride{
+				{
+					SourceDatabase: "inventory",
+					SourceTable:    "orders",
+					TableName:      "orders_v2",
+				},
+				{
+					SourceDatabase: "inventory",
+					SourceTable:    "customers",
+					TableName:      "customers_v2",
+					SkipDelete:     ptr.ToBool(false),
+				},
+			},
+		},
+		Format: &mgo,
+	})
+
+	tableName, err := processMessage(ctx, ProcessArgs{
+		Msg:                    msg,
+		GroupID:                "foo",
+		TopicToConfigFormatMap: tcFmtMap,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "customers_v2", tableName)
+
+	// The delete is not skipped, because the override has turned off SkipDelete for this table.
+	td := models.GetMemoryDB(ctx).GetOrCreateTableData(models.TableKey("lemonade", "public", "customers_v2"))
+	assert.Equal(t, 1, int(td.Rows()))
+	assert.Equal(t, "customers_v2", td.TopicConfig.TableName)
+	assert.False(t, td.TopicConfig.SkipDelete)
+	assert.Empty(t, td.TopicConfig.TableOverrides)
+}
+
+func TestProcessMessageFilter(t *testing.T) {
+	ctx := context.Background()
+	ctx = config.InjectSettingsIntoContext(ctx, &config.Settings{
+		Config: &config.Config{
+			FlushIntervalSeconds: 10,
+			BufferRows:           10,
+			FlushSizeKb:          900,
+		},
+		VerboseLogging: false,
+	})
+
+	ctx = models.LoadMemoryDB(ctx)
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	tcFmtMap.Add("filtered", TopicConfigFormatter{
+		tc: &kafkalib.TopicConfig{
+			Database:     "lemonade",
+			Schema:       "public",
+			TableName:    "customers",
+			Topic:        "filtered",
+			CDCKeyFormat: "org.apache.kafka.connect.storage.StringConverter",
+			Filter:       "tenant IN ('a', 'b') AND is_test = false",
+		},
+		Format: &mgo,
+	})
+
+	payload := func(op string, after string) []byte {
+		return []byte(fmt.Sprintf(`{
+	"schema": {},
+	"payload": {
+		"before": null,
+		"after": %q,
+		"source": {
+			"connector": "mongodb",
+			"ts_ms": 1668753321000,
+			"db": "inventory",
+			"collection": "customers"
+		},
+		"op": %q
+	}
+}`, after, op))
+	}
+
+	td := models.GetMemoryDB(ctx).GetOrCreateTableData(models.TableKey("lemonade", "public", "customers"))
+	type _tc struct {
+		name            string
+		key             string
+		op              string
+		after           string
+		expectedRows    int
+		expectedDeleted bool
+	}
+
+	tcs := []_tc{
+		{
+			name:         "filtered out insert",
+			key:          "Struct{id=1}",
+			op:           "c",
+			after:        `{"_id": {"$numberLong": "1"}, "tenant": "c", "is_test": false}`,
+			expectedRows: 0,
+		},
+		{
+			name:         "matching insert",
+			key:          "Struct{id=2}",
+			op:           "c",
+			after:        `{"_id": {"$numberLong": "2"}, "tenant": "a", "is_test": false}`,
+			expectedRows: 1,
+		},
+		{
+			name:            "update that no longer matches",
+			key:             "Struct{id=2}",
+			op:              "u",
+			after:           `{"_id": {"$numberLong": "2"}, "tenant": "a", "is_test": true}`,
+			expectedRows:    1,
+			expectedDeleted: true,
+		},
+	}
+
+	for idx, tc := range tcs {
+		kafkaMsg := kafka.Message{
+			Topic:  "filtered",
+			Offset: int64(idx),
+			Key:    []byte(tc.key),
+			Value:  payload(tc.op, tc.after),
+		}
+
+		msg := artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic)
+		tableName, err := processMessage(ctx, ProcessArgs{
+			Msg:                    msg,
+			GroupID:                "foo",
+			TopicToConfigFormatMap: tcFmtMap,
+		})
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, "customers", tableName, tc.name)
+		assert.Equal(t, tc.expectedRows, int(td.Rows()), tc.name)
+
+		// The message is still tracked, so the offset gets committed on the next flush.
+		assert.Equal(t, []artie.Message{msg}, td.PartitionsToLastMessage[msg.PartitionKey()], tc.name)
+		if tc.expectedRows > 0 {
+			assert.Equal(t, tc.expectedDeleted, td.RowsData()["_id=2"][constants.DeleteColumnMarker], tc.name)
+		}
+	}
+}
+
+func TestProcessMessageNameTemplates(t *testing.T) {
+	ctx := context.Background()
+	ctx = config.InjectSettingsIntoContext(ctx, &config.Settings{
+		Config: &config.Config{
+			FlushIntervalSeconds: 10,
+			BufferRows:           10,
+			FlushSizeKb:          900,
+		},
+		VerboseLogging: false,
+	})
+
+	ctx = models.LoadMemoryDB(ctx)
+	kafkaMsg := kafka.Message{
+		Topic: "templated",
+		Key:   []byte("Struct{id=1}"),
+		Value: []byte(`{
+	"schema": {},
+	"payload": {
+		"before": null,
+		"after": "{\"_id\": {\"$numberLong\": \"1004\"},\"first_name\": \"Anne\"}",
+		"source": {
+			"connector": "mongodb",
+			"ts_ms": 1668753321000,
+			"db": "prod_a",
+			"collection": "customers"
+		},
+		"op": "c"
+	}
+}`),
+	}
+
+	msg := artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic)
+
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	tcFmtMap.Add(msg.Topic(), TopicConfigFormatter{
+		tc: &kafkalib.TopicConfig{
+			Database:     "lemonade",
+			Schema:       "public",
+			Topic:        msg.Topic(),
+			CDCKeyFormat: "org.apache.kafka.connect.storage.StringConverter",
+			NameTemplates: &kafkalib.NameTemplates{
+				Database: "raw",
+				Schema:   "{source_db}",
+				Case:     kafkalib.NameCaseUpper,
+			},
+		},
+		Format: &mgo,
+	})
+
+	tableName, err := processMessage(ctx, ProcessArgs{
+		Msg:                    msg,
+		GroupID:                "foo",
+		TopicToConfigFormatMap: tcFmtMap,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "CUSTOMERS", tableName)
+
+	// The table is buffered under the destination's names, which are also used for the merge and temporary tables.
+	td := models.GetMemoryDB(ctx).GetOrCreateTableData(models.TableKey("RAW", "PROD_A", "CUSTOMERS"))
+	assert.Equal(t, 1, int(td.Rows()))
+	assert.Equal(t, "RAW.PROD_A.CUSTOMERS", td.ToFqName(ctx, constants.Snowflake, true))
+}
+
+func TestProcessMessageSourceIdentifier(t *testing.T) {
+	ctx := context.Background()
+	ctx = config.InjectSettingsIntoContext(ctx, &config.Settings{
+		Config: &config.Config{
+			FlushIntervalSeconds: 10,
+			BufferRows:           10,
+			FlushSizeKb:          900,
+		},
+		VerboseLogging: false,
+	})
+
+	ctx = models.LoadMemoryDB(ctx)
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	for _, topic := range []string{"shard_1.customers", "shard_2.customers"} {
+		tcFmtMap.Add(topic, TopicConfigFormatter{
+			tc: &kafkalib.TopicConfig{
+				Database:         "lemonade",
+				Schema:           "public",
+				TableName:        "customers",
+				Topic:            topic,
+				CDCKeyFormat:     "org.apache.kafka.connect.storage.StringConverter",
+				SourceIdentifier: &kafkalib.SourceIdentifier{Column: "Shard", Kind: kafkalib.SourceIdentifierTopic},
+			},
+			Format: &mgo,
+		})
+	}
+
+	// Both shards have a row with the same primary key.
+	for _, topic := range []string{"shard_1.customers", "shard_2.customers"} {
+		kafkaMsg := kafka.Message{
+			Topic: topic,
+			Key:   []byte("Struct{id=1}"),
+			Value: []byte(`{
+	"schema": {},
+	"payload": {
+		"before": null,
+		"after": "{\"_id\": {\"$numberLong\": \"1004\"},\"first_name\": \"Anne\"}",
+		"source": {
+			"connector": "mongodb",
+			"ts_ms": 1668753321000,
+			"db": "inventory",
+			"collection": "customers"
+		},
+		"op": "c"
+	}
+}`),
+		}
+
+		tableName, err := processMessage(ctx, ProcessArgs{
+			Msg:                    artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic),
+			GroupID:                "foo",
+			TopicToConfigFormatMap: tcFmtMap,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "customers", tableName)
+	}
+
+	td := models.GetMemoryDB(ctx).GetOrCreateTableData(models.TableKey("lemonade", "public", "customers"))
+	assert.Equal(t, 2, int(td.Rows()))
+	assert.Equal(t, "shard_2.customers", td.RowsData()["_id=1shard=shard_2.customers"]["shard"])
+
+	var pks []string
+	for _, pk := range td.PrimaryKeys(ctx, nil) {
+		pks = append(pks, pk.RawName())
+	}
+
+	// The source identifier is part of the primary keys, which are used for the merge.
+	assert.Equal(t, []string{"_id", "shard"}, pks)
+
+	col, isOk := td.ReadOnlyInMemoryCols().GetColumn("shard")
+	assert.True(t, isOk)
+	assert.Equal(t, typing.String, col.KindDetails)
+}
+
+func TestProcessMessageDeadLetterFirstInBatch(t *testing.T) {
+	ctx := context.Background()
+	ctx = config.InjectSettingsIntoContext(ctx, &config.Settings{
+		Config: &config.Config{
+			FlushIntervalSeconds: 10,
+			BufferRows:           10,
+			FlushSizeKb:          900,
+		},
+		VerboseLogging: false,
+	})
+
+	ctx = models.LoadMemoryDB(ctx)
+	fakeConsumer := &mocks.FakeConsumer{}
+	SetKafkaConsumer(map[string]kafkalib.Consumer{"customers": fakeConsumer})
+
+	writer, err := dlq.NewFileWriter(filepath.Join(t.TempDir(), "dlq.jsonl"))
+	assert.NoError(t, err)
+	defer writer.Close()
+
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	tcFmtMap.Add("customers", TopicConfigFormatter{
+		tc: &kafkalib.TopicConfig{
+			Database:     "lemonade",
+			Schema:       "public",
+			Topic:        "customers",
+			CDCKeyFormat: "org.apache.kafka.connect.storage.StringConverter",
+		},
+		Format: &mgo,
+		dlq:    writer,
+	})
+
+	value := []byte(`{
+	"schema": {},
+	"payload": {
+		"before": null,
+		"after": "{\"_id\": {\"$numberLong\": \"1004\"},\"first_name\": \"Anne\"}",
+		"source": {
+			"connector": "mongodb",
+			"ts_ms": 1668753321000,
+			"db": "inventory",
+			"collection": "customers"
+		},
+		"op": "c"
+	}
+}`)
+
+	process := func(kafkaMsg kafka.Message) error {
+		_, processErr := processMessage(ctx, ProcessArgs{
+			Msg:                    artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic),
+			GroupID:                "foo",
+			TopicToConfigFormatMap: tcFmtMap,
+		})
+		return processErr
+	}
+
+	// The dead lettered message is the first one in the batch.
+	assert.ErrorContains(t, process(kafka.Message{Topic: "customers", Partition: 1, Offset: 1, Key: []byte("Struct{id=1004}"), Value: []byte("not a json object")}),
+		"written to the dead letter queue")
+	assert.Equal(t, 1, fakeConsumer.CommitMessagesCallCount())
+
+	// The row after it is still merged with its primary keys.
+	assert.NoError(t, process(kafka.Message{Topic: "customers", Partition: 1, Offset: 2, Key: []byte("Struct{id=1004}"), Value: value}))
+	td := models.GetMemoryDB(ctx).GetOrCreateTableData(models.TableKey("lemonade", "public", "customers"))
+	var pks []string
+	for _, pk := range td.PrimaryKeys(ctx, nil) {
+		pks = append(pks, pk.RawName())
+	}
+
+	assert.Equal(t, []string{"_id"}, pks)
+	assert.Len(t, models.GetMemoryDB(ctx).TableData(), 1)
+
+	// Whereas a message that is dead lettered after it is committed along with the row, since the row has not been merged yet.
+	deadLettered := kafka.Message{Topic: "customers", Partition: 1, Offset: 3, Key: []byte("Struct{id=1004}"), Value: []byte("not a json object")}
+	assert.ErrorContains(t, process(deadLettered), "written to the dead letter queue")
+	assert.Equal(t, 1, fakeConsumer.CommitMessagesCallCount())
+	assert.Equal(t, uint(1), td.Rows())
+	assert.Equal(t, []artie.Message{artie.NewMessage(&deadLettered, nil, deadLettered.Topic)}, td.PartitionsToLastMessage[artie.ToPartitionKey("customers", "1")])
+	assert.Len(t, models.GetMemoryDB(ctx).TableData(), 1)
+}
+
+type failingWriter struct {
+	writes int
+	closed bool
+}
+
+func (f *failingWriter) Write(_ context.Context, _ dlq.Record) error {
+	f.writes += 1
+	return fmt.Errorf("dead letter queue is unavailable")
+}
+
+func (f *failingWriter) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestProcessMessageDeadLetterFailure(t *testing.T) {
+	ctx := context.Background()
+	ctx = config.InjectSettingsIntoContext(ctx, &config.Settings{
+		Config: &config.Config{
+			FlushIntervalSeconds: 10,
+			BufferRows:           10,
+			FlushSizeKb:          900,
+		},
+		VerboseLogging: false,
+	})
+
+	ctx = models.LoadMemoryDB(ctx)
+	kafkaMsg := kafka.Message{
+		Topic:     "foo",
+		Partition: 2,
+		Offset:    99,
+		Key:       []byte("Struct{id=1}"),
+		Value:     []byte("not a json object"),
+	}
+
+	writer := &failingWriter{}
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	tcFmtMap.Add(kafkaMsg.Topic, TopicConfigFormatter{
+		tc: &kafkalib.TopicConfig{
+			Database:     "lemonade",
+			TableName:    "orders",
+			Schema:       "public",
+			Topic:        kafkaMsg.Topic,
+			CDCKeyFormat: "org.apache.kafka.connect.storage.StringConverter",
+		},
+		Format: &mgo,
+		dlq:    writer,
+	})
+
+	// The write is retried until ctx is done, rather than moving past the message.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	_, err := processMessage(timeoutCtx, ProcessArgs{
+		Msg:                    artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic),
+		GroupID:                "foo",
+		TopicToConfigFormatMap: tcFmtMap,
+	})
+	assert.ErrorIs(t, err, errDeadLetterFailed)
+	assert.Greater(t, writer.writes, 1)
+
+	// The message is not tracked, so it will not be committed.
+	assert.True(t, models.GetMemoryDB(ctx).GetOrCreateTableData(models.TableKey("lemonade", "public", "orders")).Empty())
+}
+
+func TestCloseDeadLetterWriters(t *testing.T) {
+	writer := &failingWriter{}
+	assert.Equal(t, writer, addDeadLetterWriter(writer))
+	assert.NoError(t, closeDeadLetterWriters())
+	assert.True(t, writer.closed)
+}
@@ -2,6 +2,7 @@ package consumer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -45,6 +46,10 @@ func findOrCreateSubscription(ctx context.Context, client *gcp_pubsub.Client, to
 		}
 	}
 
+	// Messages are acked once their rows have been flushed, until then the client library will keep extending their ack deadline.
+	// This is always set, since the client library's default may be shorter than our flush interval.
+	sub.ReceiveSettings.MaxExtension = config.FromContext(ctx).Config.PubsubMaxAckExtension()
+
 	// This should be the same as our buffer rows so we don't limit our processing throughput
 	sub.ReceiveSettings.MaxOutstandingMessages = int(config.FromContext(ctx).Config.BufferRows) + 1
 
@@ -55,6 +60,25 @@ func findOrCreateSubscription(ctx context.Context, client *gcp_pubsub.Client, to
 	return sub, err
 }
 
+// subscriber keeps track of the Pub/Sub client and the receivers, so they can be stopped by Shutdown.
+// Pub/Sub will drop any acks that happen after `Receive` returns, so we will keep receiving until the final flush is done.
+type subscriber struct {
+	client        *gcp_pubsub.Client
+	receiveCtx    context.Context
+	cancelReceive context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+var pubsubSubscriber *subscriber
+
+func (s *subscriber) Close() error {
+	s.cancelReceive()
+	s.wg.Wait()
+	return s.client.Close()
+}
+
+// StartSubscriber will return once ctx is done. At that point, new messages will be nacked and the receivers will
+// continue to run until Shutdown is called.
 func StartSubscriber(ctx context.Context) {
 	log := logger.FromContext(ctx)
 	settings := config.FromContext(ctx)
@@ -64,27 +88,44 @@ func StartSubscriber(ctx context.Context) {
 		log.Fatalf("failed to create a pubsub client, err: %v", clientErr)
 	}
 
+	receiveCtx, cancelReceive := context.WithCancel(context.Background())
+	pubsubSubscriber = &subscriber{
+		client:        client,
+		receiveCtx:    receiveCtx,
+		cancelReceive: cancelReceive,
+	}
+
 	tcFmtMap := NewTcFmtMap()
 	for _, topicConfig := range settings.Config.Pubsub.TopicConfigs {
 		tcFmtMap.Add(topicConfig.Topic, TopicConfigFormatter{
 			tc:     topicConfig,
-			Format: format.GetFormatParser(ctx, topicConfig.CDCFormat, topicConfig.Topic),
+			Format: format.GetFormatParser(ctx, topicConfig),
+			dlq: loadDeadLetterWriter(ctx, topicConfig, deadLetterArgs{
+				PubSubClient: client,
+			}),
 		})
 	}
 
-	var wg sync.WaitGroup
 	for _, topicConfig := range settings.Config.Pubsub.TopicConfigs {
-		wg.Add(1)
+		pubsubSubscriber.wg.Add(1)
 		go func(ctx context.Context, client *gcp_pubsub.Client, topic string) {
-			defer wg.Done()
+			defer pubsubSubscriber.wg.Done()
 			subName := fmt.Sprintf("transfer_%s", topic)
 			sub, err := findOrCreateSubscription(ctx, client, topic, subName)
 			if err != nil {
 				log.Fatalf("failed to find or create subscription, err: %v", err)
 			}
 
+			assigned.Store(true)
+
 			for {
-				err = sub.Receive(ctx, func(_ context.Context, pubsubMsg *gcp_pubsub.Message) {
+				err = sub.Receive(receiveCtx, func(_ context.Context, pubsubMsg *gcp_pubsub.Message) {
+					if ctx.Err() != nil {
+						// We are shutting down, so this message will be redelivered.
+						pubsubMsg.Nack()
+						return
+					}
+
 					msg := artie.NewMessage(nil, pubsubMsg, topic)
 					logFields := map[string]interface{}{
 						"topic": msg.Topic(),
@@ -101,18 +142,25 @@ func StartSubscriber(ctx context.Context) {
 
 					msg.EmitIngestionLag(ctx, subName, tableName)
 					if processErr != nil {
+						if errors.Is(processErr, errDeadLetterFailed) {
+							// The message has not been dead lettered, so it needs to be redelivered.
+							pubsubMsg.Nack()
+						}
+
 						log.WithError(processErr).WithFields(logFields).Warn("skipping message...")
 					}
 				})
 
+				if receiveCtx.Err() != nil {
+					return
+				}
+
 				if err != nil {
 					log.Fatalf("sub receive error, err: %v", err)
 				}
 			}
-
 		}(ctx, client, topicConfig.Topic)
-
 	}
 
-	wg.Wait()
+	<-ctx.Done()
 }
@@ -0,0 +1,105 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/logger"
+	"github.com/artie-labs/transfer/lib/telemetry/metrics"
+	"github.com/artie-labs/transfer/models"
+)
+
+// generationConsumer commits offsets through the consumer group generation the messages were read in.
+// Once the generation has ended, commits will fail and the new owner of the partition will re-read the messages.
+type generationConsumer struct {
+	group *kafka.ConsumerGroup
+	gen   *kafka.Generation
+}
+
+func (g *generationConsumer) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	return g.gen.CommitOffsets(toCommitOffsets(msgs...))
+}
+
+// Close will close the whole consumer group, this is safe to call more than once.
+func (g *generationConsumer) Close() error {
+	return g.group.Close()
+}
+
+// toCommitOffsets returns the offsets to commit for the messages, keyed by topic and partition.
+// The committed offset is the next message that the group should read, not the last message we have processed.
+func toCommitOffsets(msgs ...kafka.Message) map[string]map[int]int64 {
+	offsets := make(map[string]map[int]int64)
+	for _, msg := range msgs {
+		if _, isOk := offsets[msg.Topic]; !isOk {
+			offsets[msg.Topic] = make(map[int]int64)
+		}
+
+		offsets[msg.Topic][msg.Partition] = msg.Offset + 1
+	}
+
+	return offsets
+}
+
+// tablesWithPartitions returns the tables that are buffering messages from any of the topic's partitions.
+func tablesWithPartitions(ctx context.Context, assignments map[string][]kafka.PartitionAssignment) []string {
+	models.GetMemoryDB(ctx).RLock()
+	allTables := models.GetMemoryDB(ctx).TableData()
+	models.GetMemoryDB(ctx).RUnlock()
+
+	var tableKeys []string
+	for tableKey, tableData := range allTables {
+		tableData.Lock()
+		partialBatch := tableData.PartialBatch()
+		if (!tableData.Empty() && hasPartitions(tableData.PartitionsToLastMessage, assignments)) ||
+			(partialBatch != nil && hasPartitions(partialBatch.PartitionsToLastMessage, assignments)) {
+			tableKeys = append(tableKeys, tableKey)
+		}
+		tableData.Unlock()
+	}
+
+	return tableKeys
+}
+
+func hasPartitions(partitionsToLastMessage map[string][]artie.Message, assignments map[string][]kafka.PartitionAssignment) bool {
+	for topic, topicAssignments := range assignments {
+		for _, assignment := range topicAssignments {
+			if _, isOk := partitionsToLastMessage[artie.ToPartitionKey(topic, fmt.Sprint(assignment.ID))]; isOk {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// revokePartitions is called after we have stopped reading from the partitions of a generation that has ended.
+// Every table that has buffered rows from these partitions is flushed, and if that fails, the rows are discarded.
+// The new owner will start from the last committed offset, so keeping the rows around would result in them being merged twice.
+// The flush has to finish before the group's rebalance timeout, otherwise we'd be removed from the group and the commit would fail,
+// so the rest of the time is left for the commit and rejoining.
+func revokePartitions(ctx context.Context, assignments map[string][]kafka.PartitionAssignment) {
+	log := logger.FromContext(ctx)
+	flushCtx, cancel := context.WithTimeout(ctx, config.FromContext(ctx).Config.Kafka.RebalanceTimeout()*3/4)
+	defer cancel()
+	for _, tableKey := range tablesWithPartitions(ctx, assignments) {
+		if err := Flush(Args{Context: flushCtx, SpecificTable: tableKey, Reason: "partition_revoked"}); err != nil {
+			log.WithError(err).WithField("tableName", tableKey).Warn("failed to flush table on partition revocation")
+		}
+
+		tableData := models.GetMemoryDB(ctx).GetOrCreateTableData(tableKey)
+		tableData.Lock()
+		buffered := tableData.Buffered()
+		lastError := tableData.Status(tableKey).LastError
+		tableData.Unlock()
+		if buffered {
+			// The merge or the commit failed, e.g. we were removed from the group before the commit. Either way, the rows will be read again.
+			log.WithField("tableName", tableKey).WithField("lastError", lastError).Warn("failed to flush table on partition revocation, discarding buffered rows")
+			metrics.FromContext(ctx).Incr("partition_revoked.discarded", map[string]string{"table": tableKey})
+			models.GetMemoryDB(ctx).ClearTableConfig(tableKey)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/artie"
+	"github.com/artie-labs/transfer/lib/cdc/mongo"
+	"github.com/artie-labs/transfer/lib/config"
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/destination/utils"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+	"github.com/artie-labs/transfer/models"
+	"github.com/artie-labs/transfer/models/event"
+)
+
+func TestToCommitOffsets(t *testing.T) {
+	offsets := toCommitOffsets(
+		kafka.Message{Topic: "foo", Partition: 0, Offset: 5},
+		kafka.Message{Topic: "foo", Partition: 1, Offset: 0},
+		kafka.Message{Topic: "bar", Partition: 0, Offset: 9},
+	)
+
+	assert.Equal(t, map[string]map[int]int64{
+		"foo": {0: 6, 1: 1},
+		"bar": {0: 10},
+	}, offsets)
+}
+
+func (f *FlushTestSuite) saveToPartition(tableName string, partition int, offset int64) {
+	f.saveToPartitionWithConfig(topicConfig, tableName, partition, offset)
+}
+
+func (f *FlushTestSuite) saveToPartitionWithConfig(tc *kafkalib.TopicConfig, tableName string, partition int, offset int64) {
+	evt := event.Event{
+		Table: tableName,
+		PrimaryKeyMap: map[string]interface{}{
+			"id": fmt.Sprintf("pk-%d", offset),
+		},
+		Data: map[string]interface{}{
+			constants.DeleteColumnMarker: false,
+			"id":                         fmt.Sprintf("pk-%d", offset),
+		},
+	}
+
+	kafkaMsg := kafka.Message{Topic: tc.Topic, Partition: partition, Offset: offset}
+	_, _, err := evt.Save(f.ctx, tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.Nil(f.T(), err)
+}
+
+func (f *FlushTestSuite) TestRevokePartitions() {
+	f.saveToPartition("revoked", 1, 3)
+	f.saveToPartition("kept", 2, 7)
+
+	revoked := map[string][]kafka.PartitionAssignment{topicConfig.Topic: {{ID: 1}}}
+	assert.Equal(f.T(), []string{tableKey("revoked")}, tablesWithPartitions(f.ctx, revoked))
+	assert.Empty(f.T(), tablesWithPartitions(f.ctx, map[string][]kafka.PartitionAssignment{"other": {{ID: 1}}}))
+
+	revokePartitions(f.ctx, revoked)
+	assert.Equal(f.T(), 1, f.fakeConsumer.CommitMessagesCallCount())
+	_, kafkaMessages := f.fakeConsumer.CommitMessagesArgsForCall(0)
+	assert.Equal(f.T(), int64(3), kafkaMessages[0].Offset)
+	assert.True(f.T(), models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("revoked")).Empty())
+	assert.False(f.T(), models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("kept")).Empty())
+}
+
+func (f *FlushTestSuite) TestRevokePartitionsDeadline() {
+	dest := &fakeDestination{}
+	f.ctx = utils.InjectDestinationsIntoCtx([]utils.Destination{
+		{Name: "warehouse", Settings: &config.Settings{Config: &config.Config{Output: constants.Snowflake}}, Baseline: dest},
+	}, f.ctx)
+
+	tc := *topicConfig
+	tc.Destinations = []string{"warehouse"}
+	kafkaMsg := kafka.Message{Topic: tc.Topic, Partition: 1, Offset: 3}
+	evt := event.Event{
+		Table:         "revoked",
+		PrimaryKeyMap: map[string]interface{}{"id": "1"},
+		Data:          map[string]interface{}{constants.DeleteColumnMarker: false, "id": "1"},
+	}
+	_, _, err := evt.Save(f.ctx, &tc, artie.NewMessage(&kafkaMsg, nil, kafkaMsg.Topic))
+	assert.NoError(f.T(), err)
+
+	// The merge has to finish before the rebalance timeout, which defaults to 5 mins.
+	start := time.Now()
+	revokePartitions(f.ctx, map[string][]kafka.PartitionAssignment{tc.Topic: {{ID: 1}}})
+	assert.Len(f.T(), dest.deadlines, 1)
+	assert.WithinDuration(f.T(), start.Add(5*time.Minute*3/4), dest.deadlines[0], time.Second)
+	assert.Equal(f.T(), 1, f.fakeConsumer.CommitMessagesCallCount())
+}
+
+func (f *FlushTestSuite) TestRevokePartitionsCommitFail() {
+	f.fakeConsumer.CommitMessagesReturns(fmt.Errorf("generation has ended"))
+	f.saveToPartition("revoked", 1, 3)
+
+	// The rows could not be committed, so they have to be discarded rather than kept around for the next flush.
+	revokePartitions(f.ctx, map[string][]kafka.PartitionAssignment{topicConfig.Topic: {{ID: 1}}})
+	assert.Equal(f.T(), 1, f.fakeConsumer.CommitMessagesCallCount())
+	assert.True(f.T(), models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("revoked")).Empty())
+}
+
+func (f *FlushTestSuite) TestPartitionsConcurrently() {
+	// Partition workers save into the same table concurrently, each one in offset order.
+	var wg sync.WaitGroup
+	for partition := 0; partition < 4; partition++ {
+		wg.Add(1)
+		go func(partition int) {
+			defer wg.Done()
+			for offset := int64(0); offset < 25; offset++ {
+				f.saveToPartition("concurrent", partition, int64(partition*100)+offset)
+			}
+		}(partition)
+	}
+	wg.Wait()
+
+	td := models.GetMemoryDB(f.ctx).GetOrCreateTableData(tableKey("concurrent"))
+	assert.Equal(f.T(), uint(100), td.Rows())
+	assert.Len(f.T(), td.PartitionsToLastMessage, 4)
+	for partition := 0; partition < 4; partition++ {
+		msgs := td.PartitionsToLastMessage[artie.ToPartitionKey(topicConfig.Topic, fmt.Sprint(partition))]
+		assert.Len(f.T(), msgs, 1)
+		assert.Equal(f.T(), int64(partition*100+24), msgs[0].Offset())
+	}
+}
+
+type fakePartitionReader struct {
+	msgs         chan kafka.Message
+	offset       int64
+	setOffsetErr error
+}
+
+func (f *fakePartitionReader) SetOffset(offset int64) error {
+	f.offset = offset
+	return f.setOffsetErr
+}
+
+func (f *fakePartitionReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case msg := <-f.msgs:
+		return msg, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (f *fakePartitionReader) Close() error {
+	return nil
+}
+
+type fakeGeneration struct {
+	ctx  context.Context
+	done chan struct{}
+}
+
+func (f *fakeGeneration) Start(fn func(ctx context.Context)) {
+	go func() {
+		defer close(f.done)
+		fn(f.ctx)
+	}()
+}
+
+func customerMessage(partition int, offset int64) kafka.Message {
+	id := partition*100 + int(offset)
+	return kafka.Message{
+		Topic:     topicConfig.Topic,
+		Partition: partition,
+		Offset:    offset,
+		Key:       []byte(fmt.Sprintf("Struct{id=%d}", id)),
+		Value: []byte(fmt.Sprintf(`{
+	"schema": {},
+	"payload": {
+		"after": "{\"_id\": {\"$numberLong\": \"%d\"},\"name\": \"foo\"}",
+		"source": {"connector": "mongodb", "ts_ms": 1668753321000, "db": "inventory", "collection": "customers"},
+		"op": "c"
+	}
+}`, id)),
+	}
+}
+
+func (f *FlushTestSuite) TestConsumeGeneration() {
+	var mgo mongo.Debezium
+	tcFmtMap := NewTcFmtMap()
+	tcFmtMap.Add(topicConfig.Topic, TopicConfigFormatter{
+		tc: &kafkalib.TopicConfig{
+			Database:     "db",
+			Schema:       "public",
+			Topic:        topicConfig.Topic,
+			CDCKeyFormat: "org.apache.kafka.connect.storage.StringConverter",
+		},
+		Format: &mgo,
+	})
+
+	readers := map[int]*fakePartitionReader{
+		0: {msgs: make(chan kafka.Message)},
+		1: {msgs: make(chan kafka.Message)},
+	}
+
+	genCtx, endGeneration := context.WithCancel(f.ctx)
+	gen := &fakeGeneration{ctx: genCtx, done: make(chan struct{})}
+	var wg sync.WaitGroup
+	consumeGeneration(f.ctx, generationArgs{
+		Gen:         gen,
+		Assignments: map[string][]kafka.PartitionAssignment{topicConfig.Topic: {{ID: 0, Offset: 3}, {ID: 1, Offset: 7}}},
+		GroupID:     "group",
+		TcFmtMap:    tcFmtMap,
+		NewReader: func(topic string, partition int) partitionReader {
+			assert.Equal(f.T(), topicConfig.Topic, topic)
+			return readers[partition]
+		},
+	}, &wg)
+
+	td := models.GetMemoryDB(f.ctx).GetOrCreateTableData(models.TableKey("db", "public", "customers"))
+	lastOffset := func(partition int) int64 {
+		td.Lock()
+		defer td.Unlock()
+		msgs := td.PartitionsToLastMessage[artie.ToPartitionKey(topicConfig.Topic, fmt.Sprint(partition))]
+		if len(msgs) == 0 {
+			return -1
+		}
+
+		return msgs[0].Offset()
+	}
+
+	// Partition 1 makes progress while partition 0 has nothing to read.
+	for offset := int64(7); offset < 12; offset++ {
+		readers[1].msgs <- customerMessage(1, offset)
+	}
+	assert.Eventually(f.T(), func() bool { return lastOffset(1) == 11 }, time.Second, 10*time.Millisecond)
+	assert.Equal(f.T(), int64(-1), lastOffset(0))
+
+	for offset := int64(3); offset < 5; offset++ {
+		readers[0].msgs <- customerMessage(0, offset)
+	}
+	assert.Eventually(f.T(), func() bool { return lastOffset(0) == 4 }, time.Second, 10*time.Millisecond)
+
+	// Each worker starts from the partition's committed offset.
+	assert.Equal(f.T(), int64(3), readers[0].offset)
+	assert.Equal(f.T(), int64(7), readers[1].offset)
+
+	// Once the generation ends, the workers stop and the revoked partitions are flushed and committed.
+	endGeneration()
+	<-gen.done
+	wg.Wait()
+	assert.True(f.T(), td.Empty())
+
+	committed := make(map[int]int64)
+	for i := 0; i < f.fakeConsumer.CommitMessagesCallCount(); i++ {
+		_, kafkaMessages := f.fakeConsumer.CommitMessagesArgsForCall(i)
+		for _, kafkaMessage := range kafkaMessages {
+			committed[kafkaMessage.Partition] = kafkaMessage.Offset
+		}
+	}
+
+	// Messages are processed in order within each partition, so the last message is committed.
+	assert.Equal(f.T(), map[int]int64{0: 4, 1: 11}, committed)
+}
+
+func (f *FlushTestSuite) TestConsumeGeneration_SetOffsetFailure() {
+	readers := map[int]*fakePartitionReader{
+		0: {msgs: make(chan kafka.Message), setOffsetErr: fmt.Errorf("offset out of range")},
+		1: {msgs: make(chan kafka.Message)},
+	}
+
+	genCtx, cancelGeneration := context.WithCancel(f.ctx)
+	gen := &fakeGeneration{ctx: genCtx, done: make(chan struct{})}
+	var wg sync.WaitGroup
+	// Rather than exiting, the generation is ended so that the group is rejoined.
+	consumeGeneration(f.ctx, generationArgs{
+		Gen:         gen,
+		Assignments: map[string][]kafka.PartitionAssignment{topicConfig.Topic: {{ID: 0, Offset: 3}, {ID: 1, Offset: 7}}},
+		GroupID:     "group",
+		TcFmtMap:    NewTcFmtMap(),
+		NewReader: func(_ string, partition int) partitionReader {
+			return readers[partition]
+		},
+		EndGeneration: cancelGeneration,
+	}, &wg)
+
+	select {
+	case <-gen.done:
+	case <-time.After(time.Second):
+		assert.FailNow(f.T(), "generation did not end")
+	}
+
+	// The other partition's worker is stopped as well.
+	wg.Wait()
+	assert.Equal(f.T(), int64(7), readers[1].offset)
+}
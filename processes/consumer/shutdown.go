@@ -0,0 +1,34 @@
+package consumer
+
+import (
+	"context"
+
+	"github.com/artie-labs/transfer/lib/logger"
+)
+
+// Shutdown should be called once the consumers have stopped fetching.
+// It will run a final flush across every table so that the buffered rows are merged and their offsets are committed.
+// Then, it will close the dead-letter queues, the Kafka readers and the Pub/Sub client.
+func Shutdown(ctx context.Context) {
+	log := logger.FromContext(ctx)
+	if err := Flush(Args{Context: ctx, Reason: "shutdown"}); err != nil {
+		log.WithError(err).Warn("failed to flush during shutdown")
+	}
+
+	// The Pub/Sub dead-letter queues publish with the Pub/Sub client, so they're closed first.
+	if err := closeDeadLetterWriters(); err != nil {
+		log.WithError(err).Warn("failed to close dead letter queues")
+	}
+
+	if topicToConsumer != nil {
+		if err := topicToConsumer.Close(); err != nil {
+			log.WithError(err).Warn("failed to close kafka consumers")
+		}
+	}
+
+	if pubsubSubscriber != nil {
+		if err := pubsubSubscriber.Close(); err != nil {
+			log.WithError(err).Warn("failed to close pubsub client")
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package consumer
+
+import "sync/atomic"
+
+// assigned is set once the consumer has something to consume, this is reported by the admin server's readiness check.
+var assigned atomic.Bool
+
+// Assigned returns whether the consumer has been assigned Kafka partitions, Pub/Sub subscriptions, Kinesis shards or files to read.
+func Assigned() bool {
+	return assigned.Load()
+}
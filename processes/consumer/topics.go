@@ -0,0 +1,102 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/artie-labs/transfer/lib/kafkalib"
+)
+
+// discoverTopics returns every topic that the brokers know about, we only need one of the brokers to respond.
+func discoverTopics(ctx context.Context, dialer *kafka.Dialer, brokers []string) ([]string, error) {
+	var err error
+	for _, broker := range brokers {
+		var conn *kafka.Conn
+		conn, err = dialer.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			continue
+		}
+
+		var partitions []kafka.Partition
+		partitions, err = conn.ReadPartitions()
+		conn.Close()
+		if err != nil {
+			continue
+		}
+
+		var topics []string
+		seen := make(map[string]bool)
+		for _, partition := range partitions {
+			if !seen[partition.Topic] {
+				seen[partition.Topic] = true
+				topics = append(topics, partition.Topic)
+			}
+		}
+
+		return topics, nil
+	}
+
+	return nil, fmt.Errorf("failed to read topics from brokers: %v, err: %v", brokers, err)
+}
+
+// resolveTopicConfigs returns the topic configs that we should subscribe to, sorted by topic.
+// Explicit topic configs are always included and take precedence over patterns. Otherwise, the first pattern that matches a topic wins.
+// Internal topics and dead-letter queue topics are never matched, so a broad pattern will not consume its own DLQ.
+func resolveTopicConfigs(tcs []*kafkalib.TopicConfig, patterns []*kafkalib.TopicPattern, topics []string) []*kafkalib.TopicConfig {
+	topicToConfig := make(map[string]*kafkalib.TopicConfig)
+	excluded := make(map[string]bool)
+	for _, tc := range tcs {
+		topicToConfig[tc.Topic] = tc
+		if tc.DeadLetterQueue != nil && tc.DeadLetterQueue.Kind == kafkalib.DeadLetterQueueKafka {
+			excluded[tc.DeadLetterQueue.Topic] = true
+		}
+	}
+
+	for _, pattern := range patterns {
+		if pattern.DeadLetterQueue != nil && pattern.DeadLetterQueue.Kind == kafkalib.DeadLetterQueueKafka {
+			excluded[pattern.DeadLetterQueue.Topic] = true
+		}
+	}
+
+	for _, topic := range topics {
+		if _, isOk := topicToConfig[topic]; isOk || excluded[topic] || strings.HasPrefix(topic, "__") {
+			continue
+		}
+
+		for _, pattern := range patterns {
+			if tc, isOk := pattern.Match(topic); isOk {
+				topicToConfig[topic] = tc
+				break
+			}
+		}
+	}
+
+	var resolved []*kafkalib.TopicConfig
+	for _, tc := range topicToConfig {
+		resolved = append(resolved, tc)
+	}
+
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].Topic < resolved[j].Topic
+	})
+
+	return resolved
+}
+
+func sameTopics(a, b []*kafkalib.TopicConfig) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Topic != b[i].Topic {
+			return false
+		}
+	}
+
+	return true
+}
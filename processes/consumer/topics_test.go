@@ -0,0 +1,82 @@
+package consumer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/artie-labs/transfer/lib/config/constants"
+	"github.com/artie-labs/transfer/lib/kafkalib"
+)
+
+func TestResolveTopicConfigs(t *testing.T) {
+	explicit := &kafkalib.TopicConfig{
+		Database:  "db",
+		Schema:    "public",
+		TableName: "customers_override",
+		Topic:     "dbserver.public.customers",
+		CDCFormat: constants.DBZPostgresFormat,
+	}
+
+	publicPattern := &kafkalib.TopicPattern{
+		Pattern: `^dbserver\.public\.(\w+)$`,
+		TopicConfig: kafkalib.TopicConfig{
+			Database:  "db",
+			Schema:    "public",
+			TableName: "$1",
+			CDCFormat: constants.DBZPostgresFormat,
+			DeadLetterQueue: &kafkalib.DeadLetterQueue{
+				Kind:  kafkalib.DeadLetterQueueKafka,
+				Topic: "dbserver.public.dlq",
+			},
+		},
+	}
+
+	catchAllPattern := &kafkalib.TopicPattern{
+		Pattern: `^dbserver\.(\w+)\.(\w+)$`,
+		TopicConfig: kafkalib.TopicConfig{
+			Database:  "db",
+			Schema:    "$1",
+			CDCFormat: constants.DBZPostgresFormat,
+		},
+	}
+
+	assert.True(t, publicPattern.Valid())
+	assert.True(t, catchAllPattern.Valid())
+
+	tcs := resolveTopicConfigs([]*kafkalib.TopicConfig{explicit}, []*kafkalib.TopicPattern{publicPattern, catchAllPattern}, []string{
+		"dbserver.public.orders",
+		"dbserver.public.customers",
+		"dbserver.public.dlq",
+		"dbserver.inventory.products",
+		"__consumer_offsets",
+		"unrelated",
+	})
+
+	var topics []string
+	for _, tc := range tcs {
+		topics = append(topics, tc.Topic)
+	}
+	assert.Equal(t, []string{"dbserver.inventory.products", "dbserver.public.customers", "dbserver.public.orders"}, topics)
+
+	// Explicit topic config wins.
+	assert.Equal(t, explicit, tcs[1])
+	// First pattern wins.
+	assert.Equal(t, "orders", tcs[2].TableName)
+	assert.Equal(t, "inventory", tcs[0].Schema)
+	assert.Equal(t, "", tcs[0].TableName)
+
+	// Explicit topic configs are included even if the topic has not been discovered.
+	tcs = resolveTopicConfigs([]*kafkalib.TopicConfig{explicit}, nil, nil)
+	assert.Equal(t, []*kafkalib.TopicConfig{explicit}, tcs)
+
+	assert.Empty(t, resolveTopicConfigs(nil, []*kafkalib.TopicPattern{publicPattern}, []string{"unrelated"}))
+}
+
+func TestSameTopics(t *testing.T) {
+	a := []*kafkalib.TopicConfig{{Topic: "a"}, {Topic: "b"}}
+	assert.True(t, sameTopics(a, []*kafkalib.TopicConfig{{Topic: "a"}, {Topic: "b"}}))
+	assert.False(t, sameTopics(a, []*kafkalib.TopicConfig{{Topic: "a"}}))
+	assert.False(t, sameTopics(a, []*kafkalib.TopicConfig{{Topic: "a"}, {Topic: "c"}}))
+	assert.True(t, sameTopics(nil, nil))
+}
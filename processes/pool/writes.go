@@ -15,11 +15,19 @@ func StartPool(ctx context.Context, td time.Duration) {
 	log := logger.FromContext(ctx)
 	log.Info("Starting pool timer...")
 	ticker := time.NewTicker(td)
-	for range ticker.C {
-		log.WithError(consumer.Flush(consumer.Args{
-			Reason:   "time",
-			Context:  ctx,
-			CoolDown: ptr.ToDuration(td),
-		})).Info("Flushing via pool...")
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// The final flush will be done by consumer.Shutdown
+			log.Info("Stopping pool timer...")
+			return
+		case <-ticker.C:
+			log.WithError(consumer.Flush(consumer.Args{
+				Reason:   "time",
+				Context:  ctx,
+				CoolDown: ptr.ToDuration(td),
+			})).Info("Flushing via pool...")
+		}
 	}
 }